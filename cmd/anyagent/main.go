@@ -1,22 +1,38 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/shibukawa/anyagent/internal/commands"
-	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/pkg/anyagent"
 )
 
 // CLI represents the command line interface structure
 type CLI struct {
-	Init   InitCmd   `cmd:"" help:"Prepare user template environment (~/.anyagent) and open in VSCode"`
-	Sync   SyncCmd   `cmd:"" help:"Initialize/sync project from user templates; prompts for missing placeholders"`
-	Add    AddCmd    `cmd:"" help:"Add additional configurations to the project"`
-	Remove RemoveCmd `cmd:"" help:"Remove configurations from the project"`
-	List   ListCmd   `cmd:"" help:"List configuration status for the project"`
-	Switch SwitchCmd `cmd:"" help:"Switch active AI agent for the project"`
+	Output  string `help:"Output format: text, or json for a single result document on stdout" enum:"text,json" default:"text" short:"o"`
+	Quiet   bool   `help:"Print only results, warnings and errors" short:"q" xor:"verbosity"`
+	Verbose bool   `help:"Also print details such as resolved paths" short:"v" xor:"verbosity"`
+	NoEmoji bool   `help:"Do not start messages with emoji" name:"no-emoji"`
+	NoColor bool   `help:"Do not color output (also disabled by NO_COLOR)" name:"no-color"`
+
+	Init     InitCmd     `cmd:"" help:"Prepare user template environment (~/.anyagent) and open in VSCode"`
+	Sync     SyncCmd     `cmd:"" help:"Initialize/sync project from user templates; prompts for missing placeholders"`
+	Add      AddCmd      `cmd:"" help:"Add additional configurations to the project"`
+	Remove   RemoveCmd   `cmd:"" help:"Remove configurations from the project"`
+	List     ListCmd     `cmd:"" help:"List configuration status for the project"`
+	Switch   SwitchCmd   `cmd:"" help:"Switch active AI agent for the project"`
+	Mcp      MCPCmd      `cmd:"" help:"Inspect the project's MCP servers"`
+	Import   ImportCmd   `cmd:"" help:"Import existing agent configurations into the project"`
+	Projects ProjectsCmd `cmd:"" help:"Manage the registry of synced projects"`
+	Adopt    AdoptCmd    `cmd:"" help:"Create .anyagent from existing CLAUDE.md, Copilot instructions, .cursorrules and commands"`
+	Status   StatusCmd   `cmd:"" help:"Show the project's agents, installed items, workspaces and last sync"`
 }
 
 // InitCmd represents the init command (template editing environment)
@@ -31,7 +47,9 @@ type SyncCmd struct {
 	ProjectDir string   `arg:"" optional:"" help:"Project directory (default: current directory)"`
 	Agents     []string `help:"AI agents to configure (copilot,qdev,claude,gemini,codex)" short:"a"`
 	DryRun     bool     `help:"Show what would be done without actually doing it" short:"n"`
-	Force      bool     `help:"Force re-distribute user templates to .anyagent (overwrite if exists)" short:"f"`
+	Force      bool     `help:"Force re-distribute user templates to .anyagent (overwrite if exists); with --all, only overwrite generated files edited by hand" short:"f"`
+	All        bool     `help:"Re-sync every registered project (see 'anyagent projects list')"`
+	Watch      bool     `help:"Keep running and regenerate the affected files when .anyagent/, the user templates or config.yaml change" short:"w"`
 }
 
 // AddCmd represents the add command with subcommands
@@ -39,18 +57,26 @@ type AddCmd struct {
 	Rule    AddRuleCmd    `cmd:"" help:"Add language-specific rules to the project"`
 	Command AddCommandCmd `cmd:"" help:"Add VS Code Copilot prompt commands to the project"`
 	Mcp     AddMCPCmd     `cmd:"" help:"Add MCP server definition and project wiring"`
+	Persona AddPersonaCmd `cmd:"" help:"Add a persona (Claude subagent, Copilot chat mode) to the project"`
+	Hook    AddHookCmd    `cmd:"" help:"Add a lifecycle hook (Claude, Gemini settings) to the project"`
 }
 
 // RemoveCmd represents the remove command with subcommands
 type RemoveCmd struct {
 	Rule    RemoveRuleCmd    `cmd:"" help:"Remove language-specific rules from the project"`
 	Command RemoveCommandCmd `cmd:"" help:"Remove VS Code Copilot prompt commands from the project"`
+	Persona RemovePersonaCmd `cmd:"" help:"Remove a persona from the project"`
+	Hook    RemoveHookCmd    `cmd:"" help:"Remove a lifecycle hook from the project"`
+	Mcp     RemoveMCPCmd     `cmd:"" help:"Remove an MCP server from the project and agent configs"`
 }
 
 // ListCmd represents the list command with subcommands
 type ListCmd struct {
 	Rule    ListRuleCmd    `cmd:"" help:"List language-specific rules status"`
 	Command ListCommandCmd `cmd:"" help:"List VS Code Copilot prompt commands status"`
+	Persona ListPersonaCmd `cmd:"" help:"List personas status"`
+	Hook    ListHookCmd    `cmd:"" help:"List hooks status"`
+	Mcp     ListMCPCmd     `cmd:"" help:"List MCP servers and per-agent install status"`
 }
 
 // AddRuleCmd represents the add rule subcommand
@@ -71,11 +97,40 @@ type AddCommandCmd struct {
 
 // AddMCPCmd represents the add mcp subcommand
 type AddMCPCmd struct {
-	Name       string `arg:"" help:"MCP server name (e.g., postgres, filesystem)"`
-	Cmd        string `help:"Command to launch the MCP server" required:""`
+	Name           string   `arg:"" optional:"" help:"MCP server name (e.g., postgres, filesystem); defaults to the preset id"`
+	Preset         string   `help:"Fill in the server from the MCP catalog (see --list-presets)" short:"p"`
+	ListPresets    bool     `help:"List the MCP server catalog" name:"list-presets"`
+	Cmd            string   `help:"Command to launch a local (stdio) MCP server; quoted with shell rules, no pipes or $()"`
+	Arg            []string `help:"Extra argument appended verbatim after --cmd (repeatable)" sep:"none"`
+	URL            string   `help:"URL of a remote (http/sse) MCP server" name:"url"`
+	Transport      string   `help:"Transport (stdio, http, sse); inferred from --cmd/--url when omitted"`
+	Env            []string `help:"Environment variable for the server as KEY=VALUE (repeatable)" short:"e" sep:"none"`
+	Header         []string `help:"HTTP header for remote servers as KEY=VALUE (repeatable)" short:"H" sep:"none"`
+	StartupTimeout int      `help:"Seconds to wait for the server to start (Codex)" name:"startup-timeout"`
+	ToolTimeout    int      `help:"Seconds to wait for a tool call (Codex, Gemini)" name:"tool-timeout"`
+	Agent          []string `help:"Only write the server for these agents (repeatable)"`
+	ExcludeAgent   []string `help:"Never write the server for these agents (repeatable)"`
+	Disable        bool     `help:"Record the server without writing it to any agent config"`
+	ProjectDir     string   `help:"Project directory (default: current directory)" short:"d"`
+	DryRun         bool     `help:"Show what would be done without actually doing it" short:"n"`
+	Global         bool     `help:"Install to user-global location when applicable (Codex)"`
+	Force          bool     `help:"Store values that look like secrets instead of refusing them" short:"f"`
+}
+
+// AddPersonaCmd represents the add persona subcommand
+type AddPersonaCmd struct {
+	Persona    string `arg:"" optional:"" help:"Persona name to add (e.g., reviewer)"`
 	ProjectDir string `help:"Project directory (default: current directory)" short:"d"`
 	DryRun     bool   `help:"Show what would be done without actually doing it" short:"n"`
-	Global     bool   `help:"Install to user-global location when applicable (Codex)"`
+	List       bool   `help:"List available personas" short:"l"`
+}
+
+// AddHookCmd represents the add hook subcommand
+type AddHookCmd struct {
+	Hook       string `arg:"" optional:"" help:"Hook name to add (e.g., gofmt)"`
+	ProjectDir string `help:"Project directory (default: current directory)" short:"d"`
+	DryRun     bool   `help:"Show what would be done without actually doing it" short:"n"`
+	List       bool   `help:"List available hooks" short:"l"`
 }
 
 // RemoveRuleCmd represents the remove rule subcommand
@@ -92,6 +147,27 @@ type RemoveCommandCmd struct {
 	DryRun     bool   `help:"Show what would be done without actually doing it" short:"n"`
 }
 
+// RemovePersonaCmd represents the remove persona subcommand
+type RemovePersonaCmd struct {
+	Persona    string `arg:"" help:"Persona name to remove (e.g., reviewer)"`
+	ProjectDir string `help:"Project directory (default: current directory)" short:"d"`
+	DryRun     bool   `help:"Show what would be done without actually doing it" short:"n"`
+}
+
+// RemoveHookCmd represents the remove hook subcommand
+type RemoveHookCmd struct {
+	Hook       string `arg:"" help:"Hook name to remove (e.g., gofmt)"`
+	ProjectDir string `help:"Project directory (default: current directory)" short:"d"`
+	DryRun     bool   `help:"Show what would be done without actually doing it" short:"n"`
+}
+
+// RemoveMCPCmd represents the remove mcp subcommand
+type RemoveMCPCmd struct {
+	Name       string `arg:"" help:"MCP server name to remove"`
+	ProjectDir string `help:"Project directory (default: current directory)" short:"d"`
+	DryRun     bool   `help:"Show what would be done without actually doing it" short:"n"`
+}
+
 // ListRuleCmd represents the list rule subcommand
 type ListRuleCmd struct {
 	ProjectDir string `help:"Project directory (default: current directory)" short:"d"`
@@ -102,6 +178,73 @@ type ListCommandCmd struct {
 	ProjectDir string `help:"Project directory (default: current directory)" short:"d"`
 }
 
+// ListPersonaCmd represents the list persona subcommand
+type ListPersonaCmd struct {
+	ProjectDir string `help:"Project directory (default: current directory)" short:"d"`
+}
+
+// ListHookCmd represents the list hook subcommand
+type ListHookCmd struct {
+	ProjectDir string `help:"Project directory (default: current directory)" short:"d"`
+}
+
+// ListMCPCmd represents the list mcp subcommand
+type ListMCPCmd struct {
+	ProjectDir string `help:"Project directory (default: current directory)" short:"d"`
+}
+
+// MCPCmd represents the mcp command with subcommands
+type MCPCmd struct {
+	Check MCPCheckCmd `cmd:"" help:"Launch stdio MCP servers and verify the initialize handshake and tools/list"`
+}
+
+// MCPCheckCmd represents the mcp check subcommand
+type MCPCheckCmd struct {
+	Names      []string      `arg:"" optional:"" help:"MCP server names to check (default: all)"`
+	ProjectDir string        `help:"Project directory (default: current directory)" short:"d"`
+	Timeout    time.Duration `help:"Time allowed for each server to answer" default:"10s"`
+}
+
+// ImportCmd represents the import command with subcommands
+type ImportCmd struct {
+	Mcp ImportMCPCmd `cmd:"" help:"Import MCP servers from .vscode/mcp.json, .mcp.json, .cursor/mcp.json or Codex config.toml"`
+}
+
+// ImportMCPCmd represents the import mcp subcommand
+type ImportMCPCmd struct {
+	From       string `help:"Import only from this agent (copilot, vscode, claude, cursor, gemini, qdev, codex) or file"`
+	ProjectDir string `help:"Project directory (default: current directory)" short:"d"`
+	DryRun     bool   `help:"Show what would be done without actually doing it" short:"n"`
+	Force      bool   `help:"Replace existing servers whose definitions differ" short:"f"`
+}
+
+// AdoptCmd represents the adopt command
+type AdoptCmd struct {
+	ProjectDir string `help:"Project directory (default: current directory)" short:"d"`
+	Agent      string `help:"Agent to enable (copilot,qdev,claude,gemini,codex); inferred from the files when omitted" short:"a"`
+	DryRun     bool   `help:"Show what would be done without actually doing it" short:"n"`
+	Force      bool   `help:"Adopt again even if .anyagent/config.yaml exists" short:"f"`
+}
+
+// ProjectsCmd represents the projects command with subcommands
+type ProjectsCmd struct {
+	List  ProjectsListCmd  `cmd:"" help:"List the projects that have been synced on this machine"`
+	Prune ProjectsPruneCmd `cmd:"" help:"Remove projects whose directory no longer exists from the registry"`
+}
+
+// ProjectsListCmd represents the projects list subcommand
+type ProjectsListCmd struct{}
+
+// ProjectsPruneCmd represents the projects prune subcommand
+type ProjectsPruneCmd struct {
+	DryRun bool `help:"Show what would be done without actually doing it" short:"n"`
+}
+
+// StatusCmd represents the status command
+type StatusCmd struct {
+	ProjectDir string `help:"Project directory (default: current directory)" short:"d"`
+}
+
 // SwitchCmd represents the switch command
 type SwitchCmd struct {
 	ProjectDir string `help:"Project directory (default: current directory)" short:"d"`
@@ -110,70 +253,246 @@ type SwitchCmd struct {
 }
 
 // Run executes the init command (template editing environment)
-func (cmd *InitCmd) Run() error {
-	// Get user config directory
-	userConfigDir, err := config.GetUserConfigDir()
+func (cmd *InitCmd) Run(opts anyagent.Options, out *report) error {
+	res, err := anyagent.EditTemplates(context.Background(), opts, anyagent.EditTemplatesOptions{Force: cmd.Force || cmd.HardReset})
 	if err != nil {
-		return fmt.Errorf("failed to get user config directory: %w", err)
+		return out.record(res, err)
 	}
-
-	fmt.Printf("Using config directory: %s\n", userConfigDir)
-
-	// Run the edit template functionality
-	return commands.RunEditTemplate(userConfigDir, cmd.DryRun, cmd.Force || cmd.HardReset)
+	templates, _ := res.Data.(*anyagent.Templates)
+	if templates == nil {
+		return out.record(res, fmt.Errorf("the template directory was not reported"))
+	}
+	return out.record(res, openTemplates(opts, res, templates.Dir, cmd.DryRun))
 }
 
 // Run executes the sync command (project initialization/sync)
-func (cmd *SyncCmd) Run() error {
-	return commands.RunSyncWithOptions(cmd.ProjectDir, cmd.Agents, cmd.DryRun, cmd.Force)
+func (cmd *SyncCmd) Run(opts anyagent.Options, out *report) error {
+	if cmd.All {
+		if cmd.ProjectDir != "" || len(cmd.Agents) > 0 || cmd.Watch {
+			return fmt.Errorf("--all cannot be combined with a project directory, --agents or --watch")
+		}
+		return out.record(anyagent.SyncAll(context.Background(), opts, anyagent.SyncAllOptions{DryRun: cmd.DryRun, Force: cmd.Force}))
+	}
+	if cmd.Watch && cmd.DryRun {
+		return fmt.Errorf("--watch cannot be combined with --dry-run")
+	}
+	ctx := context.Background()
+	p, err := anyagent.Load(ctx, cmd.ProjectDir, opts)
+	if err != nil {
+		return err
+	}
+	if cmd.Watch {
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+		return p.Watch(ctx, anyagent.WatchOptions{Agents: cmd.Agents, Force: cmd.Force})
+	}
+	res, err := p.Sync(ctx, anyagent.SyncOptions{Agents: cmd.Agents, DryRun: cmd.DryRun, Force: cmd.Force})
+	if res == nil {
+		return out.record(nil, err)
+	}
+	result := res.Result
+	result.Data = res
+	return out.record(&result, err)
 }
 
 // Run executes the add rule command
-func (cmd *AddRuleCmd) Run() error {
-	return commands.RunAddRule(cmd.Language, cmd.ProjectDir, cmd.DryRun)
+func (cmd *AddRuleCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.AddRule(ctx, cmd.Language, anyagent.AddOptions{DryRun: cmd.DryRun})
+	})
 }
 
 // Run executes the add command subcommand
-func (cmd *AddCommandCmd) Run() error {
-	if cmd.List {
-		return commands.ListAvailableCommands()
-	}
+func (cmd *AddCommandCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		if cmd.List || cmd.Command == "" {
+			return p.AvailableCommands(ctx)
+		}
+		return p.AddCommand(ctx, cmd.Command, anyagent.AddCommandOptions{DryRun: cmd.DryRun, Global: cmd.Global})
+	})
+}
 
-	if cmd.Command == "" {
-		return commands.ListAvailableCommands()
-	}
+// Run executes the add mcp subcommand
+func (cmd *AddMCPCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		if cmd.ListPresets {
+			return p.MCPPresets(ctx)
+		}
+		env, err := commands.ParseKeyValues(cmd.Env, "--env")
+		if err != nil {
+			return nil, err
+		}
+		headers, err := commands.ParseKeyValues(cmd.Header, "--header")
+		if err != nil {
+			return nil, err
+		}
+		return p.AddMCP(ctx, anyagent.MCPServer{
+			Name:           cmd.Name,
+			Preset:         cmd.Preset,
+			Command:        cmd.Cmd,
+			Args:           cmd.Arg,
+			Env:            env,
+			URL:            cmd.URL,
+			Headers:        headers,
+			Transport:      cmd.Transport,
+			StartupTimeout: cmd.StartupTimeout,
+			ToolTimeout:    cmd.ToolTimeout,
+			Agents:         cmd.Agent,
+			ExcludeAgents:  cmd.ExcludeAgent,
+			Disabled:       cmd.Disable,
+		}, anyagent.AddMCPOptions{DryRun: cmd.DryRun, Global: cmd.Global, Force: cmd.Force})
+	})
+}
 
-	return commands.RunAddCommand(cmd.Command, cmd.ProjectDir, cmd.DryRun, cmd.Global)
+// Run executes the add persona subcommand
+func (cmd *AddPersonaCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		if cmd.List || cmd.Persona == "" {
+			return p.AvailablePersonas(ctx)
+		}
+		return p.AddPersona(ctx, cmd.Persona, anyagent.AddOptions{DryRun: cmd.DryRun})
+	})
 }
 
-// Run executes the add mcp subcommand
-func (cmd *AddMCPCmd) Run() error {
-	return commands.RunAddMCP(cmd.Name, cmd.Cmd, cmd.ProjectDir, cmd.DryRun, cmd.Global)
+// Run executes the add hook subcommand
+func (cmd *AddHookCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		if cmd.List || cmd.Hook == "" {
+			return p.AvailableHooks(ctx)
+		}
+		return p.AddHook(ctx, cmd.Hook, anyagent.AddOptions{DryRun: cmd.DryRun})
+	})
 }
 
 // Run executes the remove rule command
-func (cmd *RemoveRuleCmd) Run() error {
-	return commands.RunRemoveRule(cmd.Language, cmd.ProjectDir, cmd.DryRun)
+func (cmd *RemoveRuleCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.RemoveRule(ctx, cmd.Language, anyagent.RemoveOptions{DryRun: cmd.DryRun})
+	})
 }
 
 // Run executes the remove command subcommand
-func (cmd *RemoveCommandCmd) Run() error {
-	return commands.RunRemoveCommand(cmd.Command, cmd.ProjectDir, cmd.DryRun)
+func (cmd *RemoveCommandCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.RemoveCommand(ctx, cmd.Command, anyagent.RemoveOptions{DryRun: cmd.DryRun})
+	})
+}
+
+// Run executes the remove persona subcommand
+func (cmd *RemovePersonaCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.RemovePersona(ctx, cmd.Persona, anyagent.RemoveOptions{DryRun: cmd.DryRun})
+	})
+}
+
+// Run executes the remove hook subcommand
+func (cmd *RemoveHookCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.RemoveHook(ctx, cmd.Hook, anyagent.RemoveOptions{DryRun: cmd.DryRun})
+	})
+}
+
+// Run executes the remove mcp subcommand
+func (cmd *RemoveMCPCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.RemoveMCP(ctx, cmd.Name, anyagent.RemoveOptions{DryRun: cmd.DryRun})
+	})
 }
 
 // Run executes the list rule command
-func (cmd *ListRuleCmd) Run() error {
-	return commands.RunListRules(cmd.ProjectDir)
+func (cmd *ListRuleCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.ListRules(ctx)
+	})
 }
 
 // Run executes the list command subcommand
-func (cmd *ListCommandCmd) Run() error {
-	return commands.RunListCommands(cmd.ProjectDir)
+func (cmd *ListCommandCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.ListCommands(ctx)
+	})
+}
+
+// Run executes the list persona subcommand
+func (cmd *ListPersonaCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.ListPersonas(ctx)
+	})
+}
+
+// Run executes the list hook subcommand
+func (cmd *ListHookCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.ListHooks(ctx)
+	})
+}
+
+// Run executes the list mcp subcommand
+func (cmd *ListMCPCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.ListMCP(ctx)
+	})
+}
+
+// Run executes the mcp check subcommand
+func (cmd *MCPCheckCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.CheckMCP(ctx, anyagent.CheckMCPOptions{Names: cmd.Names, Timeout: cmd.Timeout})
+	})
+}
+
+// Run executes the import mcp subcommand
+func (cmd *ImportMCPCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.ImportMCP(ctx, anyagent.ImportMCPOptions{From: cmd.From, DryRun: cmd.DryRun, Force: cmd.Force})
+	})
+}
+
+// Run executes the adopt command
+func (cmd *AdoptCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.Adopt(ctx, anyagent.AdoptOptions{Agent: cmd.Agent, DryRun: cmd.DryRun, Force: cmd.Force})
+	})
+}
+
+// Run executes the projects list subcommand
+func (cmd *ProjectsListCmd) Run(opts anyagent.Options, out *report) error {
+	return out.record(anyagent.ListProjects(context.Background(), opts))
+}
+
+// Run executes the projects prune subcommand
+func (cmd *ProjectsPruneCmd) Run(opts anyagent.Options, out *report) error {
+	return out.record(anyagent.PruneProjects(context.Background(), opts, anyagent.PruneOptions{DryRun: cmd.DryRun}))
+}
+
+// Run executes the status command
+func (cmd *StatusCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		status, err := p.Status(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result := status.Result
+		result.Data = status
+		return &result, nil
+	})
 }
 
 // Run executes the switch command
-func (cmd *SwitchCmd) Run() error {
-	return commands.RunSwitch(cmd.ProjectDir, cmd.Agent, cmd.DryRun)
+func (cmd *SwitchCmd) Run(opts anyagent.Options, out *report) error {
+	return withProject(cmd.ProjectDir, opts, out, func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error) {
+		return p.Switch(ctx, cmd.Agent, anyagent.SwitchOptions{DryRun: cmd.DryRun})
+	})
+}
+
+// withProject loads the project in dir and records what fn reports
+func withProject(dir string, opts anyagent.Options, out *report, fn func(ctx context.Context, p *anyagent.Project) (*anyagent.Result, error)) error {
+	ctx := context.Background()
+	p, err := anyagent.Load(ctx, dir, opts)
+	if err != nil {
+		return err
+	}
+	return out.record(fn(ctx, p))
 }
 
 func main() {
@@ -187,9 +506,49 @@ func main() {
 		}),
 	)
 
-	err := ctx.Run()
+	out := &report{}
+	err := ctx.Run(cli.projectOptions(), out)
+	// The anyagent API wraps errors with the operation and directory the CLI already shows
+	var apiErr *anyagent.Error
+	if errors.As(err, &apiErr) {
+		err = apiErr.Err
+	}
+	if cli.Output == outputJSON {
+		if ferr := out.print(os.Stdout, commandName(ctx), err); ferr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", ferr)
+		}
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// projectOptions returns the anyagent API options matching the global flags: messages are
+// printed to stdout (stderr with --output json) and prompts are allowed
+func (cli *CLI) projectOptions() anyagent.Options {
+	opts := anyagent.Options{
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+		Quiet:       cli.Quiet,
+		Verbose:     cli.Verbose,
+		NoEmoji:     cli.NoEmoji,
+		NoColor:     cli.NoColor,
+		Interactive: true,
+	}
+	if cli.Output == outputJSON {
+		opts.Stdout = os.Stderr
+	}
+	return opts
+}
+
+// commandName returns the selected command without its arguments, e.g. "add rule"
+func commandName(ctx *kong.Context) string {
+	var words []string
+	for _, word := range strings.Fields(ctx.Command()) {
+		if !strings.HasPrefix(word, "<") {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/shibukawa/anyagent/pkg/anyagent"
+)
+
+// outputJSON is the --output format printing a single result document on stdout
+const outputJSON = "json"
+
+// document is printed on stdout with --output json. Every command prints exactly one;
+// progress messages go to stderr instead.
+type document struct {
+	Command    string               `json:"command"`
+	OK         bool                 `json:"ok"`
+	Error      *errorInfo           `json:"error,omitempty"`
+	Data       any                  `json:"data,omitempty"`
+	Operations []anyagent.Operation `json:"operations"`
+	Warnings   []string             `json:"warnings"`
+}
+
+// errorInfo describes why a command failed
+type errorInfo struct {
+	Message string `json:"message"`
+}
+
+// report is what the command reported through the anyagent API
+type report struct {
+	result anyagent.Result
+}
+
+// record keeps res, or what a failed command reported before err, and returns err
+func (r *report) record(res *anyagent.Result, err error) error {
+	var apiErr *anyagent.Error
+	if res == nil && errors.As(err, &apiErr) {
+		res = &apiErr.Result
+	}
+	if res != nil {
+		r.result = *res
+	}
+	return err
+}
+
+// print writes the JSON document for command, including runErr
+func (r *report) print(w io.Writer, command string, runErr error) error {
+	doc := document{
+		Command:    command,
+		OK:         runErr == nil,
+		Data:       r.result.Data,
+		Operations: r.result.Operations,
+		Warnings:   r.result.Warnings,
+	}
+	if doc.Operations == nil {
+		doc.Operations = []anyagent.Operation{}
+	}
+	if doc.Warnings == nil {
+		doc.Warnings = []string{}
+	}
+	if runErr != nil {
+		doc.Error = &errorInfo{Message: runErr.Error()}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
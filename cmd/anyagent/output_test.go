@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/shibukawa/anyagent/pkg/anyagent"
+)
+
+// printedDocument prints out's JSON document for command and decodes it, with data into data
+func printedDocument(t *testing.T, out *report, command string, runErr error, data any) document {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := out.print(&buf, command, runErr); err != nil {
+		t.Fatalf("print failed: %v", err)
+	}
+	var raw struct {
+		document
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &raw); err != nil {
+		t.Fatalf("not a JSON document: %v\n%s", err, buf.Bytes())
+	}
+	if data != nil && raw.Data != nil {
+		if err := json.Unmarshal(raw.Data, data); err != nil {
+			t.Fatalf("unexpected data: %v\n%s", err, raw.Data)
+		}
+	}
+	return raw.document
+}
+
+func TestJSONOutput_ListRule(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	ctx := context.Background()
+	dir := t.TempDir()
+	p, err := anyagent.Load(ctx, dir, anyagent.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Sync(ctx, anyagent.SyncOptions{Agents: []string{"claude"}}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, err := p.AddRule(ctx, "go", anyagent.AddOptions{}); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+
+	out := &report{}
+	if err := (&ListRuleCmd{ProjectDir: dir}).Run(anyagent.Options{}, out); err != nil {
+		t.Fatalf("list rule failed: %v", err)
+	}
+	var list anyagent.RuleList
+	doc := printedDocument(t, out, "list rule", nil, &list)
+	if !doc.OK || doc.Command != "list rule" || doc.Operations == nil || doc.Warnings == nil {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+	if !list.Initialized || list.Agent != "claude" {
+		t.Fatalf("unexpected rule list: %+v", list)
+	}
+	for _, r := range list.Rules {
+		if r.Installed != (r.Name == "go") {
+			t.Errorf("rule %s: installed = %v", r.Name, r.Installed)
+		}
+	}
+}
+
+func TestJSONOutput_Error(t *testing.T) {
+	out := &report{}
+	err := out.record(nil, &anyagent.Error{
+		Op:     "add rule",
+		Err:    errors.New("project is not initialized"),
+		Result: anyagent.Result{Warnings: []string{"Could not load project registry: broken"}},
+	})
+	doc := printedDocument(t, out, "add rule", errors.Unwrap(err), nil)
+	if doc.OK || doc.Error == nil || doc.Error.Message != "project is not initialized" {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+	if len(doc.Warnings) != 1 || doc.Warnings[0] != "Could not load project registry: broken" {
+		t.Errorf("unexpected warnings: %v", doc.Warnings)
+	}
+	if doc.Operations == nil {
+		t.Error("operations should be an empty list, not null")
+	}
+}
+
+func TestProjectOptions_JSONPrintsMessagesToStderr(t *testing.T) {
+	cli := CLI{Output: outputJSON}
+	if opts := cli.projectOptions(); opts.Stdout != os.Stderr {
+		t.Error("messages should go to stderr with --output json")
+	}
+}
+
+func TestJSONOutput_InitDryRunRecordsLaunch(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	out := &report{}
+	if err := (&InitCmd{DryRun: true}).Run(anyagent.Options{}, out); err != nil {
+		t.Fatalf("init --dry-run failed: %v", err)
+	}
+	var templates anyagent.Templates
+	doc := printedDocument(t, out, "init", nil, &templates)
+	if templates.Dir == "" || len(doc.Operations) != 1 || doc.Operations[0].Action != "launch" || doc.Operations[0].Path != templates.Dir {
+		t.Errorf("unexpected document: %+v, data %+v", doc, templates)
+	}
+}
+
+func TestJSONOutput_Status(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	ctx := context.Background()
+	dir := t.TempDir()
+	p, err := anyagent.Load(ctx, dir, anyagent.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Sync(ctx, anyagent.SyncOptions{Agents: []string{"claude"}}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	out := &report{}
+	if err := (&StatusCmd{ProjectDir: dir}).Run(anyagent.Options{}, out); err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	var status map[string]any
+	printedDocument(t, out, "status", nil, &status)
+	if status["project_dir"] != dir || status["initialized"] != true || status["operations"] != nil {
+		t.Errorf("unexpected status data: %v", status)
+	}
+}
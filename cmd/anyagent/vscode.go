@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/shibukawa/anyagent/internal/logging"
+	"github.com/shibukawa/anyagent/pkg/anyagent"
+)
+
+// vscodeCommands returns the VS Code executables to try on this platform
+func vscodeCommands() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{
+			"code",
+			"code-insiders",
+			"/Applications/Visual Studio Code.app/Contents/Resources/app/bin/code",
+			"/Applications/Visual Studio Code - Insiders.app/Contents/Resources/app/bin/code",
+		}
+	case "windows":
+		return []string{"code.cmd", "code", "code-insiders.cmd", "code-insiders"}
+	default: // Linux and others
+		return []string{"code", "code-insiders", "/usr/bin/code", "/snap/bin/code"}
+	}
+}
+
+// openTemplates opens the user template directory and its README.md in VS Code. With dryRun it
+// only records the launch in res.
+func openTemplates(opts anyagent.Options, res *anyagent.Result, dir string, dryRun bool) error {
+	logger := newLogger(opts)
+	if dryRun {
+		msg := fmt.Sprintf("Would launch VSCode with directory: %s and open README.md", dir)
+		logger.Printf("[DRY RUN] %s\n", msg)
+		res.Operations = append(res.Operations, anyagent.Operation{Action: "launch", Path: dir, Message: msg})
+		return nil
+	}
+
+	tried := vscodeCommands()
+	var cmd *exec.Cmd
+	for _, name := range tried {
+		if _, err := exec.LookPath(name); err == nil {
+			// Open folder and README file - folder first, then the file to make it active
+			cmd = exec.Command(name, dir, filepath.Join(dir, "README.md"))
+			break
+		}
+	}
+	if cmd == nil {
+		return fmt.Errorf("VSCode executable not found. Please ensure VSCode is installed and available in PATH.\nTried: %v", tried)
+	}
+
+	logger.Infof("Opening with VSCode...\n")
+	// Start VSCode in the background
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start VSCode: %w", err)
+	}
+	logger.Infof("✅ VSCode launched successfully\n")
+	return nil
+}
+
+// newLogger prints the CLI's own messages the way the anyagent API prints those of commands
+func newLogger(opts anyagent.Options) *logging.Logger {
+	out, errOut := opts.Stdout, opts.Stderr
+	if out == nil {
+		out = io.Discard
+	}
+	if errOut == nil {
+		errOut = io.Discard
+	}
+	logOpts := logging.Options{NoEmoji: opts.NoEmoji, NoColor: opts.NoColor}
+	switch {
+	case opts.Quiet:
+		logOpts.Level = logging.LevelQuiet
+	case opts.Verbose:
+		logOpts.Level = logging.LevelVerbose
+	}
+	return logging.New(out, errOut, logOpts)
+}
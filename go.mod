@@ -5,3 +5,5 @@ go 1.25.0
 require github.com/alecthomas/kong v1.6.0
 
 require gopkg.in/yaml.v3 v3.0.1
+
+require github.com/pelletier/go-toml/v2 v2.3.1
@@ -6,168 +6,173 @@ import (
 	"path/filepath"
 	"strings"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/shibukawa/anyagent/internal/config"
 )
 
 // RunAddCommand executes the add command functionality
-func RunAddCommand(command, projectDir string, dryRun bool, global bool) error {
-	fmt.Printf("Adding %s command to project...\n", command)
+func (s *Session) RunAddCommand(command, projectDir string, dryRun bool, global bool) error {
+	s.Logger.Infof("Adding %s command to project...\n", command)
 
 	// Get project directory (current directory if not specified)
 	if projectDir == "" {
 		var err error
-		projectDir, err = os.Getwd()
+		projectDir, err = s.Env.Getwd()
 		if err != nil {
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 	}
 
 	// Make sure the project directory exists
-	if _, err := os.Stat(projectDir); os.IsNotExist(err) {
-		return fmt.Errorf("project directory does not exist: %s", projectDir)
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
 	}
 
-	fmt.Printf("Project directory: %s\n", projectDir)
+	s.Logger.Infof("Project directory: %s\n", projectDir)
 
 	// Check if project is initialized (has AGENTS.md)
 	agentsPath := filepath.Join(projectDir, "AGENTS.md")
-	if _, err := os.Stat(agentsPath); os.IsNotExist(err) {
-		return fmt.Errorf("project is not initialized with anyagent. Run 'anyagent sync' first")
+	if _, err := s.Env.FS.Stat(agentsPath); os.IsNotExist(err) {
+		return ErrNotInitialized
+	}
+
+	// Make sure the command exists in one of the template layers
+	if err := s.validateCommand(projectDir, command); err != nil {
+		return err
 	}
 
 	// Resolve the command template content with precedence (project → user → embedded)
-	commandContent, err := config.GetCommandTemplateResolved(projectDir, command)
+	commandContent, err := s.getCommandTemplate(projectDir, command)
 	if err != nil {
 		return fmt.Errorf("failed to get command template: %w", err)
 	}
 
 	// Create Copilot prompt if Copilot is selected (or no config present)
-	if shouldCreateCopilotCommandFiles(projectDir) {
+	if s.shouldCreateCopilotCommandFiles(projectDir) {
 		copilotPromptsDir := filepath.Join(projectDir, ".github", "prompts")
-		if err := createPromptsDirectory(copilotPromptsDir, dryRun); err != nil {
+		if err := s.createPromptsDirectory(copilotPromptsDir, dryRun); err != nil {
 			return fmt.Errorf("failed to create prompts directory: %w", err)
 		}
 
 		commandFilePath := filepath.Join(copilotPromptsDir, fmt.Sprintf("%s.prompt.md", command))
-		if err := createCommandFile(commandFilePath, commandContent, dryRun); err != nil {
+		if err := s.createCommandFile(commandFilePath, buildCopilotPromptContent(commandContent), dryRun); err != nil {
 			return fmt.Errorf("failed to create command file: %w", err)
 		}
 	}
 
 	// Create Amazon Q Developer prompt if Q Dev is selected (only with --global)
-	if shouldCreateQDevCommandFiles(projectDir) {
+	if s.shouldCreateQDevCommandFiles(projectDir) {
 		if global {
-			homeDir, err := os.UserHomeDir()
+			homeDir, err := s.Env.UserHomeDir()
 			if err != nil {
-				fmt.Printf("⚠️  Warning: Could not get home directory for Amazon Q Developer prompts: %v\n", err)
+				s.warnf("Warning: Could not get home directory for Amazon Q Developer prompts: %v", err)
 			} else {
-				qdevPromptsDir := filepath.Join(homeDir, ".aws", "amazonq", "prompts")
-				if err := createPromptsDirectory(qdevPromptsDir, dryRun); err != nil {
-					fmt.Printf("⚠️  Warning: Could not create Amazon Q Developer prompts directory: %v\n", err)
+				// Command name: hyphens and underscores become spaces
+				qdevCommandFilePath := qdevGlobalPromptPath(homeDir, command)
+				if err := s.createPromptsDirectory(filepath.Dir(qdevCommandFilePath), dryRun); err != nil {
+					s.warnf("Warning: Could not create Amazon Q Developer prompts directory: %v", err)
 				} else {
-					// Convert command name: replace hyphens and underscores with spaces
-					qdevCommandName := strings.ReplaceAll(strings.ReplaceAll(command, "-", " "), "_", " ")
-					qdevCommandFilePath := filepath.Join(qdevPromptsDir, fmt.Sprintf("%s.md", qdevCommandName))
-
 					// Create content without YAML frontmatter for Amazon Q Developer
-					qdevContent := removeYAMLFrontmatter(commandContent)
+					qdevContent := buildQDevCommandContent(commandContent)
 
-					if err := createCommandFile(qdevCommandFilePath, qdevContent, dryRun); err != nil {
-						fmt.Printf("⚠️  Warning: Could not create Amazon Q Developer command file: %v\n", err)
+					if err := s.createCommandFile(qdevCommandFilePath, qdevContent, dryRun); err != nil {
+						s.warnf("Warning: Could not create Amazon Q Developer command file: %v", err)
 					} else {
-						fmt.Printf("📄 Amazon Q Developer prompt created: ~/.aws/amazonq/prompts/%s.md\n", qdevCommandName)
+						s.Logger.Infof("📄 Amazon Q Developer prompt created: ~/.aws/amazonq/prompts/%s\n", filepath.Base(qdevCommandFilePath))
+						s.acquireGlobalArtifacts(projectDir, []string{qdevCommandFilePath}, dryRun)
 					}
 				}
 			}
 		} else {
-			fmt.Printf("ℹ️  Q Dev selected: use '--global' to install to ~/.aws/amazonq/prompts\n")
+			s.Logger.Infof("ℹ️  Q Dev selected: use '--global' to install to ~/.aws/amazonq/prompts\n")
 		}
 	}
 
 	// Create Codex prompt if Codex is selected
-	if shouldCreateCodexCommandFiles(projectDir) {
+	if s.shouldCreateCodexCommandFiles(projectDir) {
 		if global {
-			homeDir, err := os.UserHomeDir()
+			homeDir, err := s.Env.UserHomeDir()
 			if err != nil {
-				fmt.Printf("⚠️  Warning: Could not get home directory for Codex prompts: %v\n", err)
+				s.warnf("Warning: Could not get home directory for Codex prompts: %v", err)
 			} else {
-				codexPromptsDir := filepath.Join(homeDir, ".codex", "prompts")
-				if err := createPromptsDirectory(codexPromptsDir, dryRun); err != nil {
-					fmt.Printf("⚠️  Warning: Could not create Codex prompts directory: %v\n", err)
+				codexCommandFilePath := codexGlobalPromptPath(homeDir, command)
+				if err := s.createPromptsDirectory(filepath.Dir(codexCommandFilePath), dryRun); err != nil {
+					s.warnf("Warning: Could not create Codex prompts directory: %v", err)
 				} else {
-					codexCommandFilePath := filepath.Join(codexPromptsDir, fmt.Sprintf("%s.md", command))
-					codexContent := removeYAMLFrontmatter(commandContent)
-					if err := createCommandFile(codexCommandFilePath, codexContent, dryRun); err != nil {
-						fmt.Printf("⚠️  Warning: Could not create Codex command file: %v\n", err)
+					codexContent := buildCodexCommandContent(commandContent)
+					if err := s.createCommandFile(codexCommandFilePath, codexContent, dryRun); err != nil {
+						s.warnf("Warning: Could not create Codex command file: %v", err)
 					} else {
-						fmt.Printf("📄 Codex prompt created: ~/.codex/prompts/%s.md\n", command)
+						s.Logger.Infof("📄 Codex prompt created: ~/.codex/prompts/%s.md\n", command)
+						s.acquireGlobalArtifacts(projectDir, []string{codexCommandFilePath}, dryRun)
 					}
 				}
 			}
 		} else {
-			fmt.Printf("ℹ️  Codex selected: use '--global' to install to ~/.codex/prompts\n")
+			s.Logger.Infof("ℹ️  Codex selected: use '--global' to install to ~/.codex/prompts\n")
 		}
 	}
 
 	// Create Claude Code prompt if Claude is selected
-	if shouldCreateClaudeCommandFiles(projectDir) {
+	if s.shouldCreateClaudeCommandFiles(projectDir) {
 		claudeDir := filepath.Join(projectDir, ".claude", "commands")
-		if err := createPromptsDirectory(claudeDir, dryRun); err != nil {
-			fmt.Printf("⚠️  Warning: Could not create Claude commands directory: %v\n", err)
+		if err := s.createPromptsDirectory(claudeDir, dryRun); err != nil {
+			s.warnf("Warning: Could not create Claude commands directory: %v", err)
 		} else {
 			claudeCommandFilePath := filepath.Join(claudeDir, fmt.Sprintf("%s.md", command))
 			// Build Claude-specific content: add YAML frontmatter with allowed-tools and description
 			claudeContent := buildClaudeCommandContent(commandContent)
-			if err := createCommandFile(claudeCommandFilePath, claudeContent, dryRun); err != nil {
-				fmt.Printf("⚠️  Warning: Could not create Claude command file: %v\n", err)
+			if err := s.createCommandFile(claudeCommandFilePath, claudeContent, dryRun); err != nil {
+				s.warnf("Warning: Could not create Claude command file: %v", err)
 			} else {
-				fmt.Printf("📄 Claude command created: .claude/commands/%s.md\n", command)
+				s.Logger.Infof("📄 Claude command created: .claude/commands/%s.md\n", command)
 			}
 		}
 	}
 
 	// Create Gemini Code command if Gemini is selected
-	if shouldCreateGeminiCommandFiles(projectDir) {
+	if s.shouldCreateGeminiCommandFiles(projectDir) {
 		geminiDir := filepath.Join(projectDir, ".gemini", "commands")
-		if err := createPromptsDirectory(geminiDir, dryRun); err != nil {
-			fmt.Printf("⚠️  Warning: Could not create Gemini commands directory: %v\n", err)
+		if err := s.createPromptsDirectory(geminiDir, dryRun); err != nil {
+			s.warnf("Warning: Could not create Gemini commands directory: %v", err)
 		} else {
 			geminiCommandFilePath := filepath.Join(geminiDir, fmt.Sprintf("%s.toml", command))
 			tomlContent := buildGeminiCommandTOML(commandContent)
-			if err := createCommandFile(geminiCommandFilePath, tomlContent, dryRun); err != nil {
-				fmt.Printf("⚠️  Warning: Could not create Gemini command file: %v\n", err)
+			if err := s.createCommandFile(geminiCommandFilePath, tomlContent, dryRun); err != nil {
+				s.warnf("Warning: Could not create Gemini command file: %v", err)
 			} else {
-				fmt.Printf("📄 Gemini command created: .gemini/commands/%s.toml\n", command)
+				s.Logger.Infof("📄 Gemini command created: .gemini/commands/%s.toml\n", command)
 			}
 		}
 	}
 
-	fmt.Printf("✅ %s command added successfully\n", command)
-	if shouldCreateCopilotCommandFiles(projectDir) {
-		fmt.Printf("💡 Use '/prompt %s' in VS Code Copilot Chat to activate this command\n", command)
+	s.Logger.Infof("✅ %s command added successfully\n", command)
+	if s.shouldCreateCopilotCommandFiles(projectDir) {
+		s.Logger.Infof("💡 Use '/prompt %s' in VS Code Copilot Chat to activate this command\n", command)
 	}
-	if shouldCreateQDevCommandFiles(projectDir) && global {
-		fmt.Printf("💡 Use '@%s' in Amazon Q Developer Chat to activate this command\n", strings.ReplaceAll(strings.ReplaceAll(command, "-", " "), "_", " "))
+	if s.shouldCreateQDevCommandFiles(projectDir) && global {
+		s.Logger.Infof("💡 Use '@%s' in Amazon Q Developer Chat to activate this command\n", strings.ReplaceAll(strings.ReplaceAll(command, "-", " "), "_", " "))
 	}
-	if shouldCreateCodexCommandFiles(projectDir) && global {
-		fmt.Printf("💡 Use '/%s' in Codex to activate this command\n", command)
+	if s.shouldCreateCodexCommandFiles(projectDir) && global {
+		s.Logger.Infof("💡 Use '/%s' in Codex to activate this command\n", command)
 	}
-	if shouldCreateClaudeCommandFiles(projectDir) {
-		fmt.Printf("💡 Claude Code: use the command from .claude/commands/%s.md\n", command)
+	if s.shouldCreateClaudeCommandFiles(projectDir) {
+		s.Logger.Infof("💡 Claude Code: use the command from .claude/commands/%s.md\n", command)
 	}
-	if shouldCreateGeminiCommandFiles(projectDir) {
-		fmt.Printf("💡 Gemini Code: command saved at .gemini/commands/%s.toml\n", command)
+	if s.shouldCreateGeminiCommandFiles(projectDir) {
+		s.Logger.Infof("💡 Gemini Code: command saved at .gemini/commands/%s.toml\n", command)
 	}
 
 	// Track installed command in project config for future syncs (info only)
-	if err := addInstalledCommandToConfig(projectDir, command, dryRun); err != nil {
-		fmt.Printf("⚠️  Warning: Failed to update project config with command '%s': %v\n", command, err)
+	if err := s.addInstalledCommandToConfig(projectDir, command, dryRun); err != nil {
+		s.warnf("Warning: Failed to update project config with command '%s': %v", command, err)
 	}
 	return nil
 }
 
 // validateCommand validates the command name and checks if it's available
-func validateCommand(command string) error {
+func (s *Session) validateCommand(projectDir, command string) error {
 	if command == "" {
 		return fmt.Errorf("command name cannot be empty")
 	}
@@ -177,8 +182,8 @@ func validateCommand(command string) error {
 		return fmt.Errorf("command name contains invalid characters: %s", command)
 	}
 
-	// Get available commands
-	availableCommands, err := config.GetAvailableCommands()
+	// Get available commands from all template layers
+	availableCommands, err := config.GetAvailableCommands(s.Env, projectDir)
 	if err != nil {
 		return fmt.Errorf("failed to get available commands: %w", err)
 	}
@@ -190,16 +195,18 @@ func validateCommand(command string) error {
 		}
 	}
 
-	return fmt.Errorf("command '%s' is not available. Available commands: %s", command, strings.Join(availableCommands, ", "))
+	return errorOf(ErrUnknownTemplate, "command '%s' is not available. Available commands: %s", command, strings.Join(availableCommands, ", "))
 }
 
-// getCommandTemplate retrieves the template content for the specified command
-func getCommandTemplate(command string) (string, error) { return config.GetCommandTemplate(command) }
+// getCommandTemplate retrieves the template content for the specified command (project → user → embedded)
+func (s *Session) getCommandTemplate(projectDir, command string) (string, error) {
+	return config.GetCommandTemplateResolved(s.Env, projectDir, command)
+}
 
 // addInstalledCommandToConfig records the installed command into .anyagent.yaml
-func addInstalledCommandToConfig(projectDir, command string, dryRun bool) error {
+func (s *Session) addInstalledCommandToConfig(projectDir, command string, dryRun bool) error {
 	configPath := config.GetProjectConfigPath(projectDir)
-	projectConfig, err := config.LoadProjectConfig(configPath)
+	projectConfig, err := config.LoadProjectConfig(s.Env, configPath)
 	if err != nil {
 		return err
 	}
@@ -214,66 +221,83 @@ func addInstalledCommandToConfig(projectDir, command string, dryRun bool) error
 	if !present {
 		projectConfig.InstalledCommands = append(projectConfig.InstalledCommands, command)
 		if dryRun {
-			fmt.Printf("[DRY RUN] Would record installed command '%s' into .anyagent.yaml\n", command)
+			s.dryRunf("record", "", "Would record installed command '%s' into .anyagent.yaml", command)
 			return nil
 		}
-		if err := projectConfig.Save(configPath); err != nil {
+		if err := projectConfig.Save(s.Env, configPath); err != nil {
 			return fmt.Errorf("failed to save project config: %w", err)
 		}
-		fmt.Printf("💾 Project config updated: recorded command '%s'\n", command)
+		s.Logger.Infof("💾 Project config updated: recorded command '%s'\n", command)
 	}
 	return nil
 }
 
 // createPromptsDirectory creates the .github/prompts directory
-func createPromptsDirectory(dir string, dryRun bool) error {
+func (s *Session) createPromptsDirectory(dir string, dryRun bool) error {
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would create directory: %s\n", dir)
+		s.dryRunf("mkdir", dir, "Would create directory: %s", dir)
 		return nil
 	}
 
-	fmt.Printf("📁 Creating prompts directory: %s\n", dir)
-	return os.MkdirAll(dir, 0755)
+	s.Logger.Infof("📁 Creating prompts directory: %s\n", dir)
+	return s.Env.FS.MkdirAll(dir, 0755)
 }
 
 // createCommandFile creates the command prompt file
-func createCommandFile(filePath, content string, dryRun bool) error {
+func (s *Session) createCommandFile(filePath, content string, dryRun bool) error {
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would create command file: %s\n", filePath)
-		fmt.Printf("[DRY RUN] Content preview:\n")
+		s.dryRunf("create", filePath, "Would create command file: %s", filePath)
+		s.Logger.Infof("[DRY RUN] Content preview:\n")
 		lines := strings.Split(content, "\n")
 		for i, line := range lines {
 			if i >= 10 {
-				fmt.Printf("  ... (truncated)\n")
+				s.Logger.Infof("  ... (truncated)\n")
 				break
 			}
-			fmt.Printf("  %s\n", line)
+			s.Logger.Infof("  %s\n", line)
 		}
 		return nil
 	}
 
-	fmt.Printf("📄 Creating command file: %s\n", filePath)
-	return os.WriteFile(filePath, []byte(content), 0644)
+	s.Logger.Infof("📄 Creating command file: %s\n", filePath)
+	return s.Env.FS.WriteFile(filePath, []byte(content), 0644)
 }
 
-// ListAvailableCommands displays all available commands
-func ListAvailableCommands() error {
-	commands, err := config.GetAvailableCommands()
+// AvailableTemplate is a command, persona or hook template and the layer it comes from, the
+// JSON result of 'add command/persona/hook --list'
+type AvailableTemplate struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+// setAvailableTemplates records the listed templates as the result
+func (s *Session) setAvailableTemplates(templates []config.TemplateInfo) {
+	list := []AvailableTemplate{}
+	for _, t := range templates {
+		list = append(list, AvailableTemplate{Name: t.Name, Source: t.Source})
+	}
+	s.setResultData(list)
+}
+
+// ListAvailableCommands displays all available commands with the template layer they come from
+func (s *Session) ListAvailableCommands(projectDir string) error {
+	commands, err := config.ListCommandTemplates(s.Env, projectDir)
 	if err != nil {
 		return fmt.Errorf("failed to get available commands: %w", err)
 	}
+	s.setAvailableTemplates(commands)
 
 	if len(commands) == 0 {
-		fmt.Println("No commands available.")
+		s.Logger.Println("No commands available.")
 		return nil
 	}
 
-	fmt.Println("Available commands:")
+	s.Logger.Println("Available commands:")
 	for _, command := range commands {
-		fmt.Printf("  • %s\n", command)
+		s.Logger.Printf("  • %s (%s)\n", command.Name, command.Source)
 	}
-	fmt.Printf("\nUsage: anyagent add command <command-name>\n")
-	fmt.Printf("After adding, use '/prompt <command-name>' in VS Code Copilot Chat\n")
+	s.Logger.Printf("\nUsage: anyagent add command <command-name>\n")
+	s.Logger.Printf("After adding, use '/prompt <command-name>' in VS Code Copilot Chat\n")
 
 	return nil
 }
@@ -301,21 +325,67 @@ func removeYAMLFrontmatter(content string) string {
 	return content
 }
 
+// claudeVerbatimFrontmatterKey holds the verbatim frontmatter of an adopted Claude command
+const claudeVerbatimFrontmatterKey = "frontmatter"
+
 // buildClaudeCommandContent wraps a command template body with Claude-specific YAML frontmatter
 // including allowed-tools and description. It extracts description from the original template
-// frontmatter if available.
+// frontmatter if available. Argument placeholders are translated to $1..$N / $ARGUMENTS and
+// keys from the template's 'agents.claude' block (allowed-tools, model, ...) are merged in.
+// An adopted command is written back as it was: its 'agents.claude.frontmatter' replaces the
+// generated frontmatter and the body is kept as is.
 func buildClaudeCommandContent(templateContent string) string {
-	desc := extractDescriptionFromTemplate(templateContent)
-	body := removeYAMLFrontmatter(templateContent)
-	// Default allowed-tools empty; specialized commands may add more later.
-	header := []string{
-		"---",
-		"allowed-tools: []",
-		fmt.Sprintf("description: %s", quoteIfNeeded(desc)),
-		"---",
-		"",
-	}
-	return strings.Join(header, "\n") + body
+	ct := parseCommandTemplate(templateContent)
+	if raw := mappingValue(ct.agentBlock("claude"), claudeVerbatimFrontmatterKey); raw != nil && raw.Kind == yaml.ScalarNode {
+		return raw.Value + ct.Body
+	}
+	// Default allowed-tools empty; templates can override it via agents.claude.
+	front := &yaml.Node{Kind: yaml.MappingNode}
+	setMappingValue(front, "allowed-tools", &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle})
+	if hint := ct.argumentHint(); hint != "" {
+		setMappingValue(front, "argument-hint", stringNode(hint))
+	}
+	setMappingValue(front, "description", stringNode(ct.Description))
+	mergeMapping(front, ct.agentBlock("claude"))
+	return renderFrontmatter(front) + "\n" + strings.TrimPrefix(ct.positionalBody(), "\n")
+}
+
+// buildCopilotPromptContent builds a VS Code prompt file. Templates without arguments or
+// per-agent metadata are copied verbatim; otherwise 'arguments' and 'agents' are dropped from
+// the frontmatter, 'agents.copilot' (mode, tools, model, ...) is merged in and placeholders
+// become ${input:name} variables.
+func buildCopilotPromptContent(templateContent string) string {
+	ct := parseCommandTemplate(templateContent)
+	if !ct.usesArguments() && !ct.hasAgentMetadata() {
+		return templateContent
+	}
+	front := ct.front
+	if front == nil {
+		front = &yaml.Node{Kind: yaml.MappingNode}
+	}
+	deleteMappingKey(front, "arguments")
+	deleteMappingKey(front, "agents")
+	mergeMapping(front, ct.agentBlock("copilot"))
+	return renderFrontmatter(front) + ct.copilotBody()
+}
+
+// buildCodexCommandContent builds a Codex prompt: plain Markdown with $1..$N / $ARGUMENTS.
+// Frontmatter is only emitted when the template has an 'agents.codex' block.
+func buildCodexCommandContent(templateContent string) string {
+	ct := parseCommandTemplate(templateContent)
+	body := ct.positionalBody()
+	if block := ct.agentBlock("codex"); block != nil {
+		front := &yaml.Node{Kind: yaml.MappingNode}
+		mergeMapping(front, block)
+		return renderFrontmatter(front) + body
+	}
+	return body
+}
+
+// buildQDevCommandContent builds an Amazon Q Developer prompt: plain Markdown. Q Dev prompts
+// take no arguments, so placeholders are rendered as readable <name> markers.
+func buildQDevCommandContent(templateContent string) string {
+	return parseCommandTemplate(templateContent).plainBody()
 }
 
 // extractDescriptionFromTemplate tries to read 'description:' from a YAML frontmatter at the top.
@@ -339,29 +409,27 @@ func extractDescriptionFromTemplate(content string) string {
 	return ""
 }
 
-func quoteIfNeeded(s string) string {
-	if s == "" {
-		return "''"
-	}
-	if strings.ContainsAny(s, ":#[]{}\"'\n") {
-		// Use single quotes and escape existing single quotes by doubling them
-		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
-	}
-	return s
-}
-
-// buildGeminiCommandTOML constructs a TOML definition with description and prompt
+// buildGeminiCommandTOML constructs a TOML definition with description and prompt, plus any
+// keys from the template's 'agents.gemini' block
 func buildGeminiCommandTOML(templateContent string) string {
-	desc := extractDescriptionFromTemplate(templateContent)
-	prompt := removeYAMLFrontmatter(templateContent)
+	ct := parseCommandTemplate(templateContent)
+	desc := ct.Description
+	prompt := ct.geminiBody()
 	// Escape description for TOML basic string
 	descEsc := strings.ReplaceAll(desc, "\\", "\\\\")
 	descEsc = strings.ReplaceAll(descEsc, "\"", "\\\"")
-	if descEsc == "" {
-		descEsc = ""
+	var extra strings.Builder
+	if block := ct.agentBlock("gemini"); block != nil {
+		for i := 0; i+1 < len(block.Content); i += 2 {
+			key := block.Content[i].Value
+			if key == "description" || key == "prompt" {
+				continue
+			}
+			fmt.Fprintf(&extra, "%s = %s\n", tomlKey(key), tomlValue(block.Content[i+1]))
+		}
 	}
 	// Use TOML multiline basic string for prompt
 	// Trim leading newline to keep formatting tidy (simplified with TrimPrefix)
 	prompt = strings.TrimPrefix(prompt, "\n")
-	return fmt.Sprintf("description = \"%s\"\nprompt = \"\"\"%s\"\"\"\n", descEsc, prompt)
+	return fmt.Sprintf("description = \"%s\"\n%sprompt = \"\"\"%s\"\"\"\n", descEsc, extra.String(), prompt)
 }
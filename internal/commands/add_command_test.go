@@ -10,6 +10,7 @@ import (
 )
 
 func TestRunAddCommand(t *testing.T) {
+	s := newTestSession()
 	// Create temporary directory for testing
 	tempDir := t.TempDir()
 
@@ -50,7 +51,7 @@ func TestRunAddCommand(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := RunAddCommand(tt.command, tempDir, true, false) // Use dry run
+			err := s.RunAddCommand(tt.command, tempDir, true, false) // Use dry run
 
 			if tt.expectError {
 				if err == nil {
@@ -68,6 +69,7 @@ func TestRunAddCommand(t *testing.T) {
 }
 
 func TestRunAddCommandClaude(t *testing.T) {
+	s := newTestSession()
 	tempDir := t.TempDir()
 	// AGENTS.md
 	if err := os.WriteFile(filepath.Join(tempDir, "AGENTS.md"), []byte("# Test"), 0644); err != nil {
@@ -75,15 +77,15 @@ func TestRunAddCommandClaude(t *testing.T) {
 	}
 	// Enable claude
 	cfg := &config.ProjectConfig{EnabledAgents: []string{"claude"}}
-	if err := config.SaveProjectConfig(tempDir, cfg); err != nil {
+	if err := config.SaveProjectConfig(s.Env, tempDir, cfg); err != nil {
 		t.Fatalf("failed to save config: %v", err)
 	}
 	// Dry run should succeed
-	if err := RunAddCommand("create-readme", tempDir, true, false); err != nil {
+	if err := s.RunAddCommand("create-readme", tempDir, true, false); err != nil {
 		t.Fatalf("dry run failed: %v", err)
 	}
 	// Actual
-	if err := RunAddCommand("create-readme", tempDir, false, false); err != nil {
+	if err := s.RunAddCommand("create-readme", tempDir, false, false); err != nil {
 		t.Fatalf("RunAddCommand failed: %v", err)
 	}
 	// Expect .claude/commands/create-readme.md and YAML frontmatter with allowed-tools/description
@@ -92,13 +94,14 @@ func TestRunAddCommandClaude(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Claude command not readable: %v", err)
 	}
-	s := string(b)
-	if !strings.HasPrefix(s, "---") || !strings.Contains(s, "allowed-tools:") || !strings.Contains(s, "description:") {
-		t.Fatalf("Claude command missing required frontmatter:\n%s", s)
+	content := string(b)
+	if !strings.HasPrefix(content, "---") || !strings.Contains(content, "allowed-tools:") || !strings.Contains(content, "description:") {
+		t.Fatalf("Claude command missing required frontmatter:\n%s", content)
 	}
 }
 
 func TestRunAddCommandGemini(t *testing.T) {
+	s := newTestSession()
 	tempDir := t.TempDir()
 	// AGENTS.md
 	if err := os.WriteFile(filepath.Join(tempDir, "AGENTS.md"), []byte("# Test"), 0644); err != nil {
@@ -106,11 +109,11 @@ func TestRunAddCommandGemini(t *testing.T) {
 	}
 	// Enable gemini
 	cfg := &config.ProjectConfig{EnabledAgents: []string{"gemini"}}
-	if err := config.SaveProjectConfig(tempDir, cfg); err != nil {
+	if err := config.SaveProjectConfig(s.Env, tempDir, cfg); err != nil {
 		t.Fatalf("failed to save config: %v", err)
 	}
 	// Actual
-	if err := RunAddCommand("create-readme", tempDir, false, false); err != nil {
+	if err := s.RunAddCommand("create-readme", tempDir, false, false); err != nil {
 		t.Fatalf("RunAddCommand failed: %v", err)
 	}
 	// Expect .gemini/commands/create-readme.toml
@@ -119,17 +122,18 @@ func TestRunAddCommandGemini(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Gemini command not readable: %v", err)
 	}
-	s := string(b)
-	if !strings.Contains(s, "description =") || !strings.Contains(s, "prompt =") {
-		t.Fatalf("Gemini command missing fields:\n%s", s)
+	content := string(b)
+	if !strings.Contains(content, "description =") || !strings.Contains(content, "prompt =") {
+		t.Fatalf("Gemini command missing fields:\n%s", content)
 	}
 }
 
 func TestRunAddCommandNotInitialized(t *testing.T) {
+	s := newTestSession()
 	// Create temporary directory without AGENTS.md
 	tempDir := t.TempDir()
 
-	err := RunAddCommand("create-readme", tempDir, true, false)
+	err := s.RunAddCommand("create-readme", tempDir, true, false)
 	if err == nil {
 		t.Error("Expected error for uninitialized project")
 	}
@@ -141,6 +145,7 @@ func TestRunAddCommandNotInitialized(t *testing.T) {
 }
 
 func TestValidateCommand(t *testing.T) {
+	s := newTestSession()
 	tests := []struct {
 		name        string
 		command     string
@@ -175,7 +180,7 @@ func TestValidateCommand(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateCommand(tt.command)
+			err := s.validateCommand("", tt.command)
 
 			if tt.expectError && err == nil {
 				t.Error("Expected error but got none")
@@ -252,3 +257,42 @@ description: 'Test'
 		})
 	}
 }
+
+func TestRunAddCommandProjectTemplate(t *testing.T) {
+	s := newTestSession()
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "AGENTS.md"), []byte("# Test"), 0644); err != nil {
+		t.Fatalf("failed to create AGENTS.md: %v", err)
+	}
+	commandsDir := filepath.Join(tempDir, ".anyagent", "commands")
+	if err := os.MkdirAll(commandsDir, 0755); err != nil {
+		t.Fatalf("failed to create commands dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(commandsDir, "deploy.md"), []byte("---\ndescription: 'Deploy'\n---\n# Deploy\n"), 0644); err != nil {
+		t.Fatalf("failed to write command template: %v", err)
+	}
+	if err := config.SaveProjectConfig(s.Env, tempDir, &config.ProjectConfig{EnabledAgents: []string{"copilot"}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	if err := s.RunAddCommand("deploy", tempDir, false, false); err != nil {
+		t.Fatalf("RunAddCommand failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, ".github", "prompts", "deploy.prompt.md")); err != nil {
+		t.Fatalf("deploy prompt not created: %v", err)
+	}
+
+	copilot, _, err := s.listInstalledCommands(tempDir)
+	if err != nil {
+		t.Fatalf("listInstalledCommands failed: %v", err)
+	}
+	found := false
+	for _, c := range copilot {
+		if c == "deploy" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("listInstalledCommands did not report project command: %v", copilot)
+	}
+}
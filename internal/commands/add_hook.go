@@ -0,0 +1,533 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+// hookDefinition is a hook template (templates/hooks/<name>.yaml):
+//
+//	description: Run gofmt after edits
+//	event: PostToolUse
+//	matcher: Edit|MultiEdit|Write
+//	command: gofmt -w .
+//	timeout: 30
+//	agents:
+//	  gemini:
+//	    matcher: write_file|replace
+//
+// Event and matcher use Claude Code names; they are mapped for other agents unless
+// overridden in the agents block.
+type hookDefinition struct {
+	Description string                  `yaml:"description"`
+	Event       string                  `yaml:"event"`
+	Matcher     string                  `yaml:"matcher"`
+	Command     string                  `yaml:"command"`
+	Timeout     int                     `yaml:"timeout"`
+	Agents      map[string]hookOverride `yaml:"agents"`
+}
+
+// hookOverride holds per-agent replacements for hook fields
+type hookOverride struct {
+	Event   string `yaml:"event"`
+	Matcher string `yaml:"matcher"`
+	Command string `yaml:"command"`
+	Timeout int    `yaml:"timeout"`
+}
+
+// geminiHookEvents maps Claude Code hook events to Gemini CLI events
+var geminiHookEvents = map[string]string{
+	"PreToolUse":       "BeforeTool",
+	"PostToolUse":      "AfterTool",
+	"UserPromptSubmit": "BeforeAgent",
+	"Stop":             "AfterAgent",
+	"SessionStart":     "SessionStart",
+	"SessionEnd":       "SessionEnd",
+	"Notification":     "Notification",
+	"PreCompact":       "PreCompress",
+}
+
+// geminiToolNames maps Claude Code tool names used in matchers to Gemini CLI tool names
+var geminiToolNames = map[string]string{
+	"Edit":      "replace",
+	"MultiEdit": "replace",
+	"Write":     "write_file",
+	"Read":      "read_file",
+	"Bash":      "run_shell_command",
+	"Grep":      "search_file_content",
+	"Glob":      "glob",
+	"WebFetch":  "web_fetch",
+}
+
+// parseHookDefinition parses hook template content
+func parseHookDefinition(name, content string) (hookDefinition, error) {
+	var h hookDefinition
+	if err := yaml.Unmarshal([]byte(content), &h); err != nil {
+		return h, fmt.Errorf("failed to parse hook template %s: %w", name, err)
+	}
+	if h.Event == "" || strings.TrimSpace(h.Command) == "" {
+		return h, fmt.Errorf("hook template %s must define event and command", name)
+	}
+	return h, nil
+}
+
+// forAgent returns the hook as the agent sees it; ok is false when the agent has no such event
+func (h hookDefinition) forAgent(agentName string) (hookDefinition, bool) {
+	out := h
+	switch agentName {
+	case "claude":
+	case "gemini":
+		event, ok := geminiHookEvents[h.Event]
+		if !ok {
+			return out, false
+		}
+		out.Event = event
+		var tools []string
+		seen := map[string]bool{}
+		for _, tool := range strings.Split(h.Matcher, "|") {
+			if mapped, ok := geminiToolNames[tool]; ok {
+				tool = mapped
+			}
+			if tool != "" && !seen[tool] {
+				tools = append(tools, tool)
+				seen[tool] = true
+			}
+		}
+		out.Matcher = strings.Join(tools, "|")
+	default:
+		return out, false
+	}
+	if o, ok := h.Agents[agentName]; ok {
+		if o.Event != "" {
+			out.Event = o.Event
+		}
+		if o.Matcher != "" {
+			out.Matcher = o.Matcher
+		}
+		if o.Command != "" {
+			out.Command = o.Command
+		}
+		if o.Timeout != 0 {
+			out.Timeout = o.Timeout
+		}
+	}
+	// Templates give timeouts in seconds like Claude Code; Gemini CLI expects milliseconds
+	if agentName == "gemini" {
+		out.Timeout *= 1000
+	}
+	return out, true
+}
+
+// hookSettingsPath returns the settings file holding hooks for the agent
+func hookSettingsPath(agentName, projectDir string) (string, bool) {
+	switch agentName {
+	case "claude":
+		return filepath.Join(projectDir, ".claude", "settings.json"), true
+	case "gemini":
+		return filepath.Join(projectDir, ".gemini", "settings.json"), true
+	}
+	return "", false
+}
+
+// installedHook is a hook entry as written to an agent's settings. Removal matches the recorded
+// entry, so hooks are found again after their template is edited.
+type installedHook struct {
+	Event   string `json:"event"`
+	Matcher string `json:"matcher,omitempty"`
+	Command string `json:"command"`
+}
+
+// installedHooksPath returns .anyagent/installed-hooks.json: hook name -> agent -> entry
+func installedHooksPath(projectDir string) string {
+	return filepath.Join(projectDir, ".anyagent", "installed-hooks.json")
+}
+
+func (s *Session) loadInstalledHooks(projectDir string) (map[string]map[string]installedHook, error) {
+	entries := map[string]map[string]installedHook{}
+	b, err := s.Env.FS.ReadFile(installedHooksPath(projectDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", installedHooksPath(projectDir), err)
+	}
+	return entries, nil
+}
+
+func (s *Session) saveInstalledHooks(projectDir string, entries map[string]map[string]installedHook) error {
+	path := installedHooksPath(projectDir)
+	if len(entries) == 0 {
+		if err := s.Env.FS.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", path, err)
+	}
+	if err := s.Env.FS.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	return s.Env.FS.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// recordInstalledHook stores (entry != nil) or forgets the entry of a hook for an agent
+func (s *Session) recordInstalledHook(projectDir, name, agentName string, entry *installedHook) error {
+	entries, err := s.loadInstalledHooks(projectDir)
+	if err != nil {
+		return err
+	}
+	if entry != nil {
+		if entries[name] == nil {
+			entries[name] = map[string]installedHook{}
+		}
+		entries[name][agentName] = *entry
+	} else {
+		delete(entries[name], agentName)
+		if len(entries[name]) == 0 {
+			delete(entries, name)
+		}
+	}
+	return s.saveInstalledHooks(projectDir, entries)
+}
+
+// entry returns what mergeHook writes for the hook, for recording
+func (h hookDefinition) entry() installedHook {
+	return installedHook{Event: h.Event, Matcher: h.Matcher, Command: h.Command}
+}
+
+// definition turns a recorded entry back into a hook unmergeHook can match
+func (e installedHook) definition() hookDefinition {
+	return hookDefinition{Event: e.Event, Matcher: e.Matcher, Command: e.Command}
+}
+
+// mergeHook adds the hook to settings["hooks"][event], reusing a group with the same matcher.
+// Entries owned by others are left untouched. It reports whether settings changed.
+func mergeHook(settings map[string]any, h hookDefinition) bool {
+	hooks, _ := settings["hooks"].(map[string]any)
+	if hooks == nil {
+		hooks = map[string]any{}
+	}
+	groups, _ := hooks[h.Event].([]any)
+
+	entry := map[string]any{"type": "command", "command": h.Command}
+	if h.Timeout > 0 {
+		entry["timeout"] = h.Timeout
+	}
+
+	for _, g := range groups {
+		group, ok := g.(map[string]any)
+		if !ok || groupMatcher(group) != h.Matcher {
+			continue
+		}
+		list, _ := group["hooks"].([]any)
+		for i, e := range list {
+			if existing, ok := e.(map[string]any); ok && existing["command"] == h.Command {
+				if sameHookEntry(existing, entry) {
+					return false
+				}
+				list[i] = entry
+				return true
+			}
+		}
+		group["hooks"] = append(list, entry)
+		settings["hooks"] = hooks
+		hooks[h.Event] = groups
+		return true
+	}
+
+	group := map[string]any{"hooks": []any{entry}}
+	if h.Matcher != "" {
+		group["matcher"] = h.Matcher
+	}
+	hooks[h.Event] = append(groups, group)
+	settings["hooks"] = hooks
+	return true
+}
+
+// unmergeHook removes the hook entry and prunes groups, events and the hooks key left empty.
+// It reports whether settings changed.
+func unmergeHook(settings map[string]any, h hookDefinition) bool {
+	hooks, _ := settings["hooks"].(map[string]any)
+	groups, _ := hooks[h.Event].([]any)
+	changed := false
+	var keptGroups []any
+	for _, g := range groups {
+		group, ok := g.(map[string]any)
+		if !ok || groupMatcher(group) != h.Matcher {
+			keptGroups = append(keptGroups, g)
+			continue
+		}
+		list, _ := group["hooks"].([]any)
+		var kept []any
+		for _, e := range list {
+			if existing, ok := e.(map[string]any); ok && existing["command"] == h.Command {
+				changed = true
+				continue
+			}
+			kept = append(kept, e)
+		}
+		if len(kept) > 0 {
+			group["hooks"] = kept
+			keptGroups = append(keptGroups, group)
+		}
+	}
+	if !changed {
+		return false
+	}
+	if len(keptGroups) > 0 {
+		hooks[h.Event] = keptGroups
+	} else {
+		delete(hooks, h.Event)
+	}
+	if len(hooks) == 0 {
+		delete(settings, "hooks")
+	}
+	return true
+}
+
+func groupMatcher(group map[string]any) string {
+	m, _ := group["matcher"].(string)
+	return m
+}
+
+func sameHookEntry(existing, entry map[string]any) bool {
+	if existing["type"] != entry["type"] {
+		return false
+	}
+	// JSON numbers decode as float64
+	et, _ := existing["timeout"].(float64)
+	nt, _ := entry["timeout"].(int)
+	return int(et) == nt
+}
+
+// RunAddHook merges a hook template into the settings of the enabled agent(s)
+func (s *Session) RunAddHook(name, projectDir string, dryRun bool) error {
+	s.Logger.Infof("Adding %s hook to project...\n", name)
+
+	// Get project directory (current directory if not specified)
+	if projectDir == "" {
+		var err error
+		projectDir, err = s.Env.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+
+	// Make sure the project directory exists
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
+	}
+
+	s.Logger.Infof("Project directory: %s\n", projectDir)
+
+	// Check if project is initialized (has AGENTS.md)
+	if _, err := s.Env.FS.Stat(filepath.Join(projectDir, "AGENTS.md")); os.IsNotExist(err) {
+		return ErrNotInitialized
+	}
+
+	if name == "" {
+		return fmt.Errorf("hook name cannot be empty")
+	}
+	if strings.ContainsAny(name, "/\\<>:\"|?*") {
+		return fmt.Errorf("hook name contains invalid characters: %s", name)
+	}
+	// Parse up front so a broken template fails before anything is written
+	if _, err := s.loadHookDefinition(projectDir, name); err != nil {
+		return err
+	}
+
+	for _, agentName := range s.targetAgents(projectDir) {
+		if err := s.installHookForAgent(agentName, projectDir, name, dryRun); err != nil {
+			return err
+		}
+	}
+
+	if err := s.addInstalledHookToConfig(projectDir, name, dryRun); err != nil {
+		s.warnf("Warning: Failed to update project config with hook '%s': %v", name, err)
+	}
+
+	s.Logger.Infof("✅ %s hook added successfully\n", name)
+	return nil
+}
+
+// loadHookDefinition resolves and parses a hook template (project → user → embedded)
+func (s *Session) loadHookDefinition(projectDir, name string) (hookDefinition, error) {
+	content, err := config.GetHookTemplateResolved(s.Env, projectDir, name)
+	if err != nil {
+		return hookDefinition{}, err
+	}
+	return parseHookDefinition(name, content)
+}
+
+// installHookForAgent merges a single hook into the agent's settings file
+func (s *Session) installHookForAgent(agentName, projectDir, name string, dryRun bool) error {
+	path, ok := hookSettingsPath(agentName, projectDir)
+	if !ok {
+		s.warnf("Hooks are not supported by %s; '%s' is recorded and will be installed when switching to claude or gemini", agentName, name)
+		return nil
+	}
+	def, err := s.loadHookDefinition(projectDir, name)
+	if err != nil {
+		return err
+	}
+	h, ok := def.forAgent(agentName)
+	if !ok {
+		s.warnf("Hook '%s': event %s has no %s equivalent; skipped", name, def.Event, agentName)
+		return nil
+	}
+	if dryRun {
+		s.dryRunf("update", path, "Would add %s hook '%s' to %s", h.Event, name, path)
+		return nil
+	}
+	settings, err := s.readJSONObject(path)
+	if err != nil {
+		return err
+	}
+	entries, err := s.loadInstalledHooks(projectDir)
+	if err != nil {
+		return err
+	}
+	// An entry written from an older version of the template is replaced
+	changed := false
+	prev, recorded := entries[name][agentName]
+	if recorded && prev != h.entry() {
+		changed = unmergeHook(settings, prev.definition())
+	}
+	if mergeHook(settings, h) || changed {
+		if err := s.writeJSONObject(path, settings); err != nil {
+			return err
+		}
+		s.Logger.Infof("🪝 Hook '%s' (%s) added to %s\n", name, h.Event, path)
+	}
+	if !recorded || prev != h.entry() {
+		entry := h.entry()
+		if err := s.recordInstalledHook(projectDir, name, agentName, &entry); err != nil {
+			return fmt.Errorf("failed to record hook '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// uninstallHookForAgent removes a single hook from the agent's settings file
+func (s *Session) uninstallHookForAgent(agentName, projectDir, name string, dryRun bool) error {
+	path, ok := hookSettingsPath(agentName, projectDir)
+	if !ok {
+		return nil
+	}
+	// Match the entry that was installed; the template may have changed since
+	entries, err := s.loadInstalledHooks(projectDir)
+	if err != nil {
+		return err
+	}
+	if _, err := s.Env.FS.Stat(path); err != nil {
+		if _, ok := entries[name][agentName]; ok && !dryRun {
+			return s.recordInstalledHook(projectDir, name, agentName, nil)
+		}
+		return nil
+	}
+	var h hookDefinition
+	if recorded, ok := entries[name][agentName]; ok {
+		h = recorded.definition()
+	} else {
+		def, err := s.loadHookDefinition(projectDir, name)
+		if err != nil {
+			return err
+		}
+		if h, ok = def.forAgent(agentName); !ok {
+			return nil
+		}
+	}
+	settings, err := s.readJSONObject(path)
+	if err != nil {
+		return err
+	}
+	changed := unmergeHook(settings, h)
+	if dryRun {
+		if changed {
+			s.dryRunf("update", path, "Would remove hook '%s' from %s", name, path)
+		}
+		return nil
+	}
+	if changed {
+		if err := s.writeJSONObject(path, settings); err != nil {
+			return err
+		}
+		s.Logger.Infof("🗑️  Removed hook '%s' from %s\n", name, path)
+	}
+	if _, ok := entries[name][agentName]; ok {
+		if err := s.recordInstalledHook(projectDir, name, agentName, nil); err != nil {
+			return fmt.Errorf("failed to record hook '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// reinstallHooksForAgent installs the recorded hooks for the selected agent
+func (s *Session) reinstallHooksForAgent(agentName, projectDir string, hooks []string, dryRun bool) error {
+	if _, ok := hookSettingsPath(agentName, projectDir); !ok {
+		return nil
+	}
+	for _, name := range hooks {
+		if err := s.installHookForAgent(agentName, projectDir, name, dryRun); err != nil {
+			s.warnf("Warning: Could not install hook '%s' for %s: %v", name, agentName, err)
+		}
+	}
+	return nil
+}
+
+// addInstalledHookToConfig records the installed hook into the project config
+func (s *Session) addInstalledHookToConfig(projectDir, name string, dryRun bool) error {
+	configPath := config.GetProjectConfigPath(projectDir)
+	projectConfig, err := config.LoadProjectConfig(s.Env, configPath)
+	if err != nil {
+		return err
+	}
+	for _, h := range projectConfig.InstalledHooks {
+		if h == name {
+			return nil
+		}
+	}
+	projectConfig.InstalledHooks = append(projectConfig.InstalledHooks, name)
+	if dryRun {
+		s.dryRunf("record", "", "Would record installed hook '%s' into .anyagent/config.yaml", name)
+		return nil
+	}
+	if err := projectConfig.Save(s.Env, configPath); err != nil {
+		return fmt.Errorf("failed to save project config: %w", err)
+	}
+	s.Logger.Infof("💾 Project config updated: recorded hook '%s'\n", name)
+	return nil
+}
+
+// ListAvailableHooks displays all available hook templates
+func (s *Session) ListAvailableHooks(projectDir string) error {
+	hooks, err := config.ListHookTemplates(s.Env, projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to get available hooks: %w", err)
+	}
+	s.setAvailableTemplates(hooks)
+
+	if len(hooks) == 0 {
+		s.Logger.Println("No hooks available.")
+		return nil
+	}
+
+	s.Logger.Println("Available hooks:")
+	for _, h := range hooks {
+		s.Logger.Printf("  • %s (%s)\n", h.Name, h.Source)
+	}
+	s.Logger.Printf("\nUsage: anyagent add hook <hook-name>\n")
+	return nil
+}
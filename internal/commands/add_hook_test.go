@@ -0,0 +1,219 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+func TestRunAddHookMergesClaudeSettings(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "AGENTS.md"), []byte("# Test"), 0644); err != nil {
+		t.Fatalf("failed to create AGENTS.md: %v", err)
+	}
+	if err := config.SaveProjectConfig(s.Env, tempDir, &config.ProjectConfig{EnabledAgents: []string{"claude"}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	// Keys and hooks not owned by anyagent must survive add and remove
+	settingsPath := filepath.Join(tempDir, ".claude", "settings.json")
+	existing := `{
+  "permissions": {"allow": ["Bash(go test:*)"]},
+  "hooks": {
+    "PostToolUse": [
+      {"matcher": "Edit|MultiEdit|Write", "hooks": [{"type": "command", "command": "echo edited"}]}
+    ]
+  }
+}`
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(settingsPath, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.RunAddHook("gofmt", tempDir, false); err != nil {
+		t.Fatalf("RunAddHook failed: %v", err)
+	}
+	// Adding twice must not duplicate the entry
+	if err := s.RunAddHook("gofmt", tempDir, false); err != nil {
+		t.Fatalf("second RunAddHook failed: %v", err)
+	}
+
+	settings, err := s.readJSONObject(settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := settings["permissions"]; !ok {
+		t.Errorf("permissions key was clobbered: %v", settings)
+	}
+	groups := settings["hooks"].(map[string]any)["PostToolUse"].([]any)
+	if len(groups) != 1 {
+		t.Fatalf("expected hook to join the existing matcher group, got %d groups", len(groups))
+	}
+	entries := groups[0].(map[string]any)["hooks"].([]any)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 hook entries, got %d: %v", len(entries), entries)
+	}
+	if cmd := entries[1].(map[string]any)["command"].(string); !strings.Contains(cmd, "gofmt -w") {
+		t.Errorf("unexpected hook command: %s", cmd)
+	}
+
+	cfg, _ := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(tempDir))
+	if len(cfg.InstalledHooks) != 1 || cfg.InstalledHooks[0] != "gofmt" {
+		t.Fatalf("hook not recorded: %v", cfg.InstalledHooks)
+	}
+
+	if err := s.RunRemoveHook("gofmt", tempDir, false); err != nil {
+		t.Fatalf("RunRemoveHook failed: %v", err)
+	}
+	b, _ := os.ReadFile(settingsPath)
+	var after map[string]any
+	if err := json.Unmarshal(b, &after); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "echo edited") || strings.Contains(string(b), "gofmt") {
+		t.Errorf("remove should only drop the anyagent hook:\n%s", b)
+	}
+	if _, ok := after["permissions"]; !ok {
+		t.Errorf("permissions key was clobbered on remove")
+	}
+}
+
+func TestHookSwitchToGemini(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "AGENTS.md"), []byte("# Test"), 0644); err != nil {
+		t.Fatalf("failed to create AGENTS.md: %v", err)
+	}
+	if err := config.SaveProjectConfig(s.Env, tempDir, &config.ProjectConfig{EnabledAgents: []string{"claude"}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	if err := s.RunAddHook("gofmt", tempDir, false); err != nil {
+		t.Fatalf("RunAddHook failed: %v", err)
+	}
+
+	if err := s.RunSwitch(tempDir, "gemini", false); err != nil {
+		t.Fatalf("RunSwitch failed: %v", err)
+	}
+
+	// Claude settings had nothing else, so the hooks key disappears entirely
+	claude, err := s.readJSONObject(filepath.Join(tempDir, ".claude", "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := claude["hooks"]; ok {
+		t.Errorf("claude hooks should be removed after switch: %v", claude)
+	}
+
+	gemini, err := s.readJSONObject(filepath.Join(tempDir, ".gemini", "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups, ok := gemini["hooks"].(map[string]any)["AfterTool"].([]any)
+	if !ok || len(groups) != 1 {
+		t.Fatalf("expected AfterTool hook in gemini settings: %v", gemini)
+	}
+	if m := groups[0].(map[string]any)["matcher"]; m != "replace|write_file" {
+		t.Errorf("matcher should use gemini tool names, got %v", m)
+	}
+}
+
+func TestMergeHookPrunesEmptyOnUnmerge(t *testing.T) {
+	h := hookDefinition{Event: "Stop", Command: "make lint"}
+	settings := map[string]any{"model": "opus"}
+	if !mergeHook(settings, h) {
+		t.Fatal("mergeHook should report a change")
+	}
+	if mergeHook(settings, h) {
+		t.Error("merging the same hook twice should be a no-op")
+	}
+	if !unmergeHook(settings, h) {
+		t.Fatal("unmergeHook should report a change")
+	}
+	if _, ok := settings["hooks"]; ok || settings["model"] != "opus" {
+		t.Errorf("unexpected settings after unmerge: %v", settings)
+	}
+}
+
+func TestHookGeminiTimeoutInMilliseconds(t *testing.T) {
+	def, err := parseHookDefinition("gofmt", "event: PostToolUse\nmatcher: Write\ncommand: gofmt -w .\ntimeout: 30\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, _ := def.forAgent("claude"); h.Timeout != 30 {
+		t.Errorf("claude timeout = %d, want 30 seconds", h.Timeout)
+	}
+	if h, _ := def.forAgent("gemini"); h.Timeout != 30000 {
+		t.Errorf("gemini timeout = %d, want 30000 milliseconds", h.Timeout)
+	}
+}
+
+func TestRemoveHookAfterTemplateChange(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "AGENTS.md"), []byte("# Test"), 0644); err != nil {
+		t.Fatalf("failed to create AGENTS.md: %v", err)
+	}
+	if err := config.SaveProjectConfig(s.Env, tempDir, &config.ProjectConfig{EnabledAgents: []string{"claude"}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	template := filepath.Join(tempDir, ".anyagent", "hooks", "lint.yaml")
+	writeTestFile(t, template, "event: Stop\ncommand: make lint\n")
+	if err := s.RunAddHook("lint", tempDir, false); err != nil {
+		t.Fatalf("RunAddHook failed: %v", err)
+	}
+
+	// The template is edited after install; removal still finds the installed entry
+	writeTestFile(t, template, "event: Stop\ncommand: make lint-all\n")
+	if err := s.RunRemoveHook("lint", tempDir, false); err != nil {
+		t.Fatalf("RunRemoveHook failed: %v", err)
+	}
+	b, _ := os.ReadFile(filepath.Join(tempDir, ".claude", "settings.json"))
+	if strings.Contains(string(b), "make lint") {
+		t.Errorf("installed hook entry left behind:\n%s", b)
+	}
+	if _, err := os.Stat(installedHooksPath(tempDir)); !os.IsNotExist(err) {
+		t.Errorf("installed hook record should be gone, stat err = %v", err)
+	}
+}
+
+func TestSyncReplacesHookFromEditedTemplate(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "AGENTS.md"), []byte("# Test"), 0644); err != nil {
+		t.Fatalf("failed to create AGENTS.md: %v", err)
+	}
+	if err := config.SaveProjectConfig(s.Env, tempDir, &config.ProjectConfig{EnabledAgents: []string{"claude"}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	template := filepath.Join(tempDir, ".anyagent", "hooks", "lint.yaml")
+	writeTestFile(t, template, "event: Stop\ncommand: make lint\n")
+	if err := s.RunAddHook("lint", tempDir, false); err != nil {
+		t.Fatalf("RunAddHook failed: %v", err)
+	}
+	writeTestFile(t, template, "event: Stop\ncommand: make lint-all\n")
+	if err := s.reinstallHooksForAgent("claude", tempDir, []string{"lint"}, false); err != nil {
+		t.Fatal(err)
+	}
+	settings, err := s.readJSONObject(filepath.Join(tempDir, ".claude", "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := settings["hooks"].(map[string]any)["Stop"].([]any)
+	entries := groups[0].(map[string]any)["hooks"].([]any)
+	if len(entries) != 1 || entries[0].(map[string]any)["command"] != "make lint-all" {
+		t.Errorf("expected only the edited hook, got %v", entries)
+	}
+}
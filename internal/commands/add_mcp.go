@@ -1,10 +1,11 @@
 package commands
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -12,119 +13,254 @@ import (
 	"github.com/shibukawa/anyagent/internal/config"
 )
 
+// AddMCPParams holds the options of 'anyagent add mcp'
+type AddMCPParams struct {
+	Name           string
+	Cmd            string   // command line launching a stdio server (POSIX shell quoting)
+	Args           []string // extra arguments appended verbatim after the --cmd words
+	Env            []string // KEY=VALUE environment variables
+	URL            string   // endpoint of a remote (http/sse) server
+	Headers        []string // KEY=VALUE or "Key: Value" HTTP headers
+	Transport      string   // stdio, http or sse (default: stdio, or http when URL is set)
+	StartupTimeout int      // seconds
+	ToolTimeout    int      // seconds
+	ProjectDir     string
+	DryRun         bool
+	Global         bool
+	Force          bool     // store literal values that look like secrets
+	Preset         string   // catalog preset id (templates/mcp.yaml); the name defaults to it
+	Agents         []string // only write the server for these agents
+	ExcludeAgents  []string // never write the server for these agents
+	Disabled       bool     // record the server without writing it to any agent
+}
+
 // RunAddMCP adds/updates an MCP server definition for this project, records it in .anyagent.yaml,
 // writes/updates project mcp.yaml, and creates agent-specific symlinks to the project file.
-func RunAddMCP(name, cmdline, projectDir string, dryRun bool, global bool) error {
+func (s *Session) RunAddMCP(params AddMCPParams) error {
+	name := params.Name
+	projectDir := params.ProjectDir
+	dryRun := params.DryRun
 	if name == "" {
-		return fmt.Errorf("MCP server name cannot be empty")
+		name = params.Preset
 	}
-	if strings.TrimSpace(cmdline) == "" {
-		return fmt.Errorf("--cmd cannot be empty")
+	if name == "" {
+		return fmt.Errorf("MCP server name cannot be empty")
 	}
 
 	// Resolve project directory
 	if projectDir == "" {
 		var err error
-		projectDir, err = os.Getwd()
+		projectDir, err = s.Env.Getwd()
 		if err != nil {
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 	}
-	if _, err := os.Stat(projectDir); os.IsNotExist(err) {
-		return fmt.Errorf("project directory does not exist: %s", projectDir)
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
 	}
 
 	// Must be initialized (AGENTS.md present)
-	if _, err := os.Stat(filepath.Join(projectDir, "AGENTS.md")); os.IsNotExist(err) {
-		return fmt.Errorf("project is not initialized with anyagent. Run 'anyagent sync' first")
+	if _, err := s.Env.FS.Stat(filepath.Join(projectDir, "AGENTS.md")); os.IsNotExist(err) {
+		return ErrNotInitialized
 	}
 
-	fmt.Printf("Adding MCP server '%s' to project...\n", name)
+	params.ProjectDir = projectDir
+	server, err := s.buildMCPServer(params)
+	if err != nil {
+		return err
+	}
+
+	s.Logger.Infof("Adding MCP server '%s' to project...\n", name)
 
 	// Update .anyagent.yaml
 	cfgPath := config.GetProjectConfigPath(projectDir)
-	cfg, err := config.LoadProjectConfig(cfgPath)
+	cfg, err := config.LoadProjectConfig(s.Env, cfgPath)
 	if err != nil {
 		return fmt.Errorf("failed to load project config: %w", err)
 	}
 	if cfg.MCPServers == nil {
-		cfg.MCPServers = map[string]string{}
+		cfg.MCPServers = map[string]config.MCPServer{}
 	}
-	cfg.MCPServers[name] = cmdline
+	cfg.MCPServers[name] = server
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would record MCP server '%s' in .anyagent.yaml with cmd: %s\n", name, cmdline)
+		s.dryRunf("record", "", "Would record MCP server '%s' in .anyagent.yaml (%s)", name, describeMCPServer(server))
 	} else {
-		if err := cfg.Save(cfgPath); err != nil {
+		if err := cfg.Save(s.Env, cfgPath); err != nil {
 			return fmt.Errorf("failed to save project config: %w", err)
 		}
-		fmt.Printf("💾 Recorded MCP server '%s' in .anyagent.yaml\n", name)
+		s.Logger.Infof("💾 Recorded MCP server '%s' in .anyagent.yaml\n", name)
 	}
 
 	// Ensure mcp.yaml in project root is updated
-	if err := writeOrUpdateProjectMCP(projectDir, cfg.MCPServers, dryRun); err != nil {
+	if err := s.writeOrUpdateProjectMCP(projectDir, cfg.MCPServers, dryRun); err != nil {
 		return err
 	}
 
 	// Create agent-specific MCP config files based on enabled agent(s)
-	if err := ensureMCPFilesForEnabledAgents(projectDir, dryRun); err != nil {
+	if err := s.ensureMCPFilesForEnabledAgents(projectDir, dryRun); err != nil {
 		return err
 	}
+	for _, agent := range cfg.EnabledAgents {
+		if !server.Disabled && !server.EnabledFor(agent) {
+			s.Logger.Infof("ℹ️  '%s' is not written for %s (agents setting)\n", name, agentDisplayName(agent))
+		}
+	}
 
 	// If --global and Codex is selected, write to ~/.codex/config.toml directly for this server
-	if global {
-		if selectedAgent(projectDir) == "codex" {
-			if err := updateCodexMCPMapp(map[string]string{name: cmdline}, dryRun); err != nil {
+	// unless it is disabled or excluded for Codex
+	if params.Global {
+		if s.selectedAgent(projectDir) == "codex" && server.EnabledFor("codex") {
+			if err := s.updateCodexMCPConfig(map[string]config.MCPServer{name: server}, dryRun); err != nil {
 				return err
 			}
+			s.acquireGlobalArtifacts(projectDir, []string{s.codexMCPArtifact(name)}, dryRun)
 		}
 	}
 
-	fmt.Printf("✅ MCP server '%s' added/updated successfully\n", name)
+	s.Logger.Infof("✅ MCP server '%s' added/updated successfully\n", name)
 	return nil
 }
 
-// writeOrUpdateProjectMCP materializes mcp.yaml aggregating servers from config.
-func writeOrUpdateProjectMCP(projectDir string, servers map[string]string, dryRun bool) error {
-	// Convert to YAML structure similar to templates/mcp.yaml
-	type serverDef struct {
-		Command string   `yaml:"command"`
-		Args    []string `yaml:"args,omitempty"`
+// buildMCPServer turns the add mcp flags into a validated server definition
+func (s *Session) buildMCPServer(params AddMCPParams) (config.MCPServer, error) {
+	if params.Preset != "" {
+		server, err := s.presetMCPServer(params, params.ProjectDir)
+		if err != nil {
+			return server, err
+		}
+		return finishMCPServer(server, params)
 	}
-	type mcpConfig struct {
-		Servers map[string]serverDef `yaml:"servers"`
+	server := config.MCPServer{
+		Transport:      params.Transport,
+		URL:            params.URL,
+		StartupTimeout: params.StartupTimeout,
+		ToolTimeout:    params.ToolTimeout,
 	}
-	out := mcpConfig{Servers: map[string]serverDef{}}
-	for name, cmdline := range servers {
-		fields := strings.Fields(cmdline)
-		if len(fields) == 0 {
-			continue
+	if strings.TrimSpace(params.Cmd) != "" {
+		fields, err := config.SplitCommandLine(params.Cmd)
+		if err != nil {
+			return server, fmt.Errorf("invalid --cmd: %w", err)
+		}
+		server.Command = fields[0]
+		server.Args = append(fields[1:], params.Args...)
+	} else if params.URL == "" {
+		return server, fmt.Errorf("either --cmd or --url is required")
+	} else if len(params.Args) > 0 {
+		return server, fmt.Errorf("--arg requires --cmd")
+	}
+	env, err := ParseKeyValues(params.Env, "--env")
+	if err != nil {
+		return server, err
+	}
+	server.Env = env
+	headers, err := ParseKeyValues(params.Headers, "--header")
+	if err != nil {
+		return server, err
+	}
+	server.Headers = headers
+	return finishMCPServer(server, params)
+}
+
+// finishMCPServer validates a server built from flags or a preset and returns its stored form
+func finishMCPServer(server config.MCPServer, params AddMCPParams) (config.MCPServer, error) {
+	if len(params.Agents) > 0 {
+		server.Agents = params.Agents
+	}
+	if len(params.ExcludeAgents) > 0 {
+		server.ExcludeAgents = params.ExcludeAgents
+	}
+	server.Disabled = server.Disabled || params.Disabled
+	if err := validateMCPAgents(server); err != nil {
+		return server, err
+	}
+	if len(server.Headers) > 0 && !server.IsRemote() {
+		return server, fmt.Errorf("--header only applies to http/sse servers")
+	}
+	if err := server.Validate(); err != nil {
+		return server, err
+	}
+	if !params.Force {
+		if err := checkMCPSecrets(server); err != nil {
+			return server, err
+		}
+	}
+	// The default transport is implied by command/url; keep config.yaml minimal
+	return server.Normalize(), nil
+}
+
+// validateMCPAgents checks the agents and exclude_agents names of a server
+func validateMCPAgents(s config.MCPServer) error {
+	known := mcpAgents()
+	for _, list := range [][]string{s.Agents, s.ExcludeAgents} {
+		for _, agent := range list {
+			if !slices.Contains(known, agent) {
+				return fmt.Errorf("unknown agent '%s'. Available agents: %s", agent, strings.Join(known, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// ParseKeyValues parses KEY=VALUE (or "Key: Value") flag values into a map
+func ParseKeyValues(values []string, flag string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	out := map[string]string{}
+	for _, v := range values {
+		i := strings.IndexAny(v, "=:")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid %s value %q (expected KEY=VALUE)", flag, v)
 		}
-		sd := serverDef{Command: fields[0]}
-		if len(fields) > 1 {
-			sd.Args = fields[1:]
+		out[strings.TrimSpace(v[:i])] = strings.TrimSpace(v[i+1:])
+	}
+	return out, nil
+}
+
+// describeMCPServer returns a one-line summary of a server (command line or URL)
+func describeMCPServer(s config.MCPServer) string {
+	if s.IsRemote() {
+		return fmt.Sprintf("%s %s", s.TransportType(), s.URL)
+	}
+	return strings.TrimSpace(s.Command + " " + strings.Join(s.Args, " "))
+}
+
+// mcpServersForAgent returns the servers written to the agent's MCP config: disabled servers and
+// servers limited to other agents stay recorded but are left out
+func mcpServersForAgent(servers map[string]config.MCPServer, agent string) map[string]config.MCPServer {
+	out := map[string]config.MCPServer{}
+	for name, s := range servers {
+		if s.EnabledFor(agent) {
+			out[name] = s
 		}
-		out.Servers[name] = sd
 	}
+	return out
+}
 
-	data, err := yaml.Marshal(&out)
+// writeOrUpdateProjectMCP materializes mcp.yaml aggregating servers from config.
+func (s *Session) writeOrUpdateProjectMCP(projectDir string, servers map[string]config.MCPServer, dryRun bool) error {
+	type mcpConfig struct {
+		Servers map[string]config.MCPServer `yaml:"servers"`
+	}
+	data, err := yaml.Marshal(&mcpConfig{Servers: servers})
 	if err != nil {
 		return fmt.Errorf("failed to marshal mcp.yaml: %w", err)
 	}
 	path := filepath.Join(projectDir, "mcp.yaml")
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would write project MCP config: %s\n", path)
+		s.dryRunf("write", path, "Would write project MCP config: %s", path)
 		return nil
 	}
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := s.Env.FS.WriteFile(path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write %s: %w", path, err)
 	}
-	fmt.Printf("📄 Project MCP config updated: mcp.yaml\n")
+	s.Logger.Infof("📄 Project MCP config updated: mcp.yaml\n")
 	return nil
 }
 
 // ensureMCPFilesForEnabledAgents writes agent-specific MCP config files
-func ensureMCPFilesForEnabledAgents(projectDir string, dryRun bool) error {
-	cfg, err := config.LoadProjectConfig(config.GetProjectConfigPath(projectDir))
+func (s *Session) ensureMCPFilesForEnabledAgents(projectDir string, dryRun bool) error {
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
 	if err != nil {
 		return nil
 	}
@@ -132,254 +268,423 @@ func ensureMCPFilesForEnabledAgents(projectDir string, dryRun bool) error {
 		return nil
 	}
 	for _, a := range cfg.EnabledAgents {
-		if err := ensureMCPFilesForAgent(a, projectDir, cfg.MCPServers, dryRun); err != nil {
+		if err := s.ensureMCPFilesForAgent(a, projectDir, cfg.MCPServers, dryRun); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func ensureMCPFilesForAgent(agentName, projectDir string, servers map[string]string, dryRun bool) error {
+func (s *Session) ensureMCPFilesForAgent(agentName, projectDir string, servers map[string]config.MCPServer, dryRun bool) error {
 	switch agentName {
-	case "copilot":
-		// VS Code Copilot: .vscode/mcp.json
-		path := filepath.Join(projectDir, ".vscode", "mcp.json")
-		if dryRun {
-			fmt.Printf("[DRY RUN] Would write Copilot MCP config: %s\n", path)
-			return nil
-		}
-		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-			return fmt.Errorf("failed to create .vscode: %w", err)
-		}
-		data, err := buildCopilotMCPJSON(servers)
-		if err != nil {
+	case "copilot", "qdev", "claude", "gemini":
+		path, _ := s.mcpConfigPath(agentName, projectDir)
+		if err := s.mergeMCPJSON(agentName, path, servers, dryRun); err != nil {
 			return err
 		}
-		if err := os.WriteFile(path, data, 0644); err != nil {
-			return fmt.Errorf("failed to write %s: %w", path, err)
+		// Earlier versions wrote YAML files that neither Claude Code nor Gemini CLI read
+		if legacy := legacyMCPYAMLPath(agentName, projectDir); legacy != "" {
+			return s.removePath(legacy, "legacy MCP config", dryRun)
 		}
-		fmt.Printf("📄 Copilot MCP config generated: %s\n", path)
 		return nil
-	case "qdev":
-		// Amazon Q Developer: .amazonq/mcp.json
-		path := filepath.Join(projectDir, ".amazonq", "mcp.json")
-		if dryRun {
-			fmt.Printf("[DRY RUN] Would write Q Dev MCP config: %s\n", path)
-			return nil
-		}
-		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-			return fmt.Errorf("failed to create .amazonq: %w", err)
-		}
-		data, err := buildCopilotMCPJSON(servers)
-		if err != nil {
-			return err
-		}
-		if err := os.WriteFile(path, data, 0644); err != nil {
-			return fmt.Errorf("failed to write %s: %w", path, err)
-		}
-		fmt.Printf("📄 Q Dev MCP config generated: %s\n", path)
-		return nil
-	case "claude":
-		path := filepath.Join(projectDir, ".claude", "mcp.yaml")
-		return writeMCPYAML(path, servers, dryRun)
 	case "junie":
 		path := filepath.Join(projectDir, ".junie", "mcp.yaml")
-		return writeMCPYAML(path, servers, dryRun)
-	case "gemini":
-		path := filepath.Join(projectDir, ".gemini", "mcp.yaml")
-		return writeMCPYAML(path, servers, dryRun)
+		return s.writeMCPYAML(path, mcpServersForAgent(servers, agentName), dryRun)
 	case "codex":
 		// Do not modify global config automatically; warn if missing and suggest --global
-		missing := missingCodexMCPServers(servers)
+		codexServers := mcpServersForAgent(servers, agentName)
+		missing, err := s.missingCodexMCPServers(codexServers)
+		if err != nil {
+			s.warnf("Warning: Could not check the Codex MCP servers: %v", err)
+			return nil
+		}
 		if len(missing) > 0 {
-			fmt.Printf("⚠️  Some Codex MCP servers are not installed globally: %v\n", missing)
-			fmt.Printf("   Enable with: anyagent add mcp <name> --global\n")
+			s.warnf("Some Codex MCP servers are not installed globally: %v", missing)
+			s.Logger.Infof("   Enable with: anyagent add mcp <name> --global\n")
 		}
+		// Installed servers are registered as used by this project
+		var present []string
+		for name := range codexServers {
+			if !slices.Contains(missing, name) {
+				present = append(present, s.codexMCPArtifact(name))
+			}
+		}
+		s.acquireGlobalArtifacts(projectDir, present, dryRun)
 		return nil
 	default:
 		return nil
 	}
 }
 
-func buildCopilotMCPJSON(servers map[string]string) ([]byte, error) {
-	type serverJSON struct {
-		Command string            `json:"command"`
-		Args    []string          `json:"args,omitempty"`
-		Env     map[string]string `json:"env,omitempty"`
+// legacyMCPYAMLPath returns the YAML MCP file older versions generated for the agent, if any
+func legacyMCPYAMLPath(agentName, projectDir string) string {
+	switch agentName {
+	case "claude":
+		return filepath.Join(projectDir, ".claude", "mcp.yaml")
+	case "gemini":
+		return filepath.Join(projectDir, ".gemini", "mcp.yaml")
 	}
-	type mcpJSON struct {
-		MCPServers map[string]serverJSON `json:"mcpServers"`
+	return ""
+}
+
+// mcpServersKey returns the JSON key holding server definitions in the agent's MCP file
+func mcpServersKey(agentName string) string {
+	if agentName == "copilot" {
+		return "servers" // VS Code .vscode/mcp.json
 	}
-	out := mcpJSON{MCPServers: map[string]serverJSON{}}
-	for name, cmdline := range servers {
-		fields := strings.Fields(cmdline)
-		if len(fields) == 0 {
-			continue
+	return "mcpServers"
+}
+
+// mcpServerEntry renders one server in the agent's native JSON shape:
+//
+//	VS Code:     {"type": "stdio", "command", "args", "env", "cwd"} / {"type": "http"|"sse", "url", "headers"}
+//	Claude Code: {"type": "stdio", "command", "args", "env"} / {"type": "http"|"sse", "url", "headers"}
+//	Gemini CLI:  {"command", "args", "env", "cwd", "timeout"} / {"url"} for SSE / {"httpUrl"} for streamable HTTP
+//	Q Dev:       {"command", "args", "env"} / {"type", "url", "headers"}
+func mcpServerEntry(agentName string, s config.MCPServer) map[string]any {
+	s = resolveMCPPlaceholders(agentName, s)
+	entry := map[string]any{}
+	if s.ToolTimeout > 0 && agentName == "gemini" {
+		entry["timeout"] = s.ToolTimeout * 1000 // milliseconds
+	}
+	if s.IsRemote() {
+		switch {
+		case agentName == "gemini" && s.TransportType() == config.MCPTransportHTTP:
+			entry["httpUrl"] = s.URL
+		case agentName == "gemini":
+			entry["url"] = s.URL
+		default:
+			entry["type"] = s.TransportType()
+			entry["url"] = s.URL
 		}
-		sj := serverJSON{Command: fields[0]}
-		if len(fields) > 1 {
-			sj.Args = fields[1:]
+		if len(s.Headers) > 0 {
+			entry["headers"] = s.Headers
 		}
-		out.MCPServers[name] = sj
+		return entry
 	}
-	return json.MarshalIndent(out, "", "  ")
+	if agentName == "copilot" || agentName == "claude" {
+		entry["type"] = config.MCPTransportStdio
+	}
+	entry["command"] = s.Command
+	args := s.Args
+	if args == nil {
+		args = []string{}
+	}
+	entry["args"] = args
+	if len(s.Env) > 0 {
+		entry["env"] = s.Env
+	}
+	if s.Cwd != "" && (agentName == "copilot" || agentName == "gemini") {
+		entry["cwd"] = s.Cwd
+	}
+	return entry
+}
+
+// resolveMCPPlaceholders rewrites ${env:NAME}/${input:name} for the agent. VS Code understands
+// both natively. Claude Code and Gemini CLI interpolate ${NAME}. Q Dev has no interpolation, so env
+// entries that only forward a variable of the same name are dropped and inherited from its
+// environment; other placeholders are left as they are (see qdevUnexpandedPlaceholders).
+func resolveMCPPlaceholders(agentName string, s config.MCPServer) config.MCPServer {
+	switch agentName {
+	case "copilot":
+		return s
+	case "qdev":
+		out := s
+		if len(s.Env) > 0 {
+			out.Env = map[string]string{}
+			for k, v := range s.Env {
+				if name, ok := wholePlaceholderEnv(v); !ok || name != k {
+					out.Env[k] = v
+				}
+			}
+		}
+		return out
+	}
+	mapValues := func(m map[string]string) map[string]string {
+		if len(m) == 0 {
+			return m
+		}
+		out := map[string]string{}
+		for k, v := range m {
+			out[k] = interpolateMCPValue(v)
+		}
+		return out
+	}
+	out := s
+	out.Env = mapValues(s.Env)
+	out.Headers = mapValues(s.Headers)
+	out.URL = interpolateMCPValue(s.URL)
+	out.Args = nil
+	for _, a := range s.Args {
+		out.Args = append(out.Args, interpolateMCPValue(a))
+	}
+	return out
 }
 
-func writeMCPYAML(path string, servers map[string]string, dryRun bool) error {
-	type serverDef struct {
-		Command string   `yaml:"command"`
-		Args    []string `yaml:"args,omitempty"`
+// qdevUnexpandedPlaceholders returns the settings of a server that Q Dev receives with
+// placeholders it cannot expand, sorted
+func qdevUnexpandedPlaceholders(s config.MCPServer) []string {
+	s = resolveMCPPlaceholders("qdev", s)
+	var fields []string
+	if mcpPlaceholderPattern.MatchString(s.URL) {
+		fields = append(fields, "url")
+	}
+	for _, a := range s.Args {
+		if mcpPlaceholderPattern.MatchString(a) {
+			fields = append(fields, "args")
+			break
+		}
 	}
-	type mcpConfig struct {
-		Servers map[string]serverDef `yaml:"servers"`
+	for k, v := range s.Env {
+		if mcpPlaceholderPattern.MatchString(v) {
+			fields = append(fields, "env "+k)
+		}
 	}
-	out := mcpConfig{Servers: map[string]serverDef{}}
-	for name, cmdline := range servers {
-		fields := strings.Fields(cmdline)
-		if len(fields) == 0 {
+	for k, v := range s.Headers {
+		if mcpPlaceholderPattern.MatchString(v) {
+			fields = append(fields, "header "+k)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// mergeMCPJSON upserts the servers into the agent's JSON MCP file and drops the ones not enabled for it. Other keys
+// (e.g. the rest of .gemini/settings.json or VS Code "inputs") and servers not managed by anyagent are kept.
+func (s *Session) mergeMCPJSON(agentName, path string, servers map[string]config.MCPServer, dryRun bool) error {
+	if dryRun {
+		s.dryRunf("update", path, "Would update %s MCP config: %s", agentDisplayName(agentName), path)
+		return nil
+	}
+	obj, err := s.readJSONObject(path)
+	if err != nil {
+		return err
+	}
+	key := mcpServersKey(agentName)
+	existing, _ := obj[key].(map[string]any)
+	if existing == nil {
+		existing = map[string]any{}
+	}
+	for name, server := range servers {
+		if !server.EnabledFor(agentName) {
+			delete(existing, name)
 			continue
 		}
-		sd := serverDef{Command: fields[0]}
-		if len(fields) > 1 {
-			sd.Args = fields[1:]
+		existing[name] = mcpServerEntry(agentName, server)
+		if agentName == "qdev" {
+			if fields := qdevUnexpandedPlaceholders(server); len(fields) > 0 {
+				s.warnf("Warning: %s does not expand ${env:...}/${input:...}; '%s' gets them literally in %s", agentDisplayName(agentName), name, strings.Join(fields, ", "))
+			}
 		}
-		out.Servers[name] = sd
 	}
-	data, err := yaml.Marshal(&out)
+	obj[key] = existing
+	if agentName == "copilot" {
+		mergeVSCodeInputs(obj, mcpInputNames(mcpServersForAgent(servers, agentName)))
+	}
+	// Earlier versions wrote VS Code servers under "mcpServers", which VS Code ignores
+	if legacy, ok := obj["mcpServers"].(map[string]any); ok && key != "mcpServers" {
+		for name := range servers {
+			delete(legacy, name)
+		}
+		if len(legacy) == 0 {
+			delete(obj, "mcpServers")
+		}
+	}
+	if err := s.writeJSONObject(path, obj); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	s.Logger.Infof("📄 %s MCP config updated: %s\n", agentDisplayName(agentName), path)
+	return nil
+}
+
+func (s *Session) writeMCPYAML(path string, servers map[string]config.MCPServer, dryRun bool) error {
+	type mcpConfig struct {
+		Servers map[string]config.MCPServer `yaml:"servers"`
+	}
+	data, err := yaml.Marshal(&mcpConfig{Servers: servers})
 	if err != nil {
 		return fmt.Errorf("failed to marshal mcp.yaml: %w", err)
 	}
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would write MCP config: %s\n", path)
+		s.dryRunf("write", path, "Would write MCP config: %s", path)
 		return nil
 	}
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+	if err := s.Env.FS.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return fmt.Errorf("failed to create dir for MCP: %w", err)
 	}
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := s.Env.FS.WriteFile(path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write %s: %w", path, err)
 	}
-	fmt.Printf("📄 MCP config generated: %s\n", path)
+	s.Logger.Infof("📄 MCP config generated: %s\n", path)
 	return nil
 }
 
-// updateCodexMCPConfig writes/updates MCP servers into ~/.codex/config.toml
-func updateCodexMCPConfig(servers map[string]string, dryRun bool) error {
+// updateCodexMCPConfig writes/updates MCP servers into ~/.codex/config.toml, leaving the rest of the file as is
+func (s *Session) updateCodexMCPConfig(servers map[string]config.MCPServer, dryRun bool) error {
 	if len(servers) == 0 {
 		return nil
 	}
-	homeDir, err := os.UserHomeDir()
+	codexFile, err := s.codexConfigPath()
 	if err != nil {
-		return nil
+		return fmt.Errorf("failed to locate the Codex config: %w", err)
 	}
-	codexDir := filepath.Join(homeDir, ".codex")
-	codexFile := filepath.Join(codexDir, "config.toml")
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would update Codex MCP config: %s\n", codexFile)
+		s.dryRunf("update", codexFile, "Would update Codex MCP config: %s", codexFile)
 		return nil
 	}
-	if err := os.MkdirAll(codexDir, 0755); err != nil {
+	if err := s.Env.FS.MkdirAll(filepath.Dir(codexFile), 0755); err != nil {
 		return fmt.Errorf("failed to create .codex directory: %w", err)
 	}
 	var content string
-	if b, err := os.ReadFile(codexFile); err == nil {
+	if b, err := s.Env.FS.ReadFile(codexFile); err == nil {
 		content = string(b)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", codexFile, err)
 	}
-	// Upsert each server section
-	for name, cmdline := range servers {
-		fields := strings.Fields(cmdline)
-		if len(fields) == 0 {
-			continue
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if content, err = setCodexMCPServer(content, name, codexMCPSection(name, servers[name])); err != nil {
+			return fmt.Errorf("failed to update %s: %w", codexFile, err)
+		}
+	}
+	return s.Env.FS.WriteFile(codexFile, []byte(content), 0644)
+}
+
+// codexMCPSection renders the [mcp_servers.<name>] table for ~/.codex/config.toml.
+// Codex does not interpolate values, so placeholders become its forwarding options:
+// env entries go to env_vars, headers to env_http_headers or bearer_token_env_var.
+func codexMCPSection(name string, s config.MCPServer) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[mcp_servers.%s]\n", tomlKey(name))
+	if s.IsRemote() {
+		fmt.Fprintf(&sb, "url = %s\n", tomlQuote(interpolateMCPValue(s.URL)))
+		headers := map[string]string{}
+		envHeaders := map[string]string{}
+		for k, v := range s.Headers {
+			if token, ok := strings.CutPrefix(v, "Bearer "); ok && strings.EqualFold(k, "Authorization") {
+				if env, ok := wholePlaceholderEnv(token); ok {
+					fmt.Fprintf(&sb, "bearer_token_env_var = %s\n", tomlQuote(env))
+					continue
+				}
+			}
+			if env, ok := wholePlaceholderEnv(v); ok {
+				envHeaders[k] = env
+			} else {
+				headers[k] = interpolateMCPValue(v)
+			}
+		}
+		if len(headers) > 0 {
+			fmt.Fprintf(&sb, "http_headers = %s\n", tomlInlineTable(headers))
+		}
+		if len(envHeaders) > 0 {
+			fmt.Fprintf(&sb, "env_http_headers = %s\n", tomlInlineTable(envHeaders))
 		}
-		cmd := tomlQuote(fields[0])
+	} else {
 		var args []string
-		for _, a := range fields[1:] {
-			args = append(args, tomlQuote(a))
+		for _, a := range s.Args {
+			args = append(args, tomlQuote(interpolateMCPValue(a)))
+		}
+		fmt.Fprintf(&sb, "command = %s\nargs = [%s]\n", tomlQuote(s.Command), strings.Join(args, ", "))
+	}
+	if s.Cwd != "" {
+		fmt.Fprintf(&sb, "cwd = %s\n", tomlQuote(s.Cwd))
+	}
+	env := map[string]string{}
+	var forward []string
+	for k, v := range s.Env {
+		if name, ok := wholePlaceholderEnv(v); ok && name == k {
+			forward = append(forward, k)
+		} else {
+			env[k] = interpolateMCPValue(v)
 		}
-		section := fmt.Sprintf("[mcp_servers.%s]\ncommand = %s\nargs = [%s]\n", name, cmd, strings.Join(args, ", "))
-		content = upsertTomlSection(content, "mcp_servers."+name, section)
 	}
-	return os.WriteFile(codexFile, []byte(content), 0644)
+	if len(env) > 0 {
+		fmt.Fprintf(&sb, "env = %s\n", tomlInlineTable(env))
+	}
+	if s.StartupTimeout > 0 {
+		fmt.Fprintf(&sb, "startup_timeout_sec = %d\n", s.StartupTimeout)
+	}
+	if s.ToolTimeout > 0 {
+		fmt.Fprintf(&sb, "tool_timeout_sec = %d\n", s.ToolTimeout)
+	}
+	if len(forward) > 0 {
+		sort.Strings(forward)
+		var quoted []string
+		for _, k := range forward {
+			quoted = append(quoted, tomlQuote(k))
+		}
+		fmt.Fprintf(&sb, "env_vars = [%s]\n", strings.Join(quoted, ", "))
+	}
+	return sb.String()
 }
 
-// backwards-compatible helper to update a single server map
-func updateCodexMCPMapp(servers map[string]string, dryRun bool) error {
-	return updateCodexMCPConfig(servers, dryRun)
+// tomlInlineTable renders a string map as a TOML inline table with sorted keys
+func tomlInlineTable(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var items []string
+	for _, k := range keys {
+		items = append(items, fmt.Sprintf("%s = %s", tomlKey(k), tomlQuote(m[k])))
+	}
+	return "{ " + strings.Join(items, ", ") + " }"
 }
 
-// missingCodexMCPServers returns names that are not present in ~/.codex/config.toml
-func missingCodexMCPServers(servers map[string]string) []string {
-	var missing []string
-	homeDir, err := os.UserHomeDir()
+// missingCodexMCPServers returns names that are not present in ~/.codex/config.toml; a missing
+// file has no servers, a file that cannot be read or parsed is an error
+func (s *Session) missingCodexMCPServers(servers map[string]config.MCPServer) ([]string, error) {
+	path, err := s.codexConfigPath()
 	if err != nil {
-		// Cannot check; assume missing
-		for name := range servers {
-			missing = append(missing, name)
-		}
-		return missing
+		return nil, fmt.Errorf("failed to locate the Codex config: %w", err)
 	}
-	path := filepath.Join(homeDir, ".codex", "config.toml")
-	b, err := os.ReadFile(path)
-	if err != nil {
-		for name := range servers {
-			missing = append(missing, name)
+	installed := map[string]bool{}
+	if b, err := s.Env.FS.ReadFile(path); err == nil {
+		if installed, err = codexMCPServerNames(string(b)); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
 		}
-		return missing
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
 	}
-	content := string(b)
+	var missing []string
 	for name := range servers {
-		header := "[mcp_servers." + name + "]"
-		if !strings.Contains(content, header) {
+		if !installed[name] {
 			missing = append(missing, name)
 		}
 	}
-	return missing
+	sort.Strings(missing)
+	return missing, nil
 }
 
 func tomlQuote(s string) string {
 	// TOML basic string quoting
-	s = strings.ReplaceAll(s, "\\", "\\\\")
-	s = strings.ReplaceAll(s, "\"", "\\\"")
-	return "\"" + s + "\""
-}
-
-// upsertTomlSection replaces or appends a TOML section by name
-func upsertTomlSection(content, sectionName, sectionBody string) string {
-	header := "[" + sectionName + "]"
-	if content == "" {
-		return sectionBody + "\n"
-	}
-	lines := strings.Split(content, "\n")
-	var out []string
-	replaced := false
-	i := 0
-	for i < len(lines) {
-		line := lines[i]
-		if strings.TrimSpace(line) == header {
-			// Skip until next section header or EOF
-			// Replace with new section body
-			// Move forward to next header
-			// First, append sectionBody (without trailing newline split)
-			out = append(out, strings.Split(strings.TrimRight(sectionBody, "\n"), "\n")...)
-			replaced = true
-			i++
-			for i < len(lines) {
-				if strings.HasPrefix(strings.TrimSpace(lines[i]), "[") {
-					break
-				}
-				i++
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
 			}
-			continue
-		}
-		out = append(out, line)
-		i++
-	}
-	if !replaced {
-		if len(out) > 0 && strings.TrimSpace(out[len(out)-1]) != "" {
-			out = append(out, "")
 		}
-		out = append(out, strings.Split(strings.TrimRight(sectionBody, "\n"), "\n")...)
-		out = append(out, "")
 	}
-	return strings.Join(out, "\n")
+	sb.WriteByte('"')
+	return sb.String()
 }
@@ -1,24 +1,31 @@
 package commands
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/internal/fsys"
+	"github.com/shibukawa/anyagent/internal/logging"
 )
 
 func TestRunAddMCP_CreatesProjectMCPAndAgentFiles(t *testing.T) {
+	s := newTestSession()
 	dir := t.TempDir()
 	// Initialize project: AGENTS.md + config with copilot enabled
 	if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("# AGENTS"), 0644); err != nil {
 		t.Fatalf("failed to write AGENTS.md: %v", err)
 	}
-	if err := config.SaveProjectConfig(dir, &config.ProjectConfig{EnabledAgents: []string{"copilot"}}); err != nil {
+	if err := config.SaveProjectConfig(s.Env, dir, &config.ProjectConfig{EnabledAgents: []string{"copilot"}}); err != nil {
 		t.Fatalf("failed to save config: %v", err)
 	}
 
-	if err := RunAddMCP("postgres", "npx -y @modelcontextprotocol/server-postgres postgresql://localhost/mydb", dir, false, false); err != nil {
+	if err := s.RunAddMCP(AddMCPParams{Name: "postgres", Cmd: "npx -y @modelcontextprotocol/server-postgres postgresql://localhost/mydb", ProjectDir: dir}); err != nil {
 		t.Fatalf("RunAddMCP failed: %v", err)
 	}
 
@@ -31,3 +38,307 @@ func TestRunAddMCP_CreatesProjectMCPAndAgentFiles(t *testing.T) {
 		t.Fatalf(".vscode/mcp.json was not created for Copilot")
 	}
 }
+
+func TestRunAddMCP_StructuredServer(t *testing.T) {
+	s := newTestSession()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("# AGENTS"), 0644); err != nil {
+		t.Fatalf("failed to write AGENTS.md: %v", err)
+	}
+	if err := config.SaveProjectConfig(s.Env, dir, &config.ProjectConfig{EnabledAgents: []string{"copilot"}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	if err := s.RunAddMCP(AddMCPParams{
+		Name:       "postgres",
+		Cmd:        "npx -y @modelcontextprotocol/server-postgres",
+		Env:        []string{"DATABASE_URL=postgresql://localhost/mydb"},
+		ProjectDir: dir,
+	}); err != nil {
+		t.Fatalf("RunAddMCP stdio failed: %v", err)
+	}
+	if err := s.RunAddMCP(AddMCPParams{
+		Name:       "remote",
+		URL:        "https://mcp.example.com/mcp",
+		Headers:    []string{"Authorization: Bearer ${env:TOKEN}"},
+		ProjectDir: dir,
+	}); err != nil {
+		t.Fatalf("RunAddMCP remote failed: %v", err)
+	}
+
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(dir))
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	pg := cfg.MCPServers["postgres"]
+	if pg.Command != "npx" || pg.Env["DATABASE_URL"] != "postgresql://localhost/mydb" {
+		t.Errorf("unexpected postgres server: %+v", pg)
+	}
+	remote := cfg.MCPServers["remote"]
+	if remote.TransportType() != config.MCPTransportHTTP || remote.Headers["Authorization"] != "Bearer ${env:TOKEN}" {
+		t.Errorf("unexpected remote server: %+v", remote)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, ".vscode", "mcp.json"))
+	if err != nil {
+		t.Fatalf("failed to read .vscode/mcp.json: %v", err)
+	}
+	var out struct {
+		Servers map[string]map[string]any `json:"servers"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if env, _ := out.Servers["postgres"]["env"].(map[string]any); env["DATABASE_URL"] != "postgresql://localhost/mydb" {
+		t.Errorf("env not written to mcp.json: %s", b)
+	}
+	if out.Servers["remote"]["type"] != "http" || out.Servers["remote"]["url"] != "https://mcp.example.com/mcp" {
+		t.Errorf("remote server not written to mcp.json: %s", b)
+	}
+}
+
+func TestBuildMCPServerValidation(t *testing.T) {
+	s := newTestSession()
+	cases := []AddMCPParams{
+		{Name: "x"}, // neither --cmd nor --url
+		{Name: "x", Cmd: "srv", URL: "https://x"},         // both
+		{Name: "x", Cmd: "srv", Headers: []string{"A=b"}}, // header on stdio
+		{Name: "x", URL: "https://x", Transport: "ws"},    // unknown transport
+		{Name: "x", Cmd: "srv", Env: []string{"NOVALUE"}}, // malformed env
+	}
+	for _, c := range cases {
+		if _, err := s.buildMCPServer(c); err == nil {
+			t.Errorf("expected error for %+v", c)
+		}
+	}
+}
+
+func TestRunAddMCP_ShellQuotedArgsRoundTrip(t *testing.T) {
+	s := newTestSession()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("# AGENTS"), 0644); err != nil {
+		t.Fatalf("failed to write AGENTS.md: %v", err)
+	}
+	if err := config.SaveProjectConfig(s.Env, dir, &config.ProjectConfig{EnabledAgents: []string{"copilot"}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	want := []string{"server.js", "--root", "/path with spaces", `say "hi"`, "--label", "a b"}
+	if err := s.RunAddMCP(AddMCPParams{
+		Name:       "files",
+		Cmd:        `node server.js --root '/path with spaces' "say \"hi\""`,
+		Args:       []string{"--label", "a b"},
+		ProjectDir: dir,
+	}); err != nil {
+		t.Fatalf("RunAddMCP failed: %v", err)
+	}
+
+	cfg, _ := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(dir))
+	if got := cfg.MCPServers["files"].Args; !reflect.DeepEqual(got, want) {
+		t.Errorf("config args = %q, want %q", got, want)
+	}
+
+	b, _ := os.ReadFile(filepath.Join(dir, ".vscode", "mcp.json"))
+	var out struct {
+		Servers map[string]struct {
+			Args []string `json:"args"`
+		} `json:"servers"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got := out.Servers["files"].Args; !reflect.DeepEqual(got, want) {
+		t.Errorf("mcp.json args = %q, want %q", got, want)
+	}
+
+	section := codexMCPSection("files", cfg.MCPServers["files"])
+	if !strings.Contains(section, `args = ["server.js", "--root", "/path with spaces", "say \"hi\"", "--label", "a b"]`) {
+		t.Errorf("unexpected codex section:\n%s", section)
+	}
+
+	if err := s.RunAddMCP(AddMCPParams{Name: "bad", Cmd: "srv | tee log", ProjectDir: dir}); err == nil {
+		t.Error("expected pipes in --cmd to be rejected")
+	}
+}
+
+func TestRunAddMCP_Preset(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("# AGENTS"), 0644); err != nil {
+		t.Fatalf("failed to write AGENTS.md: %v", err)
+	}
+	if err := config.SaveProjectConfig(s.Env, dir, &config.ProjectConfig{EnabledAgents: []string{"claude"}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".anyagent", "mcp.yaml"), []byte(`presets:
+  db:
+    command: db-mcp
+    env:
+      DATABASE_URL:
+        description: Connection string
+        required: true
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Secrets default to ${env:NAME} without prompting
+	if err := s.RunAddMCP(AddMCPParams{Preset: "github", ProjectDir: dir}); err != nil {
+		t.Fatalf("RunAddMCP --preset github failed: %v", err)
+	}
+	// Required values without a default must be given when stdin is not a terminal
+	if err := s.RunAddMCP(AddMCPParams{Preset: "db", ProjectDir: dir}); err == nil || !strings.Contains(err.Error(), "DATABASE_URL") {
+		t.Fatalf("expected missing DATABASE_URL error, got %v", err)
+	}
+	if err := s.RunAddMCP(AddMCPParams{Name: "maindb", Preset: "db", Env: []string{"DATABASE_URL=postgresql://localhost/app"}, ProjectDir: dir}); err != nil {
+		t.Fatalf("RunAddMCP --preset db failed: %v", err)
+	}
+	if err := s.RunAddMCP(AddMCPParams{Preset: "db", Cmd: "x", ProjectDir: dir}); err == nil {
+		t.Error("--preset with --cmd should fail")
+	}
+	// Interactive sessions read the answer from the session's input
+	ps := &Session{Logger: s.Logger, Interactive: true, Env: &fsys.Env{FS: fsys.OS, Stdin: strings.NewReader("postgresql://localhost/prompted\n")}}
+	if err := ps.RunAddMCP(AddMCPParams{Name: "prompted", Preset: "db", ProjectDir: dir}); err != nil {
+		t.Fatalf("RunAddMCP --preset db with input failed: %v", err)
+	}
+
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]config.MCPServer{
+		"github": {
+			Command: "npx",
+			Args:    []string{"-y", "@modelcontextprotocol/server-github"},
+			Env:     map[string]string{"GITHUB_PERSONAL_ACCESS_TOKEN": "${env:GITHUB_PERSONAL_ACCESS_TOKEN}"},
+		},
+		"maindb":   {Command: "db-mcp", Env: map[string]string{"DATABASE_URL": "postgresql://localhost/app"}},
+		"prompted": {Command: "db-mcp", Env: map[string]string{"DATABASE_URL": "postgresql://localhost/prompted"}},
+	}
+	if !reflect.DeepEqual(cfg.MCPServers, want) {
+		t.Errorf("unexpected servers\n got: %+v\nwant: %+v", cfg.MCPServers, want)
+	}
+}
+
+func TestEnsureMCPFilesForEnabledAgents_PerAgentSelection(t *testing.T) {
+	s := newTestSession()
+	dir := t.TempDir()
+	servers := map[string]config.MCPServer{
+		"filesystem": {Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-filesystem", "."}, ExcludeAgents: []string{"claude"}},
+		"browser":    {Command: "npx", Args: []string{"@playwright/mcp@latest"}, Agents: []string{"claude"}},
+		"paused":     {Command: "paused-server", Disabled: true},
+	}
+	cfg := &config.ProjectConfig{EnabledAgents: []string{"claude", "copilot"}, MCPServers: servers}
+	if err := config.SaveProjectConfig(s.Env, dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+	// A server written by an earlier sync is taken out once it no longer applies
+	if err := s.writeJSONObject(filepath.Join(dir, ".mcp.json"), map[string]any{
+		"mcpServers": map[string]any{"filesystem": map[string]any{"command": "npx"}, "manual": map[string]any{"command": "mine"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.ensureMCPFilesForEnabledAgents(dir, false); err != nil {
+		t.Fatalf("ensureMCPFilesForEnabledAgents failed: %v", err)
+	}
+	for agent, want := range map[string][]string{
+		"claude":  {"browser", "manual"},
+		"copilot": {"filesystem"},
+	} {
+		names, err := s.installedMCPServerNames(agent, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for name := range names {
+			got = append(got, name)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s servers = %v, want %v", agent, got, want)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("# AGENTS"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RunAddMCP(AddMCPParams{Name: "bad", Cmd: "x", Agents: []string{"cursor"}, ProjectDir: dir}); err == nil || !strings.Contains(err.Error(), "unknown agent") {
+		t.Errorf("unknown agent names should be rejected, got %v", err)
+	}
+}
+
+func TestRunAddMCP_GlobalSkipsServersNotForCodex(t *testing.T) {
+	s := newTestSession()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("# AGENTS"), 0644); err != nil {
+		t.Fatalf("failed to write AGENTS.md: %v", err)
+	}
+	if err := config.SaveProjectConfig(s.Env, dir, &config.ProjectConfig{EnabledAgents: []string{"codex"}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	params := []AddMCPParams{
+		{Name: "off", Cmd: "npx -y off", Disabled: true},
+		{Name: "excluded", Cmd: "npx -y excluded", ExcludeAgents: []string{"codex"}},
+		{Name: "on", Cmd: "npx -y on"},
+	}
+	for _, p := range params {
+		p.ProjectDir, p.Global = dir, true
+		if err := s.RunAddMCP(p); err != nil {
+			t.Fatalf("RunAddMCP %s failed: %v", p.Name, err)
+		}
+	}
+	names, err := s.installedMCPServerNames("codex", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names["off"] || names["excluded"] || !names["on"] {
+		t.Errorf("Codex config servers = %v, want only on", names)
+	}
+}
+
+func TestRunAddMCP_PresetAgentsAreRecommended(t *testing.T) {
+	env := fsys.Memory("/work", "/home/dev")
+	if err := env.FS.WriteFile("/work/AGENTS.md", []byte("# AGENTS"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SaveProjectConfig(env, "/work", &config.ProjectConfig{EnabledAgents: []string{"codex"}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	base := Session{Logger: logging.Discard(), Env: env}
+
+	// sentry is recommended for other agents: it is still written for Codex, with a warning
+	report, err := RunSession(base, func(s *Session) error {
+		return s.RunAddMCP(AddMCPParams{Preset: "sentry", ProjectDir: "/work"})
+	})
+	if err != nil {
+		t.Fatalf("RunAddMCP --preset sentry failed: %v", err)
+	}
+	if !strings.Contains(strings.Join(report.Warnings, "\n"), "Preset 'sentry' is recommended for claude, copilot, gemini, not ChatGPT Codex") {
+		t.Errorf("expected a recommendation warning, got %q", report.Warnings)
+	}
+	// --agent restricts the server and is not warned about when it excludes Codex
+	report, err = RunSession(base, func(s *Session) error {
+		return s.RunAddMCP(AddMCPParams{Name: "sentry-claude", Preset: "sentry", Agents: []string{"claude"}, ProjectDir: "/work"})
+	})
+	if err != nil {
+		t.Fatalf("RunAddMCP --preset sentry --agent claude failed: %v", err)
+	}
+	if strings.Contains(strings.Join(report.Warnings, "\n"), "recommended for") {
+		t.Errorf("unexpected recommendation warning: %q", report.Warnings)
+	}
+
+	cfg, err := config.LoadProjectConfig(env, config.GetProjectConfigPath("/work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.MCPServers["sentry"]; len(got.Agents) != 0 || !got.EnabledFor("codex") {
+		t.Errorf("the recommendation must not restrict the server: %+v", got)
+	}
+	if got := cfg.MCPServers["sentry-claude"]; !reflect.DeepEqual(got.Agents, []string{"claude"}) {
+		t.Errorf("--agent should restrict the server: %+v", got)
+	}
+}
@@ -0,0 +1,320 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+// persona is a parsed persona template (templates/agents/<name>.md):
+//
+//	---
+//	name: reviewer
+//	description: Reviews recent changes
+//	tools: [Read, Grep, Glob]
+//	agents:
+//	  copilot:
+//	    tools: ['codebase', 'changes']
+//	---
+//	<system prompt>
+//
+// It becomes a Claude Code subagent, a Copilot chat mode or a Q Developer CLI agent.
+type persona struct {
+	Name        string
+	Description string
+	Tools       []string
+	Prompt      string
+	tmpl        commandTemplate
+}
+
+// parsePersona parses persona template content; name defaults to the template file name
+func parsePersona(name, content string) persona {
+	ct := parseCommandTemplate(content)
+	p := persona{
+		Name:        name,
+		Description: ct.Description,
+		Prompt:      strings.TrimPrefix(ct.Body, "\n"),
+		tmpl:        ct,
+	}
+	if v := mappingValue(ct.front, "name"); v != nil && v.Value != "" {
+		p.Name = v.Value
+	}
+	if v := mappingValue(ct.front, "tools"); v != nil {
+		switch v.Kind {
+		case yaml.SequenceNode:
+			_ = v.Decode(&p.Tools)
+		case yaml.ScalarNode:
+			for _, tool := range strings.Split(v.Value, ",") {
+				if tool = strings.TrimSpace(tool); tool != "" {
+					p.Tools = append(p.Tools, tool)
+				}
+			}
+		}
+	}
+	return p
+}
+
+// personaFilePath returns where a persona is installed for the agent; ok is false when the
+// agent has no persona equivalent.
+func personaFilePath(agentName, projectDir, name string) (string, bool) {
+	switch agentName {
+	case "claude":
+		return filepath.Join(projectDir, ".claude", "agents", fmt.Sprintf("%s.md", name)), true
+	case "copilot":
+		return filepath.Join(projectDir, ".github", "chatmodes", fmt.Sprintf("%s.chatmode.md", name)), true
+	case "qdev":
+		return filepath.Join(projectDir, ".amazonq", "cli-agents", fmt.Sprintf("%s.json", name)), true
+	}
+	return "", false
+}
+
+// buildPersonaContent renders a persona in the agent's native format
+func buildPersonaContent(agentName string, p persona) (string, error) {
+	switch agentName {
+	case "claude":
+		return buildClaudePersonaContent(p), nil
+	case "copilot":
+		return buildCopilotChatModeContent(p), nil
+	case "qdev":
+		return buildQDevAgentJSON(p)
+	}
+	return "", fmt.Errorf("personas are not supported for agent: %s", agentName)
+}
+
+// buildClaudePersonaContent renders a Claude Code subagent (.claude/agents/<name>.md)
+func buildClaudePersonaContent(p persona) string {
+	front := &yaml.Node{Kind: yaml.MappingNode}
+	setMappingValue(front, "name", stringNode(p.Name))
+	setMappingValue(front, "description", stringNode(p.Description))
+	if len(p.Tools) > 0 {
+		// Claude Code expects a comma-separated tool list
+		setMappingValue(front, "tools", stringNode(strings.Join(p.Tools, ", ")))
+	}
+	mergeMapping(front, p.tmpl.agentBlock("claude"))
+	return renderFrontmatter(front) + "\n" + p.Prompt
+}
+
+// copilotToolNames maps Claude Code tool names to VS Code chat tools
+var copilotToolNames = map[string]string{
+	"Read":      "codebase",
+	"Grep":      "search",
+	"Glob":      "search",
+	"Edit":      "editFiles",
+	"MultiEdit": "editFiles",
+	"Write":     "editFiles",
+	"Bash":      "runCommands",
+	"WebFetch":  "fetch",
+}
+
+// buildCopilotChatModeContent renders a VS Code chat mode (.github/chatmodes/<name>.chatmode.md).
+// Claude tool names are mapped to VS Code chat tools; tools without an equivalent are left out,
+// and the list is omitted when none map.
+func buildCopilotChatModeContent(p persona) string {
+	front := &yaml.Node{Kind: yaml.MappingNode}
+	setMappingValue(front, "description", stringNode(p.Description))
+	tools := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+	seen := map[string]bool{}
+	for _, tool := range p.Tools {
+		mapped, ok := copilotToolNames[tool]
+		if !ok || seen[mapped] {
+			continue
+		}
+		seen[mapped] = true
+		tools.Content = append(tools.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: mapped, Style: yaml.SingleQuotedStyle})
+	}
+	if len(tools.Content) > 0 {
+		setMappingValue(front, "tools", tools)
+	}
+	mergeMapping(front, p.tmpl.agentBlock("copilot"))
+	return renderFrontmatter(front) + "\n" + p.Prompt
+}
+
+// buildQDevAgentJSON renders a Q Developer CLI agent (.amazonq/cli-agents/<name>.json).
+// Tool names differ from Claude's, so tools are only set from an 'agents.qdev' block.
+func buildQDevAgentJSON(p persona) (string, error) {
+	out := map[string]any{
+		"name":        p.Name,
+		"description": p.Description,
+		"prompt":      p.Prompt,
+	}
+	if block := p.tmpl.agentBlock("qdev"); block != nil {
+		var extra map[string]any
+		if err := block.Decode(&extra); err != nil {
+			return "", fmt.Errorf("invalid agents.qdev block: %w", err)
+		}
+		for k, v := range extra {
+			out[k] = v
+		}
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// RunAddPersona installs a persona template for the enabled agent(s) and records it in the project config
+func (s *Session) RunAddPersona(name, projectDir string, dryRun bool) error {
+	s.Logger.Infof("Adding %s persona to project...\n", name)
+
+	// Get project directory (current directory if not specified)
+	if projectDir == "" {
+		var err error
+		projectDir, err = s.Env.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+
+	// Make sure the project directory exists
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
+	}
+
+	s.Logger.Infof("Project directory: %s\n", projectDir)
+
+	// Check if project is initialized (has AGENTS.md)
+	if _, err := s.Env.FS.Stat(filepath.Join(projectDir, "AGENTS.md")); os.IsNotExist(err) {
+		return ErrNotInitialized
+	}
+
+	if err := s.validatePersona(projectDir, name); err != nil {
+		return err
+	}
+
+	for _, agentName := range s.targetAgents(projectDir) {
+		if err := s.installPersonaForAgent(agentName, projectDir, name, dryRun); err != nil {
+			return err
+		}
+	}
+
+	if err := s.addInstalledPersonaToConfig(projectDir, name, dryRun); err != nil {
+		s.warnf("Warning: Failed to update project config with persona '%s': %v", name, err)
+	}
+
+	s.Logger.Infof("✅ %s persona added successfully\n", name)
+	return nil
+}
+
+// validatePersona checks the persona name against the available persona templates
+func (s *Session) validatePersona(projectDir, name string) error {
+	if name == "" {
+		return fmt.Errorf("persona name cannot be empty")
+	}
+	if strings.ContainsAny(name, "/\\<>:\"|?*") {
+		return fmt.Errorf("persona name contains invalid characters: %s", name)
+	}
+	available, err := config.ListPersonaTemplates(s.Env, projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to get available personas: %w", err)
+	}
+	var names []string
+	for _, p := range available {
+		if p.Name == name {
+			return nil
+		}
+		names = append(names, p.Name)
+	}
+	return errorOf(ErrUnknownTemplate, "persona '%s' is not available. Available personas: %s", name, strings.Join(names, ", "))
+}
+
+// targetAgents returns the enabled agents, defaulting to Copilot when none are recorded
+func (s *Session) targetAgents(projectDir string) []string {
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
+	if err != nil || len(cfg.EnabledAgents) == 0 {
+		return []string{"copilot"}
+	}
+	return cfg.EnabledAgents
+}
+
+// installPersonaForAgent writes a single persona for the agent, or warns when the agent has no equivalent
+func (s *Session) installPersonaForAgent(agentName, projectDir, name string, dryRun bool) error {
+	path, ok := personaFilePath(agentName, projectDir, name)
+	if !ok {
+		s.warnf("Personas are not supported by %s; '%s' is recorded and will be installed when switching to claude, copilot or qdev", agentName, name)
+		return nil
+	}
+	content, err := config.GetPersonaTemplateResolved(s.Env, projectDir, name)
+	if err != nil {
+		return fmt.Errorf("failed to get persona template: %w", err)
+	}
+	rendered, err := buildPersonaContent(agentName, parsePersona(name, content))
+	if err != nil {
+		return err
+	}
+	return s.createPersonaFile(path, rendered, dryRun)
+}
+
+// createPersonaFile writes a persona file, creating its directory
+func (s *Session) createPersonaFile(filePath, content string, dryRun bool) error {
+	if dryRun {
+		s.dryRunf("create", filePath, "Would create persona file: %s", filePath)
+		return nil
+	}
+	if err := s.Env.FS.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create persona directory: %w", err)
+	}
+	s.Logger.Infof("📄 Creating persona file: %s\n", filePath)
+	return s.Env.FS.WriteFile(filePath, []byte(content), 0644)
+}
+
+// reinstallPersonasForAgent installs the recorded personas for the selected agent
+func (s *Session) reinstallPersonasForAgent(agentName, projectDir string, personas []string, dryRun bool) error {
+	for _, name := range personas {
+		if err := s.installPersonaForAgent(agentName, projectDir, name, dryRun); err != nil {
+			s.warnf("Warning: Could not install persona '%s' for %s: %v", name, agentName, err)
+		}
+	}
+	return nil
+}
+
+// addInstalledPersonaToConfig records the installed persona into the project config
+func (s *Session) addInstalledPersonaToConfig(projectDir, name string, dryRun bool) error {
+	configPath := config.GetProjectConfigPath(projectDir)
+	projectConfig, err := config.LoadProjectConfig(s.Env, configPath)
+	if err != nil {
+		return err
+	}
+	for _, p := range projectConfig.InstalledPersonas {
+		if p == name {
+			return nil
+		}
+	}
+	projectConfig.InstalledPersonas = append(projectConfig.InstalledPersonas, name)
+	if dryRun {
+		s.dryRunf("record", "", "Would record installed persona '%s' into .anyagent/config.yaml", name)
+		return nil
+	}
+	if err := projectConfig.Save(s.Env, configPath); err != nil {
+		return fmt.Errorf("failed to save project config: %w", err)
+	}
+	s.Logger.Infof("💾 Project config updated: recorded persona '%s'\n", name)
+	return nil
+}
+
+// ListAvailablePersonas displays all available persona templates
+func (s *Session) ListAvailablePersonas(projectDir string) error {
+	personas, err := config.ListPersonaTemplates(s.Env, projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to get available personas: %w", err)
+	}
+	s.setAvailableTemplates(personas)
+
+	if len(personas) == 0 {
+		s.Logger.Println("No personas available.")
+		return nil
+	}
+
+	s.Logger.Println("Available personas:")
+	for _, p := range personas {
+		s.Logger.Printf("  • %s (%s)\n", p.Name, p.Source)
+	}
+	s.Logger.Printf("\nUsage: anyagent add persona <persona-name>\n")
+	return nil
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+func TestRunAddPersonaAndSwitch(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "AGENTS.md"), []byte("# Test"), 0644); err != nil {
+		t.Fatalf("failed to create AGENTS.md: %v", err)
+	}
+	if err := config.SaveProjectConfig(s.Env, tempDir, &config.ProjectConfig{EnabledAgents: []string{"claude"}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	if err := s.RunAddPersona("reviewer", tempDir, false); err != nil {
+		t.Fatalf("RunAddPersona failed: %v", err)
+	}
+	claudePath := filepath.Join(tempDir, ".claude", "agents", "reviewer.md")
+	b, err := os.ReadFile(claudePath)
+	if err != nil {
+		t.Fatalf("Claude subagent not created: %v", err)
+	}
+	content := string(b)
+	for _, want := range []string{"name: reviewer", "description:", "tools: Read, Grep, Glob, Bash", "You are a senior engineer"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Claude subagent missing %q:\n%s", want, content)
+		}
+	}
+
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(tempDir))
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if len(cfg.InstalledPersonas) != 1 || cfg.InstalledPersonas[0] != "reviewer" {
+		t.Fatalf("persona not recorded: %v", cfg.InstalledPersonas)
+	}
+
+	// Switching to Copilot replaces the subagent with a chat mode
+	if err := s.RunSwitch(tempDir, "copilot", false); err != nil {
+		t.Fatalf("RunSwitch failed: %v", err)
+	}
+	if _, err := os.Stat(claudePath); !os.IsNotExist(err) {
+		t.Errorf("Claude subagent should be removed after switch")
+	}
+	b, err = os.ReadFile(filepath.Join(tempDir, ".github", "chatmodes", "reviewer.chatmode.md"))
+	if err != nil {
+		t.Fatalf("Copilot chat mode not created: %v", err)
+	}
+	if content := string(b); !strings.Contains(content, "tools: ['changes', 'codebase', 'problems', 'search']") {
+		t.Errorf("chat mode should use agents.copilot tools:\n%s", content)
+	}
+
+	// Codex has no persona equivalent: switching warns but succeeds
+	if err := s.RunSwitch(tempDir, "codex", false); err != nil {
+		t.Fatalf("RunSwitch to codex failed: %v", err)
+	}
+
+	if err := s.RunRemovePersona("reviewer", tempDir, false); err != nil {
+		t.Fatalf("RunRemovePersona failed: %v", err)
+	}
+	cfg, _ = config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(tempDir))
+	if len(cfg.InstalledPersonas) != 0 {
+		t.Fatalf("persona should be removed from config: %v", cfg.InstalledPersonas)
+	}
+}
+
+func TestRunAddPersonaUnknown(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "AGENTS.md"), []byte("# Test"), 0644); err != nil {
+		t.Fatalf("failed to create AGENTS.md: %v", err)
+	}
+	if err := s.RunAddPersona("nonexistent", tempDir, true); err == nil {
+		t.Fatal("expected error for unknown persona")
+	}
+}
+
+func TestBuildCopilotChatModeMapsClaudeTools(t *testing.T) {
+	got := buildCopilotChatModeContent(persona{Description: "Reviews", Tools: []string{"Read", "Grep", "Glob", "Bash", "Task"}, Prompt: "Review.\n"})
+	if !strings.Contains(got, "tools: ['codebase', 'search', 'runCommands']") {
+		t.Errorf("tools should use VS Code chat tool names:\n%s", got)
+	}
+	got = buildCopilotChatModeContent(persona{Description: "Plans", Tools: []string{"Task", "TodoWrite"}, Prompt: "Plan.\n"})
+	if strings.Contains(got, "tools:") {
+		t.Errorf("tools without a Copilot equivalent should be omitted:\n%s", got)
+	}
+}
@@ -19,80 +19,99 @@ var SupportedRules = []string{
 }
 
 // RunAddRule executes the add rule command functionality
-func RunAddRule(language, projectDir string, dryRun bool) error {
-	fmt.Printf("Adding %s rules to project...\n", language)
+func (s *Session) RunAddRule(language, projectDir string, dryRun bool) error {
+	s.Logger.Infof("Adding %s rules to project...\n", language)
 
 	// Get project directory (current directory if not specified)
 	if projectDir == "" {
 		var err error
-		projectDir, err = os.Getwd()
+		projectDir, err = s.Env.Getwd()
 		if err != nil {
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 	}
 
 	// Make sure the project directory exists
-	if _, err := os.Stat(projectDir); os.IsNotExist(err) {
-		return fmt.Errorf("project directory does not exist: %s", projectDir)
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
 	}
 
-	fmt.Printf("Project directory: %s\n", projectDir)
+	s.Logger.Infof("Project directory: %s\n", projectDir)
 
 	// Check if project is initialized (has AGENTS.md)
 	agentsPath := filepath.Join(projectDir, "AGENTS.md")
-	if _, err := os.Stat(agentsPath); os.IsNotExist(err) {
-		return fmt.Errorf("project is not initialized with anyagent. Run 'anyagent sync' first")
+	if _, err := s.Env.FS.Stat(agentsPath); os.IsNotExist(err) {
+		return ErrNotInitialized
 	}
 
 	// Validate and normalize language name
 	normalizedLanguage, err := validateAndNormalizeLanguage(language)
 	if err != nil {
-		return fmt.Errorf("unsupported language: %s. Supported: %s", language, strings.Join(SupportedRules, ", "))
+		return errorOf(ErrUnknownTemplate, "unsupported language: %s. Supported: %s", language, strings.Join(SupportedRules, ", "))
 	}
 
 	// Get the rule template content with precedence (project → user → embedded)
-	ruleContent, err := getRuleTemplate(projectDir, normalizedLanguage)
+	ruleContent, err := s.getRuleTemplate(projectDir, normalizedLanguage)
 	if err != nil {
 		return fmt.Errorf("failed to get rule template: %w", err)
 	}
 
 	// Create external rule files for agent-specific locations
-	if shouldCreateCopilotRuleFiles(projectDir) {
+	if err := s.writeRuleFiles(projectDir, normalizedLanguage, ruleContent, dryRun); err != nil {
+		return err
+	}
+
+	// Update project configuration and regenerate AGENTS.md
+	if !dryRun {
+		if err := s.updateProjectConfigAndRegenerate(projectDir, normalizedLanguage); err != nil {
+			s.warnf("Warning: Failed to update configuration: %v", err)
+		}
+	}
+
+	s.Logger.Infof("✅ %s rules added successfully\n", normalizedLanguage)
+	return nil
+}
+
+// writeRuleFiles writes the rule file of the selected agent: a Copilot instructions file or an
+// Amazon Q Developer rule. Other agents only read the rule from AGENTS.md.
+func (s *Session) writeRuleFiles(projectDir, rule, ruleContent string, dryRun bool) error {
+	if s.shouldCreateCopilotRuleFiles(projectDir) {
 		// Create GitHub Copilot instructions directory
 		copilotInstructionsDir := filepath.Join(projectDir, ".github", "instructions")
-		if err := createInstructionsDirectory(copilotInstructionsDir, dryRun); err != nil {
+		if err := s.createInstructionsDirectory(copilotInstructionsDir, dryRun); err != nil {
 			return fmt.Errorf("failed to create instructions directory: %w", err)
 		}
 
 		// Create the rule file
-		ruleFilePath := filepath.Join(copilotInstructionsDir, fmt.Sprintf("%s.instructions.md", normalizedLanguage))
-		if err := createRuleFile(ruleFilePath, ruleContent, dryRun); err != nil {
+		ruleFilePath := filepath.Join(copilotInstructionsDir, fmt.Sprintf("%s.instructions.md", rule))
+		if err := s.createRuleFile(ruleFilePath, ruleContent, dryRun); err != nil {
 			return fmt.Errorf("failed to create rule file: %w", err)
 		}
-	} else if shouldCreateQDevRuleFiles(projectDir) {
+	} else if s.shouldCreateQDevRuleFiles(projectDir) {
 		// Amazon Q Developer rules directory
 		qdevRulesDir := filepath.Join(projectDir, ".amazonq", "rules")
-		if err := createInstructionsDirectory(qdevRulesDir, dryRun); err != nil {
+		if err := s.createInstructionsDirectory(qdevRulesDir, dryRun); err != nil {
 			return fmt.Errorf("failed to create Q Developer rules directory: %w", err)
 		}
-		qdevRulePath := filepath.Join(qdevRulesDir, fmt.Sprintf("%s.md", normalizedLanguage))
-		if err := createRuleFile(qdevRulePath, ruleContent, dryRun); err != nil {
+		qdevRulePath := filepath.Join(qdevRulesDir, fmt.Sprintf("%s.md", rule))
+		if err := s.createRuleFile(qdevRulePath, ruleContent, dryRun); err != nil {
 			return fmt.Errorf("failed to create Q Developer rule file: %w", err)
 		}
-		fmt.Printf("📄 Amazon Q Developer rule created: .amazonq/rules/%s.md\n", normalizedLanguage)
+		s.Logger.Infof("📄 Amazon Q Developer rule created: .amazonq/rules/%s.md\n", rule)
 	} else {
-		fmt.Printf("ℹ️  Codex selected: skipping external rule files; regenerating AGENTS.md only.\n")
+		s.Logger.Infof("ℹ️  Codex selected: skipping external rule files; regenerating AGENTS.md only.\n")
 	}
+	return nil
+}
 
-	// Update project configuration and regenerate AGENTS.md
-	if !dryRun {
-		if err := updateProjectConfigAndRegenerate(projectDir, normalizedLanguage); err != nil {
-			fmt.Printf("⚠️  Warning: Failed to update configuration: %v\n", err)
+// ruleOfTemplate returns the rule whose template is the extra_rules file name
+func ruleOfTemplate(name string) (string, bool) {
+	for rule, filename := range ruleTemplateFiles {
+		if filename == name {
+			return rule, true
 		}
 	}
-
-	fmt.Printf("✅ %s rules added successfully\n", normalizedLanguage)
-	return nil
+	return "", false
 }
 
 // validateAndNormalizeLanguage validates the language and returns the normalized name
@@ -123,26 +142,23 @@ func validateAndNormalizeLanguage(language string) (string, error) {
 	return "", fmt.Errorf("unsupported language: %s", language)
 }
 
+// ruleTemplateFiles maps each rule to its template under extra_rules/
+var ruleTemplateFiles = map[string]string{
+	"go":         "go.md",
+	"typescript": "ts.md",
+	"docker":     "docker.md",
+	"python":     "python.md",
+	"react":      "react.md",
+}
+
 // getRuleTemplate retrieves the template content for the specified language
-func getRuleTemplate(projectDir, language string) (string, error) {
-	// Map to filename
-	filename := ""
-	switch language {
-	case "go":
-		filename = "go.md"
-	case "typescript":
-		filename = "ts.md"
-	case "docker":
-		filename = "docker.md"
-	case "python":
-		filename = "python.md"
-	case "react":
-		filename = "react.md"
-	default:
+func (s *Session) getRuleTemplate(projectDir, language string) (string, error) {
+	filename, ok := ruleTemplateFiles[language]
+	if !ok {
 		return "", fmt.Errorf("template not found for language: %s", language)
 	}
 	// Resolve using precedence; fallback to embedded per language
-	return config.ResolveTemplateContent(projectDir, filepath.Join("extra_rules", filename), func() (string, error) {
+	return config.ResolveTemplateContent(s.Env, projectDir, filepath.Join("extra_rules", filename), func() (string, error) {
 		switch language {
 		case "go":
 			return config.GetGoExtraRuleTemplate(), nil
@@ -160,42 +176,42 @@ func getRuleTemplate(projectDir, language string) (string, error) {
 }
 
 // createInstructionsDirectory creates the .github/instructions directory
-func createInstructionsDirectory(dir string, dryRun bool) error {
+func (s *Session) createInstructionsDirectory(dir string, dryRun bool) error {
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would create directory: %s\n", dir)
+		s.dryRunf("mkdir", dir, "Would create directory: %s", dir)
 		return nil
 	}
 
-	fmt.Printf("📁 Creating instructions directory: %s\n", dir)
-	return os.MkdirAll(dir, 0755)
+	s.Logger.Infof("📁 Creating instructions directory: %s\n", dir)
+	return s.Env.FS.MkdirAll(dir, 0755)
 }
 
 // createRuleFile creates the rule instruction file
-func createRuleFile(filePath, content string, dryRun bool) error {
+func (s *Session) createRuleFile(filePath, content string, dryRun bool) error {
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would create rule file: %s\n", filePath)
-		fmt.Printf("[DRY RUN] Content preview:\n")
+		s.dryRunf("create", filePath, "Would create rule file: %s", filePath)
+		s.Logger.Infof("[DRY RUN] Content preview:\n")
 		lines := strings.Split(content, "\n")
 		for i, line := range lines {
 			if i >= 10 {
-				fmt.Printf("  ... (truncated)\n")
+				s.Logger.Infof("  ... (truncated)\n")
 				break
 			}
-			fmt.Printf("  %s\n", line)
+			s.Logger.Infof("  %s\n", line)
 		}
 		return nil
 	}
 
-	fmt.Printf("📄 Creating rule file: %s\n", filePath)
-	return os.WriteFile(filePath, []byte(content), 0644)
+	s.Logger.Infof("📄 Creating rule file: %s\n", filePath)
+	return s.Env.FS.WriteFile(filePath, []byte(content), 0644)
 }
 
 // updateProjectConfigAndRegenerate updates the project config and regenerates AGENTS.md
-func updateProjectConfigAndRegenerate(projectDir, rule string) error {
+func (s *Session) updateProjectConfigAndRegenerate(projectDir, rule string) error {
 	configPath := config.GetProjectConfigPath(projectDir)
 
 	// Load existing config or create new one
-	projectConfig, err := config.LoadProjectConfig(configPath)
+	projectConfig, err := config.LoadProjectConfig(s.Env, configPath)
 	if err != nil {
 		// If config doesn't exist, create a new one
 		projectConfig = &config.ProjectConfig{
@@ -207,7 +223,7 @@ func updateProjectConfigAndRegenerate(projectDir, rule string) error {
 	for _, installedRule := range projectConfig.InstalledRules {
 		if installedRule == rule {
 			// Already installed, just regenerate
-			return projectConfig.RegenerateAgentsFile()
+			return projectConfig.RegenerateAgentsFileAt(s.Env, projectDir)
 		}
 	}
 
@@ -215,13 +231,13 @@ func updateProjectConfigAndRegenerate(projectDir, rule string) error {
 	projectConfig.InstalledRules = append(projectConfig.InstalledRules, rule)
 
 	// Save the updated config
-	if err := projectConfig.Save(configPath); err != nil {
+	if err := projectConfig.Save(s.Env, configPath); err != nil {
 		return fmt.Errorf("failed to save project config: %w", err)
 	}
 
 	// Ensure template parameters, then regenerate AGENTS.md at the specified project directory
-	if err := ensureTemplateParameters(projectDir, projectConfig, false); err != nil {
+	if err := s.ensureTemplateParameters(projectDir, projectConfig, false); err != nil {
 		return fmt.Errorf("failed to resolve template parameters: %w", err)
 	}
-	return projectConfig.RegenerateAgentsFileAt(projectDir)
+	return projectConfig.RegenerateAgentsFileAt(s.Env, projectDir)
 }
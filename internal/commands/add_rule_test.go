@@ -10,6 +10,7 @@ import (
 )
 
 func TestRunAddRule(t *testing.T) {
+	s := newTestSession()
 	tests := []struct {
 		name         string
 		language     string
@@ -74,7 +75,7 @@ func TestRunAddRule(t *testing.T) {
 			}
 
 			// Test dry run first
-			err = RunAddRule(tt.language, tempDir, true)
+			err = s.RunAddRule(tt.language, tempDir, true)
 			if tt.expectError {
 				if err == nil {
 					t.Errorf("Expected error for dry run, got nil")
@@ -87,7 +88,7 @@ func TestRunAddRule(t *testing.T) {
 			}
 
 			// Test actual execution
-			err = RunAddRule(tt.language, tempDir, false)
+			err = s.RunAddRule(tt.language, tempDir, false)
 			if err != nil {
 				t.Errorf("Actual run failed: %v", err)
 				return
@@ -114,6 +115,7 @@ func TestRunAddRule(t *testing.T) {
 }
 
 func TestRunAddRuleCodexUpdatesAgentsOnly(t *testing.T) {
+	s := newTestSession()
 	// Create temporary directory
 	tempDir := t.TempDir()
 
@@ -125,12 +127,12 @@ func TestRunAddRuleCodexUpdatesAgentsOnly(t *testing.T) {
 
 	// Save project config with Codex enabled
 	cfg := &config.ProjectConfig{EnabledAgents: []string{"codex"}}
-	if err := config.SaveProjectConfig(tempDir, cfg); err != nil {
+	if err := config.SaveProjectConfig(s.Env, tempDir, cfg); err != nil {
 		t.Fatalf("Failed to save project config: %v", err)
 	}
 
 	// Run add rule for Go
-	if err := RunAddRule("go", tempDir, false); err != nil {
+	if err := s.RunAddRule("go", tempDir, false); err != nil {
 		t.Fatalf("RunAddRule failed: %v", err)
 	}
 
@@ -151,6 +153,7 @@ func TestRunAddRuleCodexUpdatesAgentsOnly(t *testing.T) {
 }
 
 func TestRunAddRuleQDevCreatesRuleFile(t *testing.T) {
+	s := newTestSession()
 	tempDir := t.TempDir()
 	// AGENTS.md present
 	if err := os.WriteFile(filepath.Join(tempDir, "AGENTS.md"), []byte("# AI Agents Configuration\n"), 0644); err != nil {
@@ -158,11 +161,11 @@ func TestRunAddRuleQDevCreatesRuleFile(t *testing.T) {
 	}
 	// Set enabled agent to qdev
 	cfg := &config.ProjectConfig{EnabledAgents: []string{"qdev"}}
-	if err := config.SaveProjectConfig(tempDir, cfg); err != nil {
+	if err := config.SaveProjectConfig(s.Env, tempDir, cfg); err != nil {
 		t.Fatalf("failed to save project config: %v", err)
 	}
 
-	if err := RunAddRule("go", tempDir, false); err != nil {
+	if err := s.RunAddRule("go", tempDir, false); err != nil {
 		t.Fatalf("RunAddRule failed: %v", err)
 	}
 
@@ -219,13 +222,14 @@ func TestValidateAndNormalizeLanguage(t *testing.T) {
 }
 
 func TestCreateInstructionsDirectory(t *testing.T) {
+	s := newTestSession()
 	tempDir := t.TempDir()
 	var err error
 
 	instructionsDir := filepath.Join(tempDir, ".github", "instructions")
 
 	// Test dry run
-	err = createInstructionsDirectory(instructionsDir, true)
+	err = s.createInstructionsDirectory(instructionsDir, true)
 	if err != nil {
 		t.Errorf("Dry run failed: %v", err)
 	}
@@ -236,7 +240,7 @@ func TestCreateInstructionsDirectory(t *testing.T) {
 	}
 
 	// Test actual creation
-	err = createInstructionsDirectory(instructionsDir, false)
+	err = s.createInstructionsDirectory(instructionsDir, false)
 	if err != nil {
 		t.Errorf("Directory creation failed: %v", err)
 	}
@@ -248,6 +252,7 @@ func TestCreateInstructionsDirectory(t *testing.T) {
 }
 
 func TestCreateRuleFile(t *testing.T) {
+	s := newTestSession()
 	tempDir := t.TempDir()
 	var err error
 
@@ -262,7 +267,7 @@ func TestCreateRuleFile(t *testing.T) {
 	testContent := "# Go Rules\nTest content for Go rules"
 
 	// Test dry run
-	err = createRuleFile(ruleFilePath, testContent, true)
+	err = s.createRuleFile(ruleFilePath, testContent, true)
 	if err != nil {
 		t.Errorf("Dry run failed: %v", err)
 	}
@@ -273,7 +278,7 @@ func TestCreateRuleFile(t *testing.T) {
 	}
 
 	// Test actual creation
-	err = createRuleFile(ruleFilePath, testContent, false)
+	err = s.createRuleFile(ruleFilePath, testContent, false)
 	if err != nil {
 		t.Errorf("File creation failed: %v", err)
 	}
@@ -0,0 +1,371 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/internal/fsys"
+)
+
+// adoptInstructionSources are the hand-written instruction files 'adopt' reads, in order of
+// preference for the AGENTS.md template. The rule name is used when a file becomes an extra rule.
+var adoptInstructionSources = []struct {
+	Path  string
+	Rule  string
+	Agent string
+}{
+	{Path: "AGENTS.md", Rule: "agents"},
+	{Path: "CLAUDE.md", Rule: "claude", Agent: "claude"},
+	{Path: filepath.Join(".github", "copilot-instructions.md"), Rule: "copilot-instructions", Agent: "copilot"},
+	{Path: ".cursorrules", Rule: "cursorrules"},
+}
+
+// adoptPlan is what 'adopt' writes into .anyagent
+type adoptPlan struct {
+	Agent          string
+	Template       string // .anyagent/AGENTS.md.tmpl
+	TemplateSource string
+	Rules          []adoptedFile // .anyagent/extra_rules/<name>.md
+	Commands       []adoptedFile // .anyagent/commands/<name>.md
+	Notes          []string
+}
+
+// adoptedFile is a template created from an existing agent file
+type adoptedFile struct {
+	Name    string
+	Source  string
+	Content string
+}
+
+// RunAdopt builds .anyagent from the agent files a repository already has, so that
+// 'anyagent sync' reproduces them
+func (s *Session) RunAdopt(projectDir, agent string, dryRun, force bool) error {
+	s.Logger.Infof("Adopting existing agent configuration...\n")
+
+	if projectDir == "" {
+		var err error
+		projectDir, err = s.Env.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
+	}
+	s.Logger.Infof("Project directory: %s\n", projectDir)
+
+	configPath := config.GetProjectConfigPath(projectDir)
+	if _, err := s.Env.FS.Stat(configPath); err == nil && !force {
+		return fmt.Errorf("project is already managed by anyagent (%s exists); use --force to adopt again", configPath)
+	}
+
+	plan, err := s.planAdoption(projectDir, agent)
+	if err != nil {
+		return err
+	}
+	s.printAdoptPlan(plan)
+	if err := s.writeAdoption(projectDir, plan, dryRun); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+	s.verifyAdoption(projectDir, plan)
+
+	s.Logger.Infof("✅ Adopted into .anyagent. Run 'anyagent sync' to generate AGENTS.md and the %s files\n", agentDisplayName(plan.Agent))
+	return nil
+}
+
+// planAdoption reads the existing instruction and command files
+func (s *Session) planAdoption(projectDir, agent string) (*adoptPlan, error) {
+	plan := &adoptPlan{}
+	var agents []string
+
+	// The first instruction file is the AGENTS.md template; other, different ones become rules
+	// appended at {{EXTRA_RULES}}
+	var primary string
+	seen := map[string]string{}
+	for _, src := range adoptInstructionSources {
+		content, ok := s.readAdoptableFile(filepath.Join(projectDir, src.Path))
+		if !ok {
+			continue
+		}
+		if src.Agent != "" {
+			agents = append(agents, src.Agent)
+		}
+		if src.Path == ".cursorrules" {
+			plan.Notes = append(plan.Notes, "Cursor is not a supported agent; .cursorrules is kept as is and its rules are merged into AGENTS.md")
+		}
+		key := strings.TrimSpace(content)
+		if prev, ok := seen[key]; ok {
+			plan.Notes = append(plan.Notes, fmt.Sprintf("%s is identical to %s", src.Path, prev))
+			continue
+		}
+		seen[key] = src.Path
+		if primary == "" {
+			primary = content
+			plan.TemplateSource = src.Path
+			continue
+		}
+		plan.Rules = append(plan.Rules, adoptedFile{Name: src.Rule, Source: src.Path, Content: content})
+	}
+	plan.Template = primary
+	if len(plan.Rules) > 0 {
+		plan.Template = strings.TrimRight(primary, "\n") + "\n\n{{EXTRA_RULES}}\n"
+		plan.Notes = append(plan.Notes, fmt.Sprintf("%d instruction file(s) differ from %s; AGENTS.md will contain all of them", len(plan.Rules), plan.TemplateSource))
+	}
+
+	// Copilot prompts are copied verbatim; Claude commands keep their frontmatter in agents.claude.frontmatter
+	names := map[string]string{}
+	prompts, _ := fsys.Glob(s.Env.FS, filepath.Join(projectDir, ".github", "prompts", "*.prompt.md"))
+	sort.Strings(prompts)
+	for _, path := range prompts {
+		content, ok := s.readAdoptableFile(path)
+		if !ok {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".prompt.md")
+		rel, _ := filepath.Rel(projectDir, path)
+		plan.Commands = append(plan.Commands, adoptedFile{Name: name, Source: rel, Content: content})
+		names[name] = rel
+	}
+	if len(prompts) > 0 {
+		agents = append(agents, "copilot")
+	}
+	claudeCommands, _ := fsys.Glob(s.Env.FS, filepath.Join(projectDir, ".claude", "commands", "*.md"))
+	sort.Strings(claudeCommands)
+	for _, path := range claudeCommands {
+		content, ok := s.readAdoptableFile(path)
+		if !ok {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".md")
+		rel, _ := filepath.Rel(projectDir, path)
+		if prev, ok := names[name]; ok {
+			plan.Notes = append(plan.Notes, fmt.Sprintf("%s is skipped: the command is adopted from %s", rel, prev))
+			continue
+		}
+		plan.Commands = append(plan.Commands, adoptedFile{Name: name, Source: rel, Content: claudeCommandTemplate(content)})
+		names[name] = rel
+	}
+	if len(claudeCommands) > 0 {
+		agents = append(agents, "claude")
+	}
+
+	if plan.Template == "" && len(plan.Commands) == 0 {
+		return nil, fmt.Errorf("no agent files found to adopt; run 'anyagent sync' to start from the templates")
+	}
+
+	// Only one agent can be enabled; prefer the one the repository has the most files for
+	if agent == "" {
+		agent = mostCommonAgent(agents)
+	}
+	if _, err := validateAgentNames([]string{agent}); err != nil {
+		return nil, err
+	}
+	plan.Agent = agent
+	return plan, nil
+}
+
+// readAdoptableFile reads a hand-written file; symlinks (e.g. CLAUDE.md -> AGENTS.md) are skipped
+func (s *Session) readAdoptableFile(path string) (string, bool) {
+	info, err := s.Env.FS.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+	b, err := s.Env.FS.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// mostCommonAgent returns the agent found most often, preferring the earliest on ties; copilot by default
+func mostCommonAgent(agents []string) string {
+	best, count := "copilot", 0
+	counts := map[string]int{}
+	for _, a := range agents {
+		counts[a]++
+		if counts[a] > count {
+			best, count = a, counts[a]
+		}
+	}
+	return best
+}
+
+// claudeCommandTemplate turns a Claude Code command into a command template that renders back
+// to the same bytes: agents.claude.frontmatter keeps the original frontmatter verbatim, fences
+// included ("" when the command has none), and the description is copied to the top level for
+// listings and the other agents
+func claudeCommandTemplate(content string) string {
+	ct := parseCommandTemplate(content)
+	front := &yaml.Node{Kind: yaml.MappingNode}
+	if v := mappingValue(ct.front, "description"); v != nil {
+		setMappingValue(front, "description", v)
+	}
+	raw := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: strings.TrimSuffix(content, ct.Body), Style: yaml.LiteralStyle}
+	if raw.Value == "" {
+		raw.Style = yaml.DoubleQuotedStyle
+	}
+	claude := &yaml.Node{Kind: yaml.MappingNode}
+	setMappingValue(claude, claudeVerbatimFrontmatterKey, raw)
+	agents := &yaml.Node{Kind: yaml.MappingNode}
+	setMappingValue(agents, "claude", claude)
+	setMappingValue(front, "agents", agents)
+	return renderFrontmatter(front) + ct.Body
+}
+
+// printAdoptPlan shows what will be written
+func (s *Session) printAdoptPlan(plan *adoptPlan) {
+	s.Logger.Printf("📋 Proposed .anyagent configuration (agent: %s)\n", plan.Agent)
+	if plan.Template != "" {
+		s.Logger.Printf("  • AGENTS.md.tmpl ← %s\n", plan.TemplateSource)
+	}
+	for _, r := range plan.Rules {
+		s.Logger.Printf("  • extra_rules/%s.md ← %s\n", r.Name, r.Source)
+	}
+	for _, c := range plan.Commands {
+		s.Logger.Printf("  • commands/%s.md ← %s\n", c.Name, c.Source)
+	}
+	for _, note := range plan.Notes {
+		s.Logger.Printf("ℹ️  %s\n", note)
+	}
+}
+
+// writeAdoption writes the templates and config.yaml
+func (s *Session) writeAdoption(projectDir string, plan *adoptPlan, dryRun bool) error {
+	base := filepath.Join(projectDir, ".anyagent")
+	// Without instruction files AGENTS.md keeps coming from the user/default template
+	if plan.Template != "" {
+		if err := s.writeGeneratedFile(filepath.Join(base, "AGENTS.md.tmpl"), plan.Template, dryRun); err != nil {
+			return err
+		}
+	}
+	pc := &config.ProjectConfig{
+		ProjectName:       filepath.Base(projectDir),
+		InstalledRules:    []string{},
+		InstalledCommands: []string{},
+		EnabledAgents:     []string{plan.Agent},
+		Parameters:        map[string]string{"PROJECT_NAME": filepath.Base(projectDir)},
+	}
+	if abs, err := filepath.Abs(projectDir); err == nil {
+		pc.ProjectName = filepath.Base(abs)
+		pc.Parameters["PROJECT_NAME"] = pc.ProjectName
+	}
+	for _, r := range plan.Rules {
+		if err := s.writeGeneratedFile(filepath.Join(base, "extra_rules", r.Name+".md"), r.Content, dryRun); err != nil {
+			return err
+		}
+		pc.InstalledRules = append(pc.InstalledRules, r.Name)
+	}
+	for _, c := range plan.Commands {
+		if err := s.writeGeneratedFile(filepath.Join(base, "commands", c.Name+".md"), c.Content, dryRun); err != nil {
+			return err
+		}
+		pc.InstalledCommands = append(pc.InstalledCommands, c.Name)
+	}
+	configPath := config.GetProjectConfigPath(projectDir)
+	if dryRun {
+		s.dryRunf("write", configPath, "Would write project config: %s", configPath)
+		return nil
+	}
+	if err := pc.Save(s.Env, configPath); err != nil {
+		return fmt.Errorf("failed to save project config: %w", err)
+	}
+	s.Logger.Infof("💾 Project config written: %s\n", configPath)
+	return nil
+}
+
+// verifyAdoption renders the adopted templates and reports whether they reproduce the originals
+func (s *Session) verifyAdoption(projectDir string, plan *adoptPlan) {
+	pc, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
+	if err != nil {
+		s.warnf("Warning: Could not verify the adoption: %v", err)
+		return
+	}
+	if plan.Template != "" {
+		if content, err := pc.RenderAgentsContent(s.Env, projectDir); err != nil {
+			s.warnf("Warning: Could not render AGENTS.md: %v", err)
+		} else if original, ok := s.readAdoptableFile(filepath.Join(projectDir, plan.TemplateSource)); ok {
+			// With extra rules AGENTS.md also carries the merged files, which the diff shows
+			s.reportReproduction(plan.TemplateSource, content, original)
+		}
+	}
+	for _, c := range plan.Commands {
+		original, ok := s.readAdoptableFile(filepath.Join(projectDir, c.Source))
+		if !ok {
+			continue
+		}
+		var rendered string
+		if strings.HasPrefix(c.Source, filepath.Join(".claude", "commands")) {
+			rendered = buildClaudeCommandContent(c.Content)
+		} else {
+			rendered = buildCopilotPromptContent(c.Content)
+		}
+		s.reportReproduction(c.Source, rendered, original)
+	}
+}
+
+// maxReproductionDiffLines limits the diff shown for a file that is not reproduced
+const maxReproductionDiffLines = 20
+
+// reportReproduction compares a rendered file with the original it was adopted from and shows
+// how 'anyagent sync' will change it unless the two are byte-identical
+func (s *Session) reportReproduction(source, rendered, original string) bool {
+	if rendered == original {
+		s.Logger.Infof("✅ %s is reproduced exactly\n", source)
+		return true
+	}
+	diff := lineDiff(original, rendered)
+	if len(diff) == 0 {
+		s.warnf("%s is not reproduced exactly: line endings or the final newline differ", source)
+		return false
+	}
+	if len(diff) > maxReproductionDiffLines {
+		diff = append(diff[:maxReproductionDiffLines], fmt.Sprintf("... (%d more lines)", len(diff)-maxReproductionDiffLines))
+	}
+	s.warnf("%s is not reproduced exactly; 'anyagent sync' will change it (- original, + generated):\n    %s", source, strings.Join(diff, "\n    "))
+	return false
+}
+
+// lineDiff returns the lines removed from a ("- ") and added in b ("+ "), in order, from the
+// longest common subsequence of their lines
+func lineDiff(a, b string) []string {
+	x := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	y := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var out []string
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			i++
+			j++
+		case j < len(y) && (i == len(x) || lcs[i][j+1] >= lcs[i+1][j]):
+			out = append(out, "+ "+y[j])
+			j++
+		default:
+			out = append(out, "- "+x[i])
+			i++
+		}
+	}
+	return out
+}
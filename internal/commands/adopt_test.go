@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+func TestRunAdoptReproducesOriginals(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+
+	claudeMD := "# Team rules\n\n- Run make test before committing\n"
+	review := "---\nallowed-tools: [Read, Grep]\ndescription: Review a file\nmodel: sonnet\n---\n\nReview $ARGUMENTS carefully.\n"
+	prompt := "---\nmode: agent\ndescription: Write docs\n---\nDocument ${input:file}.\n"
+	files := map[string]string{
+		"CLAUDE.md":                            claudeMD,
+		".cursorrules":                         claudeMD, // identical copies are adopted once
+		".claude/commands/review.md":           review,
+		".github/prompts/write-docs.prompt.md": prompt,
+	}
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.RunAdopt(dir, "", false, false); err != nil {
+		t.Fatalf("RunAdopt failed: %v", err)
+	}
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.EnabledAgents) != 1 || cfg.EnabledAgents[0] != "claude" {
+		t.Errorf("enabled agents = %v, want [claude]", cfg.EnabledAgents)
+	}
+	if strings.Join(cfg.InstalledCommands, ",") != "write-docs,review" {
+		t.Errorf("installed commands = %v", cfg.InstalledCommands)
+	}
+	if len(cfg.InstalledRules) != 0 {
+		t.Errorf("identical instruction files should not become rules: %v", cfg.InstalledRules)
+	}
+	if err := s.RunAdopt(dir, "", false, false); err == nil {
+		t.Error("adopting a managed project without --force should fail")
+	}
+
+	if err := s.RunSyncWithOptions(dir, nil, false, false); err != nil {
+		t.Fatalf("RunSyncWithOptions failed: %v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "AGENTS.md")); string(b) != claudeMD {
+		t.Errorf("AGENTS.md = %q, want %q", b, claudeMD)
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "CLAUDE.md")); err != nil || target != "AGENTS.md" {
+		t.Errorf("CLAUDE.md should link to AGENTS.md, got %q, %v", target, err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, ".claude", "commands", "review.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"allowed-tools: [Read, Grep]", "description: Review a file", "model: sonnet", "\nReview $ARGUMENTS carefully.\n"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("review.md missing %q:\n%s", want, b)
+		}
+	}
+	// Copilot prompts are kept verbatim as templates
+	if got := buildCopilotPromptContent(mustReadFile(t, filepath.Join(dir, ".anyagent", "commands", "write-docs.md"))); got != prompt {
+		t.Errorf("write-docs prompt = %q, want %q", got, prompt)
+	}
+}
+
+func TestRunAdoptRoundTripsClaudeCommands(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+
+	commands := map[string]string{
+		"plain":   "Explain the selected code.\n",
+		"ordered": "---\ndescription: Review code\nallowed-tools: [Read]\n---\nReview $ARGUMENTS.\n",
+		"quoted":  "---\ndescription: 'Review code'   # keep me\nargument-hint: \"[file]\"\n---\n\n\nCheck $1\n",
+		"empty":   "---\n---\nNothing here",
+	}
+	for name, content := range commands {
+		path := filepath.Join(dir, ".claude", "commands", name+".md")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.RunAdopt(dir, "claude", false, false); err != nil {
+		t.Fatalf("RunAdopt failed: %v", err)
+	}
+	if err := s.RunSyncWithOptions(dir, nil, false, false); err != nil {
+		t.Fatalf("RunSyncWithOptions failed: %v", err)
+	}
+	for name, want := range commands {
+		if got := mustReadFile(t, filepath.Join(dir, ".claude", "commands", name+".md")); got != want {
+			t.Errorf("%s.md after adopt and sync = %q, want %q", name, got, want)
+		}
+	}
+	if got := mustReadFile(t, filepath.Join(dir, ".anyagent", "commands", "quoted.md")); !strings.Contains(got, "\ndescription: 'Review code'") {
+		t.Errorf("the adopted template should keep the description at the top level:\n%s", got)
+	}
+}
+
+func TestPlanAdoptionMergesDifferentInstructions(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".github"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".github", "copilot-instructions.md"), []byte("# Copilot\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".cursorrules"), []byte("Use tabs.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := s.planAdoption(dir, "")
+	if err != nil {
+		t.Fatalf("planAdoption failed: %v", err)
+	}
+	if plan.Agent != "copilot" || plan.TemplateSource != filepath.Join(".github", "copilot-instructions.md") {
+		t.Errorf("plan = %+v", plan)
+	}
+	if plan.Template != "# Copilot\n\n{{EXTRA_RULES}}\n" || len(plan.Rules) != 1 || plan.Rules[0].Name != "cursorrules" {
+		t.Errorf("unexpected template %q / rules %+v", plan.Template, plan.Rules)
+	}
+
+	if _, err := s.planAdoption(t.TempDir(), ""); err == nil {
+		t.Error("a repository without agent files should not be adopted")
+	}
+}
+
+func mustReadFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestReportReproduction(t *testing.T) {
+	s := newTestSession()
+	if !s.reportReproduction("CLAUDE.md", "# Rules\n", "# Rules\n") {
+		t.Error("identical content should be reported as reproduced")
+	}
+	if s.reportReproduction("CLAUDE.md", "# Rules\n", "# Rules\n\n") {
+		t.Error("content differing only in whitespace is not reproduced exactly")
+	}
+	if s.reportReproduction("CLAUDE.md", "# Rules\n\nUse tabs.\n", "# Rules\n") {
+		t.Error("content with merged rules is not reproduced exactly")
+	}
+	got := strings.Join(lineDiff("a\nb\nc\n", "a\nc\nd\n"), "|")
+	if want := "- b|+ d"; got != want {
+		t.Errorf("lineDiff = %q, want %q", got, want)
+	}
+}
@@ -11,8 +11,8 @@ import (
 // - If project config exists and enabled agent includes "copilot", return true
 // - If project config exists and enabled agent is "codex" (and not copilot), return false
 // - If no config or no enabled agents recorded, default to true (backward compatible)
-func shouldCreateCopilotRuleFiles(projectDir string) bool {
-	cfg, err := config.LoadProjectConfig(config.GetProjectConfigPath(projectDir))
+func (s *Session) shouldCreateCopilotRuleFiles(projectDir string) bool {
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
 	if err != nil || len(cfg.EnabledAgents) == 0 {
 		// Unknown agent selection → keep previous behavior
 		return true
@@ -27,13 +27,13 @@ func shouldCreateCopilotRuleFiles(projectDir string) bool {
 }
 
 // shouldCreateCopilotCommandFiles determines if Copilot command files should be created
-func shouldCreateCopilotCommandFiles(projectDir string) bool {
-	return shouldCreateCopilotRuleFiles(projectDir)
+func (s *Session) shouldCreateCopilotCommandFiles(projectDir string) bool {
+	return s.shouldCreateCopilotRuleFiles(projectDir)
 }
 
 // shouldCreateQDevCommandFiles returns true if Amazon Q Developer is the selected agent
-func shouldCreateQDevCommandFiles(projectDir string) bool {
-	cfg, err := config.LoadProjectConfig(config.GetProjectConfigPath(projectDir))
+func (s *Session) shouldCreateQDevCommandFiles(projectDir string) bool {
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
 	if err != nil || len(cfg.EnabledAgents) == 0 {
 		return false
 	}
@@ -46,13 +46,13 @@ func shouldCreateQDevCommandFiles(projectDir string) bool {
 }
 
 // shouldCreateQDevRuleFiles returns true if Q Developer is the selected agent
-func shouldCreateQDevRuleFiles(projectDir string) bool {
-	return shouldCreateQDevCommandFiles(projectDir)
+func (s *Session) shouldCreateQDevRuleFiles(projectDir string) bool {
+	return s.shouldCreateQDevCommandFiles(projectDir)
 }
 
 // shouldCreateCodexCommandFiles returns true if Codex is the selected agent
-func shouldCreateCodexCommandFiles(projectDir string) bool {
-	cfg, err := config.LoadProjectConfig(config.GetProjectConfigPath(projectDir))
+func (s *Session) shouldCreateCodexCommandFiles(projectDir string) bool {
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
 	if err != nil || len(cfg.EnabledAgents) == 0 {
 		return false
 	}
@@ -65,8 +65,8 @@ func shouldCreateCodexCommandFiles(projectDir string) bool {
 }
 
 // selectedAgent returns the first enabled agent name from project config (single-agent expected).
-func selectedAgent(projectDir string) string {
-	cfg, err := config.LoadProjectConfig(config.GetProjectConfigPath(projectDir))
+func (s *Session) selectedAgent(projectDir string) string {
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
 	if err != nil {
 		return ""
 	}
@@ -77,8 +77,8 @@ func selectedAgent(projectDir string) string {
 }
 
 // shouldCreateClaudeCommandFiles returns true if Claude Code is the selected agent
-func shouldCreateClaudeCommandFiles(projectDir string) bool {
-	cfg, err := config.LoadProjectConfig(config.GetProjectConfigPath(projectDir))
+func (s *Session) shouldCreateClaudeCommandFiles(projectDir string) bool {
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
 	if err != nil || len(cfg.EnabledAgents) == 0 {
 		return false
 	}
@@ -91,8 +91,8 @@ func shouldCreateClaudeCommandFiles(projectDir string) bool {
 }
 
 // shouldCreateGeminiCommandFiles returns true if Gemini Code is the selected agent
-func shouldCreateGeminiCommandFiles(projectDir string) bool {
-	cfg, err := config.LoadProjectConfig(config.GetProjectConfigPath(projectDir))
+func (s *Session) shouldCreateGeminiCommandFiles(projectDir string) bool {
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
 	if err != nil || len(cfg.EnabledAgents) == 0 {
 		return false
 	}
@@ -0,0 +1,199 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ~/.codex/config.toml is shared with settings the user edits by hand, so MCP servers are
+// edited in place: only the statements of the affected server are replaced, and every other
+// line (comments, formatting, unrelated tables) is kept byte for byte.
+
+// codexConfigPath returns ~/.codex/config.toml
+func (s *Session) codexConfigPath() (string, error) {
+	homeDir, err := s.Env.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".codex", "config.toml"), nil
+}
+
+// codexMCPServerNames returns the servers defined under mcp_servers, in any of the forms
+// TOML allows ([mcp_servers.x] tables, inline tables, dotted keys, quoted names)
+func codexMCPServerNames(content string) (map[string]bool, error) {
+	doc, err := decodeToml(content)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	servers, _ := doc["mcp_servers"].(map[string]any)
+	for name := range servers {
+		names[name] = true
+	}
+	return names, nil
+}
+
+// setCodexMCPServer replaces the server's definition with section, or appends section after
+// the other MCP servers (or at the end of the file) when the server is new
+func setCodexMCPServer(content, name, section string) (string, error) {
+	statements, err := scanToml(content)
+	if err != nil {
+		return "", err
+	}
+	ranges, err := codexMCPServerRanges(content, statements, name)
+	if err != nil {
+		return "", err
+	}
+	// A replaced [mcp_servers.x] table keeps its place. A server defined by key/value lines
+	// (x = { ... } under [mcp_servers]) is rewritten as a table after the table holding it.
+	at := -1
+	for i, st := range statements {
+		if hasPathPrefix(st.Path, []string{"mcp_servers", name}) {
+			if st.Header {
+				at = leadingCommentStart(content, st.Start)
+			} else {
+				at = tomlTableEnd(content, statements, i)
+			}
+			break
+		}
+	}
+	if at < 0 {
+		at = codexMCPTablesEnd(content, statements)
+	}
+	if at < 0 {
+		at = len(content)
+	}
+	head := strings.TrimRight(cutRanges(content, ranges, 0, at), "\n")
+	tail := strings.TrimLeft(cutRanges(content, ranges, at, len(content)), "\n")
+	var out strings.Builder
+	if head != "" {
+		out.WriteString(head + "\n\n")
+	}
+	out.WriteString(strings.TrimRight(section, "\n") + "\n")
+	if tail != "" {
+		out.WriteString("\n" + tail)
+	}
+	return out.String(), nil
+}
+
+// removeCodexMCPServer drops every statement of the server, keeping a single blank line
+// between the tables around it
+func removeCodexMCPServer(content, name string) (string, error) {
+	statements, err := scanToml(content)
+	if err != nil {
+		return "", err
+	}
+	ranges, err := codexMCPServerRanges(content, statements, name)
+	if err != nil {
+		return "", err
+	}
+	if len(ranges) == 0 {
+		return content, nil
+	}
+	result := strings.TrimRight(cutRanges(content, ranges, 0, len(content)), "\n")
+	if result == "" {
+		return "", nil
+	}
+	return result + "\n", nil
+}
+
+// codexMCPServerRanges returns the sorted byte ranges holding the server's definition: its
+// tables and sub-tables ([mcp_servers.x], [mcp_servers.x.env]) with their leading comments,
+// and key/value lines such as x = { ... } under [mcp_servers]
+func codexMCPServerRanges(content string, statements []tomlStatement, name string) ([][2]int, error) {
+	prefix := []string{"mcp_servers", name}
+	var ranges [][2]int
+	for i, st := range statements {
+		if !st.Header && len(st.Path) < len(prefix) && hasPathPrefix(prefix, st.Path) {
+			if st.Inline {
+				return nil, fmt.Errorf("%s is an inline table; move the servers to [mcp_servers.<name>] tables to let anyagent edit them", strings.Join(st.Path, "."))
+			}
+		}
+		if !hasPathPrefix(st.Path, prefix) {
+			continue
+		}
+		if !st.Header {
+			ranges = append(ranges, [2]int{st.Start, st.End})
+			continue
+		}
+		ranges = append(ranges, [2]int{leadingCommentStart(content, st.Start), tomlTableEnd(content, statements, i)})
+	}
+	// Key/value lines inside a removed table are already covered by the table's range
+	var merged [][2]int
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r[0] < merged[n-1][1] {
+			merged[n-1][1] = max(merged[n-1][1], r[1])
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged, nil
+}
+
+// cutRanges returns content[from:to] without the given sorted ranges
+func cutRanges(content string, ranges [][2]int, from, to int) string {
+	var sb strings.Builder
+	pos := from
+	for _, r := range ranges {
+		start, end := max(r[0], from), min(r[1], to)
+		if start >= end {
+			continue
+		}
+		if start > pos {
+			sb.WriteString(content[pos:start])
+		}
+		pos = max(pos, end)
+	}
+	if pos < to {
+		sb.WriteString(content[pos:to])
+	}
+	return sb.String()
+}
+
+// codexMCPTablesEnd returns the end of the last [mcp_servers...] table, or -1 if there is none
+func codexMCPTablesEnd(content string, statements []tomlStatement) int {
+	end := -1
+	for i, st := range statements {
+		if !st.Header || !hasPathPrefix(st.Path, []string{"mcp_servers"}) {
+			continue
+		}
+		end = tomlTableEnd(content, statements, i)
+	}
+	return end
+}
+
+// tomlTableEnd returns where the table opened by statements[i] ends: at the next header
+// (before its leading comments) or at the end of the document
+func tomlTableEnd(content string, statements []tomlStatement, i int) int {
+	for _, next := range statements[i+1:] {
+		if next.Header {
+			return leadingCommentStart(content, next.Start)
+		}
+	}
+	return len(content)
+}
+
+// leadingCommentStart extends a statement start upwards over the comment lines directly above it
+func leadingCommentStart(content string, start int) int {
+	for start > 0 {
+		prev := strings.LastIndexByte(content[:start-1], '\n') + 1
+		if !strings.HasPrefix(strings.TrimSpace(content[prev:start]), "#") {
+			break
+		}
+		start = prev
+	}
+	return start
+}
+
+func hasPathPrefix(path, prefix []string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/internal/fsys"
+	"github.com/shibukawa/anyagent/internal/logging"
+)
+
+const codexConfigFixture = `# Codex settings
+model = "o3"
+
+# File system server
+[mcp_servers.fs]
+command = "npx"
+args = ["-y", "@modelcontextprotocol/server-filesystem"]
+
+[mcp_servers.fs.env]
+ROOT = "/tmp" # scratch
+
+[mcp_servers."docs.internal"]
+url = "https://docs.example.com/mcp"
+
+[profiles.fast]
+model = "gpt-5-mini"   # keep this comment
+`
+
+func TestCodexMCPServerNames(t *testing.T) {
+	names, err := codexMCPServerNames(codexConfigFixture + "\n[mcp_servers]\ninline = { command = \"x\" }\n")
+	if err != nil {
+		t.Fatalf("codexMCPServerNames failed: %v", err)
+	}
+	for _, name := range []string{"fs", "docs.internal", "inline"} {
+		if !names[name] {
+			t.Errorf("expected server %q in %v", name, names)
+		}
+	}
+	if names["fs.env"] || len(names) != 3 {
+		t.Errorf("unexpected servers: %v", names)
+	}
+}
+
+func TestSetCodexMCPServer_ReplacesNestedTables(t *testing.T) {
+	section := codexMCPSection("fs", config.MCPServer{Command: "node", Args: []string{"fs.js"}, Env: map[string]string{"ROOT": "/srv"}})
+	got, err := setCodexMCPServer(codexConfigFixture, "fs", section)
+	if err != nil {
+		t.Fatalf("setCodexMCPServer failed: %v", err)
+	}
+	want := `# Codex settings
+model = "o3"
+
+[mcp_servers.fs]
+command = "node"
+args = ["fs.js"]
+env = { ROOT = "/srv" }
+
+[mcp_servers."docs.internal"]
+url = "https://docs.example.com/mcp"
+
+[profiles.fast]
+model = "gpt-5-mini"   # keep this comment
+`
+	if got != want {
+		t.Errorf("unexpected config:\n%s\nwant:\n%s", got, want)
+	}
+	if _, err := decodeToml(got); err != nil {
+		t.Errorf("result is not valid TOML: %v", err)
+	}
+}
+
+func TestSetCodexMCPServer_AppendsAfterOtherServers(t *testing.T) {
+	section := codexMCPSection("my.server", config.MCPServer{Command: "srv", StartupTimeout: 20, ToolTimeout: 60})
+	got, err := setCodexMCPServer(codexConfigFixture, "my.server", section)
+	if err != nil {
+		t.Fatalf("setCodexMCPServer failed: %v", err)
+	}
+	if !strings.Contains(got, "url = \"https://docs.example.com/mcp\"\n\n[mcp_servers.\"my.server\"]\ncommand = \"srv\"\nargs = []\nstartup_timeout_sec = 20\ntool_timeout_sec = 60\n\n[profiles.fast]") {
+		t.Errorf("new server not inserted after the existing servers:\n%s", got)
+	}
+	names, err := codexMCPServerNames(got)
+	if err != nil || !names["my.server"] {
+		t.Errorf("quoted server name not readable back: %v %v", names, err)
+	}
+
+	got, err = setCodexMCPServer("", "fs", codexMCPSection("fs", config.MCPServer{Command: "npx"}))
+	if err != nil || got != "[mcp_servers.fs]\ncommand = \"npx\"\nargs = []\n" {
+		t.Errorf("unexpected config for empty file: %q %v", got, err)
+	}
+}
+
+func TestSetCodexMCPServer_InlineAndDottedForms(t *testing.T) {
+	content := "[mcp_servers]\nfs = { command = \"old\" }\nother.command = \"keep\"\n"
+	got, err := setCodexMCPServer(content, "fs", codexMCPSection("fs", config.MCPServer{Command: "new"}))
+	if err != nil {
+		t.Fatalf("setCodexMCPServer failed: %v", err)
+	}
+	doc, err := decodeToml(got)
+	if err != nil {
+		t.Fatalf("result is not valid TOML: %v\n%s", err, got)
+	}
+	servers := doc["mcp_servers"].(map[string]any)
+	if servers["fs"].(map[string]any)["command"] != "new" || servers["other"].(map[string]any)["command"] != "keep" {
+		t.Errorf("unexpected servers: %v", servers)
+	}
+
+	if _, err := setCodexMCPServer("mcp_servers = { fs = { command = \"x\" } }\n", "fs", "[mcp_servers.fs]\n"); err == nil {
+		t.Errorf("expected an error for servers kept in an inline table")
+	}
+}
+
+func TestRemoveCodexMCPServer(t *testing.T) {
+	got, err := removeCodexMCPServer(codexConfigFixture, "fs")
+	if err != nil {
+		t.Fatalf("removeCodexMCPServer failed: %v", err)
+	}
+	want := `# Codex settings
+model = "o3"
+
+[mcp_servers."docs.internal"]
+url = "https://docs.example.com/mcp"
+
+[profiles.fast]
+model = "gpt-5-mini"   # keep this comment
+`
+	if got != want {
+		t.Errorf("unexpected config:\n%s\nwant:\n%s", got, want)
+	}
+	got, err = removeCodexMCPServer(got, "docs.internal")
+	if err != nil || strings.Contains(got, "docs") || !strings.HasSuffix(got, "# keep this comment\n") {
+		t.Errorf("unexpected config after removing quoted server: %q %v", got, err)
+	}
+}
+
+func TestUpdateCodexMCPConfig_KeepsUserSettings(t *testing.T) {
+	s := newTestSession()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, ".codex", "config.toml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(codexConfigFixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+	servers := map[string]config.MCPServer{"fs": {Command: "npx", Cwd: "/work"}, "new": {Command: "srv"}}
+	if err := s.updateCodexMCPConfig(servers, false); err != nil {
+		t.Fatalf("updateCodexMCPConfig failed: %v", err)
+	}
+	b, _ := os.ReadFile(path)
+	doc, err := decodeToml(string(b))
+	if err != nil {
+		t.Fatalf("result is not valid TOML: %v\n%s", err, b)
+	}
+	fs := doc["mcp_servers"].(map[string]any)["fs"].(map[string]any)
+	if fs["cwd"] != "/work" || fs["env"] != nil {
+		t.Errorf("fs server not replaced: %v", fs)
+	}
+	if doc["model"] != "o3" || !strings.Contains(string(b), "# keep this comment") {
+		t.Errorf("user settings not preserved:\n%s", b)
+	}
+	if missing, err := s.missingCodexMCPServers(servers); err != nil || len(missing) != 0 {
+		t.Errorf("expected no missing servers, got %v", missing)
+	}
+}
+
+func TestSetCodexMCPServer_KeepsDatetimes(t *testing.T) {
+	content := "created = 1979-05-27 07:32:00Z\n\n[mcp_servers.fs]\ncommand = \"old\"\n"
+	got, err := setCodexMCPServer(content, "fs", codexMCPSection("fs", config.MCPServer{Command: "new"}))
+	if err != nil {
+		t.Fatalf("setCodexMCPServer failed: %v", err)
+	}
+	if !strings.HasPrefix(got, "created = 1979-05-27 07:32:00Z\n\n[mcp_servers.fs]\ncommand = \"new\"") {
+		t.Errorf("unexpected config:\n%s", got)
+	}
+}
+
+func TestMissingCodexMCPServers_ReportsParseErrors(t *testing.T) {
+	env := fsys.Memory("/work", "/home/dev")
+	s := &Session{Logger: logging.New(io.Discard, io.Discard, logging.Options{}), Env: env}
+	servers := map[string]config.MCPServer{"fs": {Command: "npx"}}
+	if missing, err := s.missingCodexMCPServers(servers); err != nil || len(missing) != 1 {
+		t.Errorf("without config.toml every server is missing, got %v, %v", missing, err)
+	}
+	_ = env.FS.MkdirAll("/home/dev/.codex", 0755)
+	_ = env.FS.WriteFile("/home/dev/.codex/config.toml", []byte("[mcp_servers.fs\ncommand = \"npx\"\n"), 0644)
+	if _, err := s.missingCodexMCPServers(servers); err == nil || !strings.Contains(err.Error(), "config.toml") {
+		t.Errorf("expected a parse error naming config.toml, got %v", err)
+	}
+	if err := s.updateCodexMCPConfig(servers, false); err == nil {
+		t.Error("updateCodexMCPConfig should fail on a config.toml it cannot parse")
+	}
+}
+
+func TestUpdateCodexMCPConfig_FailsWithoutHome(t *testing.T) {
+	t.Setenv("HOME", "")
+	s := &Session{Logger: logging.New(io.Discard, io.Discard, logging.Options{}), Env: &fsys.Env{FS: fsys.NewMemFS()}}
+	if err := s.updateCodexMCPConfig(map[string]config.MCPServer{"fs": {Command: "npx"}}, false); err == nil {
+		t.Error("expected an error when the home directory is unknown")
+	}
+}
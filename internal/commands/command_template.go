@@ -0,0 +1,298 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// commandArgument is a named argument declared in a command template frontmatter:
+//
+//	arguments:
+//	  - name: file
+//	    description: File to review
+//	    required: true
+//
+// The template body refers to it with the agent-neutral {{arg:file}} placeholder, and
+// to the whole argument string with {{args}}.
+type commandArgument struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Required    bool   `yaml:"required"`
+}
+
+// commandTemplate is a parsed command template (frontmatter + body)
+type commandTemplate struct {
+	raw         string
+	front       *yaml.Node // frontmatter mapping node; nil when the template has none
+	Description string
+	Arguments   []commandArgument
+	Agents      map[string]*yaml.Node // per-agent frontmatter blocks from 'agents:'
+	Body        string
+}
+
+var (
+	namedArgPattern = regexp.MustCompile(`\{\{arg:([A-Za-z][A-Za-z0-9_-]*)\}\}`)
+	allArgsPattern  = regexp.MustCompile(`\{\{args\}\}`)
+)
+
+// parseCommandTemplate splits a command template into frontmatter metadata and body.
+// Malformed frontmatter is tolerated: the template is then treated as having no metadata
+// besides a best-effort description, matching the previous line-based behavior.
+func parseCommandTemplate(content string) commandTemplate {
+	ct := commandTemplate{
+		raw:         content,
+		Description: extractDescriptionFromTemplate(content),
+		Body:        removeYAMLFrontmatter(content),
+	}
+	front, ok := frontmatterText(content)
+	if !ok {
+		return ct
+	}
+	// The closing newline ends the last line, which a trailing block scalar keeps
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(front+"\n"), &doc); err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return ct
+	}
+	ct.front = doc.Content[0]
+	if v := mappingValue(ct.front, "description"); v != nil && v.Kind == yaml.ScalarNode {
+		ct.Description = v.Value
+	}
+	if v := mappingValue(ct.front, "arguments"); v != nil {
+		_ = v.Decode(&ct.Arguments)
+	}
+	if v := mappingValue(ct.front, "agents"); v != nil && v.Kind == yaml.MappingNode {
+		ct.Agents = map[string]*yaml.Node{}
+		for i := 0; i+1 < len(v.Content); i += 2 {
+			if block := v.Content[i+1]; block.Kind == yaml.MappingNode {
+				ct.Agents[v.Content[i].Value] = block
+			}
+		}
+	}
+	return ct
+}
+
+// agentBlock returns the 'agents.<name>' frontmatter block, or nil
+func (ct commandTemplate) agentBlock(agent string) *yaml.Node {
+	return ct.Agents[agent]
+}
+
+// hasAgentMetadata reports whether the frontmatter has an 'agents:' section
+func (ct commandTemplate) hasAgentMetadata() bool {
+	return mappingValue(ct.front, "agents") != nil
+}
+
+// frontmatterText returns the YAML between the leading '---' fences
+func frontmatterText(content string) (string, bool) {
+	lines := strings.Split(content, "\n")
+	if len(lines) < 3 || lines[0] != "---" {
+		return "", false
+	}
+	for i := 1; i < len(lines); i++ {
+		if lines[i] == "---" {
+			return strings.Join(lines[1:i], "\n"), true
+		}
+	}
+	return "", false
+}
+
+// mappingValue returns the value node for key in a YAML mapping node
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// deleteMappingKey removes key from a YAML mapping node
+func deleteMappingKey(m *yaml.Node, key string) {
+	if m == nil {
+		return
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// setMappingValue sets key to value in a YAML mapping node, replacing an existing entry in place
+func setMappingValue(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// mergeMapping copies every entry of src into dst, overriding existing keys
+func mergeMapping(dst, src *yaml.Node) {
+	if src == nil {
+		return
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		setMappingValue(dst, src.Content[i].Value, src.Content[i+1])
+	}
+}
+
+// stringNode returns a plain YAML string scalar
+func stringNode(v string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+}
+
+// usesArguments reports whether the template declares or references arguments
+func (ct commandTemplate) usesArguments() bool {
+	return len(ct.Arguments) > 0 || namedArgPattern.MatchString(ct.Body) || allArgsPattern.MatchString(ct.Body)
+}
+
+// argumentNames returns declared argument names followed by any undeclared names
+// referenced in the body, in order of first appearance.
+func (ct commandTemplate) argumentNames() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, a := range ct.Arguments {
+		if a.Name != "" && !seen[a.Name] {
+			names = append(names, a.Name)
+			seen[a.Name] = true
+		}
+	}
+	for _, m := range namedArgPattern.FindAllStringSubmatch(ct.Body, -1) {
+		if !seen[m[1]] {
+			names = append(names, m[1])
+			seen[m[1]] = true
+		}
+	}
+	return names
+}
+
+// argumentDescription returns the declared description of an argument, if any
+func (ct commandTemplate) argumentDescription(name string) string {
+	for _, a := range ct.Arguments {
+		if a.Name == name {
+			return a.Description
+		}
+	}
+	return ""
+}
+
+// argumentHint renders a usage hint like "<file> [focus]"
+func (ct commandTemplate) argumentHint() string {
+	required := map[string]bool{}
+	for _, a := range ct.Arguments {
+		required[a.Name] = a.Required
+	}
+	var parts []string
+	for _, name := range ct.argumentNames() {
+		if required[name] {
+			parts = append(parts, "<"+name+">")
+		} else {
+			parts = append(parts, "["+name+"]")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// positionalBody translates placeholders to $1..$N and $ARGUMENTS (Claude Code, Codex)
+func (ct commandTemplate) positionalBody() string {
+	index := map[string]int{}
+	for i, name := range ct.argumentNames() {
+		index[name] = i + 1
+	}
+	body := namedArgPattern.ReplaceAllStringFunc(ct.Body, func(m string) string {
+		return fmt.Sprintf("$%d", index[namedArgPattern.FindStringSubmatch(m)[1]])
+	})
+	return allArgsPattern.ReplaceAllString(body, "$$ARGUMENTS")
+}
+
+// copilotBody translates placeholders to VS Code prompt variables ${input:name[:placeholder]}
+func (ct commandTemplate) copilotBody() string {
+	body := namedArgPattern.ReplaceAllStringFunc(ct.Body, func(m string) string {
+		name := namedArgPattern.FindStringSubmatch(m)[1]
+		if desc := ct.argumentDescription(name); desc != "" {
+			// ':' and '}' would end the placeholder early
+			desc = strings.NewReplacer(":", " ", "}", ")").Replace(desc)
+			return fmt.Sprintf("${input:%s:%s}", name, desc)
+		}
+		return fmt.Sprintf("${input:%s}", name)
+	})
+	return allArgsPattern.ReplaceAllString(body, "$${input:args}")
+}
+
+// geminiBody translates placeholders for Gemini CLI, which only knows {{args}}.
+// A single named argument maps to {{args}}. With several, they are referenced by
+// name and Gemini appends the raw arguments to the prompt.
+func (ct commandTemplate) geminiBody() string {
+	names := ct.argumentNames()
+	if len(names) == 1 {
+		return namedArgPattern.ReplaceAllString(ct.Body, "{{args}}")
+	}
+	body := namedArgPattern.ReplaceAllString(ct.Body, "<$1>")
+	if len(names) > 1 && !allArgsPattern.MatchString(body) {
+		body = strings.TrimRight(body, "\n") + fmt.Sprintf("\n\nThe arguments are given in this order: %s\n", ct.argumentHint())
+	}
+	return body
+}
+
+// plainBody replaces placeholders with readable names for agents without argument support (Q Dev)
+func (ct commandTemplate) plainBody() string {
+	body := namedArgPattern.ReplaceAllString(ct.Body, "<$1>")
+	return allArgsPattern.ReplaceAllString(body, "<arguments>")
+}
+
+// renderFrontmatter encodes a frontmatter mapping node between '---' fences
+func renderFrontmatter(m *yaml.Node) string {
+	if m == nil || len(m.Content) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	enc := yaml.NewEncoder(&sb)
+	enc.SetIndent(2)
+	_ = enc.Encode(m)
+	_ = enc.Close()
+	return "---\n" + sb.String() + "---\n"
+}
+
+// tomlValue encodes a YAML value node as a TOML value (scalars, arrays, inline tables)
+func tomlValue(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		switch n.Tag {
+		case "!!bool", "!!int", "!!float":
+			return n.Value
+		}
+		return tomlQuote(n.Value)
+	case yaml.SequenceNode:
+		var items []string
+		for _, c := range n.Content {
+			items = append(items, tomlValue(c))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case yaml.MappingNode:
+		var items []string
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			items = append(items, fmt.Sprintf("%s = %s", tomlKey(n.Content[i].Value), tomlValue(n.Content[i+1])))
+		}
+		return "{ " + strings.Join(items, ", ") + " }"
+	case yaml.AliasNode:
+		return tomlValue(n.Alias)
+	}
+	return "\"\""
+}
+
+// tomlKey returns key as a bare TOML key when possible, quoted otherwise
+func tomlKey(key string) string {
+	if key != "" && strings.Trim(key, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789_-") == "" {
+		return key
+	}
+	return tomlQuote(key)
+}
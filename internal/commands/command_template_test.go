@@ -0,0 +1,163 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+const argsTemplate = `---
+mode: 'agent'
+description: 'Review a file'
+arguments:
+  - name: file
+    description: File to review
+    required: true
+  - name: focus
+---
+
+Review {{arg:file}} focusing on {{arg:focus}}.
+Raw input: {{args}}
+`
+
+func TestCommandArgumentTranslation(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func(string) string
+		contains []string
+		absent   []string
+	}{
+		{
+			name:     "Claude uses positional arguments and argument-hint",
+			build:    buildClaudeCommandContent,
+			contains: []string{"argument-hint: <file> [focus]", "Review $1 focusing on $2.", "Raw input: $ARGUMENTS", "description: Review a file"},
+			absent:   []string{"{{arg:", "{{args}}", "arguments:"},
+		},
+		{
+			name:     "Copilot uses input variables",
+			build:    buildCopilotPromptContent,
+			contains: []string{"mode: 'agent'", "Review ${input:file:File to review} focusing on ${input:focus}.", "Raw input: ${input:args}"},
+			absent:   []string{"{{arg:", "arguments:"},
+		},
+		{
+			name:     "Codex uses positional arguments",
+			build:    buildCodexCommandContent,
+			contains: []string{"Review $1 focusing on $2.", "Raw input: $ARGUMENTS"},
+			absent:   []string{"---", "{{arg:"},
+		},
+		{
+			name:     "Q Dev renders readable markers",
+			build:    buildQDevCommandContent,
+			contains: []string{"Review <file> focusing on <focus>.", "Raw input: <arguments>"},
+			absent:   []string{"---", "{{"},
+		},
+		{
+			name:     "Gemini keeps {{args}}",
+			build:    buildGeminiCommandTOML,
+			contains: []string{`description = "Review a file"`, "Review <file> focusing on <focus>.", "Raw input: {{args}}"},
+			absent:   []string{"{{arg:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.build(argsTemplate)
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("output missing %q:\n%s", want, got)
+				}
+			}
+			for _, unwanted := range tt.absent {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("output should not contain %q:\n%s", unwanted, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGeminiSingleArgument(t *testing.T) {
+	tmpl := "---\ndescription: 'Explain'\narguments:\n  - name: topic\n---\nExplain {{arg:topic}}\n"
+	got := buildGeminiCommandTOML(tmpl)
+	if !strings.Contains(got, "Explain {{args}}") {
+		t.Fatalf("single argument should map to {{args}}:\n%s", got)
+	}
+	if strings.Contains(got, "given in this order") {
+		t.Fatalf("single argument should not add an order hint:\n%s", got)
+	}
+}
+
+func TestCopilotPromptWithoutArgumentsIsVerbatim(t *testing.T) {
+	tmpl := "---\nmode: 'edit'\ndescription: 'Plain'\n---\n\n# Plain\n"
+	if got := buildCopilotPromptContent(tmpl); got != tmpl {
+		t.Fatalf("template without arguments should be copied verbatim:\n%s", got)
+	}
+}
+
+const agentMetadataTemplate = `---
+mode: 'edit'
+description: 'Review changes'
+agents:
+  claude:
+    allowed-tools: [Bash(git diff:*)]
+    model: opus
+  copilot:
+    mode: agent
+    tools: ['changes', 'codebase']
+  gemini:
+    model: gemini-2.5-pro
+  codex:
+    description: Review changes
+---
+
+Review the diff.
+`
+
+func TestCommandAgentMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func(string) string
+		contains []string
+		absent   []string
+	}{
+		{
+			name:     "Claude merges agents.claude",
+			build:    buildClaudeCommandContent,
+			contains: []string{"allowed-tools: ['Bash(git diff:*)']", "model: opus", "description: Review changes"},
+			absent:   []string{"allowed-tools: []", "agents:", "tools: ['changes'"},
+		},
+		{
+			name:     "Copilot merges agents.copilot",
+			build:    buildCopilotPromptContent,
+			contains: []string{"mode: agent", "tools: ['changes', 'codebase']", "description: 'Review changes'", "Review the diff."},
+			absent:   []string{"mode: 'edit'", "agents:", "allowed-tools"},
+		},
+		{
+			name:     "Gemini adds TOML keys",
+			build:    buildGeminiCommandTOML,
+			contains: []string{`model = "gemini-2.5-pro"`, `description = "Review changes"`},
+			absent:   []string{"allowed-tools"},
+		},
+		{
+			name:     "Codex emits frontmatter only from agents.codex",
+			build:    buildCodexCommandContent,
+			contains: []string{"---\ndescription: Review changes\n---\n", "Review the diff."},
+			absent:   []string{"mode:", "agents:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.build(agentMetadataTemplate)
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("output missing %q:\n%s", want, got)
+				}
+			}
+			for _, unwanted := range tt.absent {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("output should not contain %q:\n%s", unwanted, got)
+				}
+			}
+		})
+	}
+}
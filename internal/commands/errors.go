@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors commands wrap so callers (e.g. pkg/anyagent) can tell failures apart with errors.Is
+var (
+	ErrProjectNotFound  = errors.New("project directory does not exist")
+	ErrNotInitialized   = errors.New("project is not initialized with anyagent. Run 'anyagent sync' first")
+	ErrUnsupportedAgent = errors.New("unsupported agent")
+	ErrUnknownTemplate  = errors.New("template is not available")
+	ErrInputRequired    = errors.New("input is required but prompts are disabled")
+)
+
+// kindError is an error with its own message that matches one of the errors above
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string { return e.msg }
+
+func (e *kindError) Unwrap() error { return e.kind }
+
+// errorOf formats an error that errors.Is reports as kind
+func errorOf(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
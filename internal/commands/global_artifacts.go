@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+// User-global artifacts are shared by every project on the machine. Each project that installs
+// or finds one registers itself in the global state, and an artifact is only deleted when the
+// last project releases it.
+
+// qdevGlobalPromptPath returns ~/.aws/amazonq/prompts/<name>.md; Q Dev prompt names use spaces
+func qdevGlobalPromptPath(homeDir, command string) string {
+	name := strings.ReplaceAll(strings.ReplaceAll(command, "-", " "), "_", " ")
+	return filepath.Join(homeDir, ".aws", "amazonq", "prompts", fmt.Sprintf("%s.md", name))
+}
+
+// codexGlobalPromptPath returns ~/.codex/prompts/<name>.md
+func codexGlobalPromptPath(homeDir, command string) string {
+	return filepath.Join(homeDir, ".codex", "prompts", fmt.Sprintf("%s.md", command))
+}
+
+// codexMCPArtifact identifies a server section of ~/.codex/config.toml in the global state
+func (s *Session) codexMCPArtifact(name string) string {
+	path, err := s.codexConfigPath()
+	if err != nil {
+		path = filepath.Join("~", ".codex", "config.toml")
+	}
+	return path + "#mcp_servers." + name
+}
+
+// acquireGlobalArtifacts records that the project uses the artifacts
+func (s *Session) acquireGlobalArtifacts(projectDir string, artifacts []string, dryRun bool) {
+	if dryRun || len(artifacts) == 0 {
+		return
+	}
+	err := config.UpdateGlobalState(s.Env, func(state *config.GlobalState) error {
+		for _, artifact := range artifacts {
+			state.AddReference(artifact, projectDir)
+		}
+		return nil
+	})
+	if err != nil {
+		s.warnf("Warning: Could not update global state: %v", err)
+	}
+}
+
+// releaseGlobalArtifact drops the project's reference and, when no other project uses the
+// artifact, calls remove while still holding the global state lock. Artifacts the project never
+// referenced (e.g. created by hand) and artifacts whose state cannot be read are kept. Only the
+// error of remove is returned; when it fails, the reference is kept.
+func (s *Session) releaseGlobalArtifact(projectDir, artifact, label string, dryRun bool, remove func() error) error {
+	var removeErr error
+	release := func(state *config.GlobalState) error {
+		if !state.IsReferencedBy(artifact, projectDir) {
+			s.Logger.Infof("ℹ️  Keeping %s: not installed by this project\n", label)
+			return nil
+		}
+		if others := state.RemoveReference(artifact, projectDir); len(others) > 0 {
+			s.Logger.Infof("ℹ️  Keeping %s: still used by %d other project(s): %s\n", label, len(others), strings.Join(others, ", "))
+			return nil
+		}
+		removeErr = remove()
+		return removeErr
+	}
+	var err error
+	if dryRun {
+		var state *config.GlobalState
+		if state, err = config.LoadGlobalState(s.Env); err == nil {
+			err = release(state)
+		}
+	} else {
+		err = config.UpdateGlobalState(s.Env, release)
+	}
+	if removeErr != nil {
+		return removeErr
+	}
+	if err != nil {
+		s.warnf("Warning: Could not update global state for %s: %v", label, err)
+	}
+	return nil
+}
+
+// removeGlobalArtifact releases the project's reference and removes the file when unused
+func (s *Session) removeGlobalArtifact(projectDir, path, label string, dryRun bool) error {
+	if _, err := s.Env.FS.Lstat(path); os.IsNotExist(err) {
+		s.forgetGlobalArtifact(projectDir, path, dryRun)
+		return nil
+	}
+	return s.releaseGlobalArtifact(projectDir, path, label, dryRun, func() error {
+		return s.removePath(path, label, dryRun)
+	})
+}
+
+// forgetGlobalArtifact drops the project's reference to an artifact that no longer exists
+func (s *Session) forgetGlobalArtifact(projectDir, artifact string, dryRun bool) {
+	if dryRun {
+		return
+	}
+	_ = config.UpdateGlobalState(s.Env, func(state *config.GlobalState) error {
+		state.RemoveReference(artifact, projectDir)
+		return nil
+	})
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/internal/fsys"
+	"github.com/shibukawa/anyagent/internal/logging"
+)
+
+func TestGlobalArtifacts_Concurrent(t *testing.T) {
+	env := fsys.Memory("/work", "/home/dev")
+	artifact := codexGlobalPromptPath("/home/dev", "review")
+	const projects = 30
+	session := func() *Session {
+		return &Session{Logger: logging.New(io.Discard, io.Discard, logging.Options{}), Env: env}
+	}
+	project := func(i int) string { return fmt.Sprintf("/work/project-%02d", i) }
+
+	var wg sync.WaitGroup
+	for i := range projects {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session().acquireGlobalArtifacts(project(i), []string{artifact}, false)
+		}()
+	}
+	wg.Wait()
+	state, err := config.LoadGlobalState(env)
+	if err != nil {
+		t.Fatalf("LoadGlobalState failed: %v", err)
+	}
+	if refs := state.References(artifact); len(refs) != projects {
+		t.Fatalf("expected %d references, got %d", projects, len(refs))
+	}
+
+	// All but the last project release it at once; only the last release removes it
+	var removed atomic.Int32
+	remove := func() error { removed.Add(1); return nil }
+	for i := 1; i < projects; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := session().releaseGlobalArtifact(project(i), artifact, "review", false, remove); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := removed.Load(); n != 0 {
+		t.Fatalf("artifact removed %d times while project-00 still uses it", n)
+	}
+	if err := session().releaseGlobalArtifact(project(0), artifact, "review", false, remove); err != nil {
+		t.Fatal(err)
+	}
+	if n := removed.Load(); n != 1 {
+		t.Errorf("expected the last release to remove the artifact, removed %d times", n)
+	}
+	state, _ = config.LoadGlobalState(env)
+	if refs := state.References(artifact); len(refs) != 0 {
+		t.Errorf("expected no references, got %v", refs)
+	}
+}
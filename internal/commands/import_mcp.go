@@ -0,0 +1,289 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+// mcpImportSource is an existing MCP config file that can be imported
+type mcpImportSource struct {
+	Label string // agent or file name shown in the report
+	Path  string
+	Agent string // format hint: copilot, claude, cursor, gemini, qdev, codex
+}
+
+// mcpImportSources returns the known MCP config locations, in import priority order
+func (s *Session) mcpImportSources(projectDir string) []mcpImportSource {
+	sources := []mcpImportSource{
+		{Label: "VS Code (.vscode/mcp.json)", Path: filepath.Join(projectDir, ".vscode", "mcp.json"), Agent: "copilot"},
+		{Label: "Claude Code (.mcp.json)", Path: filepath.Join(projectDir, ".mcp.json"), Agent: "claude"},
+		{Label: "Cursor (.cursor/mcp.json)", Path: filepath.Join(projectDir, ".cursor", "mcp.json"), Agent: "cursor"},
+		{Label: "Gemini CLI (.gemini/settings.json)", Path: filepath.Join(projectDir, ".gemini", "settings.json"), Agent: "gemini"},
+		{Label: "Amazon Q (.amazonq/mcp.json)", Path: filepath.Join(projectDir, ".amazonq", "mcp.json"), Agent: "qdev"},
+	}
+	if homeDir, err := s.Env.UserHomeDir(); err == nil {
+		sources = append(sources, mcpImportSource{Label: "Codex (~/.codex/config.toml)", Path: filepath.Join(homeDir, ".codex", "config.toml"), Agent: "codex"})
+	}
+	return sources
+}
+
+// resolveMCPImportSources turns --from (an agent name or a file path) into sources
+func (s *Session) resolveMCPImportSources(projectDir, from string) ([]mcpImportSource, error) {
+	all := s.mcpImportSources(projectDir)
+	if from == "" {
+		return all, nil
+	}
+	agent := from
+	if agent == "vscode" {
+		agent = "copilot"
+	}
+	for _, source := range all {
+		if source.Agent == agent {
+			return []mcpImportSource{source}, nil
+		}
+	}
+	if _, err := s.Env.FS.Stat(from); err != nil {
+		return nil, fmt.Errorf("--from must be an agent (copilot, vscode, claude, cursor, gemini, qdev, codex) or an existing file: %s", from)
+	}
+	src := mcpImportSource{Label: from, Path: from}
+	switch {
+	case strings.HasSuffix(from, ".toml"):
+		src.Agent = "codex"
+	case filepath.Base(from) == "settings.json" && filepath.Base(filepath.Dir(from)) == ".gemini":
+		src.Agent = "gemini"
+	}
+	return []mcpImportSource{src}, nil
+}
+
+// parseMCPImportSource reads the servers defined in a source file
+func (s *Session) parseMCPImportSource(src mcpImportSource) (map[string]config.MCPServer, error) {
+	b, err := s.Env.FS.ReadFile(src.Path)
+	if err != nil {
+		return nil, err
+	}
+	servers := map[string]config.MCPServer{}
+	if src.Agent == "codex" {
+		doc, err := decodeToml(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", src.Path, err)
+		}
+		tables, _ := doc["mcp_servers"].(map[string]any)
+		for name, t := range tables {
+			if entry, ok := t.(map[string]any); ok {
+				servers[name] = mcpServerFromEntry(entry, src.Agent)
+			}
+		}
+		return servers, nil
+	}
+	obj, err := s.readJSONObject(src.Path)
+	if err != nil {
+		return nil, err
+	}
+	// VS Code uses "servers"; every other client (and older VS Code files) uses "mcpServers"
+	for _, key := range []string{"mcpServers", "servers"} {
+		entries, _ := obj[key].(map[string]any)
+		for name, e := range entries {
+			if entry, ok := e.(map[string]any); ok {
+				servers[name] = mcpServerFromEntry(entry, src.Agent)
+			}
+		}
+	}
+	return servers, nil
+}
+
+// mcpServerFromEntry converts one JSON/TOML server entry of any supported client
+func mcpServerFromEntry(entry map[string]any, agent string) config.MCPServer {
+	s := config.MCPServer{
+		Command:  stringField(entry, "command"),
+		Args:     stringList(entry["args"]),
+		Env:      stringMap(entry["env"]),
+		Cwd:      stringField(entry, "cwd"),
+		Headers:  stringMap(entry["headers"]),
+		Disabled: entry["disabled"] == true || entry["enabled"] == false,
+	}
+	if h := stringMap(entry["http_headers"]); h != nil { // Codex
+		s.Headers = h
+	}
+	s.StartupTimeout = secondsField(entry, "startup_timeout_sec", "startup_timeout_ms") // Codex
+	s.ToolTimeout = secondsField(entry, "tool_timeout_sec", "timeout")                  // Codex, Gemini (ms)
+	switch typ := stringField(entry, "type"); {
+	case stringField(entry, "httpUrl") != "": // Gemini streamable HTTP
+		s.URL = stringField(entry, "httpUrl")
+		s.Transport = config.MCPTransportHTTP
+	case stringField(entry, "url") != "" || stringField(entry, "serverUrl") != "":
+		s.URL = stringField(entry, "url")
+		if s.URL == "" {
+			s.URL = stringField(entry, "serverUrl")
+		}
+		switch {
+		case typ == config.MCPTransportSSE || (typ == "" && agent == "gemini"):
+			s.Transport = config.MCPTransportSSE
+		default:
+			s.Transport = config.MCPTransportHTTP
+		}
+	}
+	return s.Normalize()
+}
+
+func stringField(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// secondsField reads a timeout given in seconds (secKey) or milliseconds (msKey), rounding up
+func secondsField(m map[string]any, secKey, msKey string) int {
+	toFloat := func(v any) float64 {
+		switch n := v.(type) {
+		case int64:
+			return float64(n)
+		case float64:
+			return n
+		}
+		return 0
+	}
+	if sec := toFloat(m[secKey]); sec > 0 {
+		return int(math.Ceil(sec))
+	}
+	if ms := toFloat(m[msKey]); ms > 0 {
+		return int(math.Ceil(ms / 1000))
+	}
+	return 0
+}
+
+func stringList(v any) []string {
+	items, _ := v.([]any)
+	var out []string
+	for _, item := range items {
+		out = append(out, fmt.Sprint(item))
+	}
+	return out
+}
+
+func stringMap(v any) map[string]string {
+	m, _ := v.(map[string]any)
+	if len(m) == 0 {
+		return nil
+	}
+	out := map[string]string{}
+	for k, val := range m {
+		out[k] = fmt.Sprint(val)
+	}
+	return out
+}
+
+// RunImportMCP imports MCP servers from existing agent config files into the project config,
+// deduplicating by name, then regenerates the enabled agents' MCP files.
+// Conflicting definitions keep the existing one unless force is set.
+func (s *Session) RunImportMCP(projectDir, from string, dryRun, force bool) error {
+	// Resolve project directory
+	if projectDir == "" {
+		var err error
+		projectDir, err = s.Env.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
+	}
+
+	// Must be initialized (AGENTS.md present)
+	if _, err := s.Env.FS.Stat(filepath.Join(projectDir, "AGENTS.md")); os.IsNotExist(err) {
+		return ErrNotInitialized
+	}
+
+	sources, err := s.resolveMCPImportSources(projectDir, from)
+	if err != nil {
+		return err
+	}
+
+	cfgPath := config.GetProjectConfigPath(projectDir)
+	cfg, err := config.LoadProjectConfig(s.Env, cfgPath)
+	if err != nil {
+		return fmt.Errorf("failed to load project config: %w", err)
+	}
+
+	s.Logger.Infof("Importing MCP servers into project: %s\n\n", projectDir)
+
+	origin := map[string]string{} // server name -> source label it was taken from in this run
+	imported, unchanged, conflicts := 0, 0, 0
+	for _, src := range sources {
+		servers, err := s.parseMCPImportSource(src)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			s.warnf("Skipping %s: %v", src.Label, err)
+			continue
+		}
+		if len(servers) == 0 {
+			continue
+		}
+		s.Logger.Infof("📥 %s: %d server(s)\n", src.Label, len(servers))
+
+		names := make([]string, 0, len(servers))
+		for name := range servers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			server := servers[name]
+			if err := server.Validate(); err != nil {
+				s.warnf("%s: skipped (%v)", name, err)
+				continue
+			}
+			existing, exists := cfg.MCPServers[name]
+			switch {
+			case !exists:
+				cfg.MCPServers[name] = server
+				origin[name] = src.Label
+				imported++
+				s.Logger.Infof("   ➕ %s: %s\n", name, describeMCPServer(server))
+			case reflect.DeepEqual(existing.Normalize(), server):
+				unchanged++
+			case origin[name] != "":
+				// Two sources disagree within this import; the earlier source wins
+				conflicts++
+				s.warnf("%s: conflicts with the definition from %s (kept): %s", name, origin[name], describeMCPServer(server))
+			case force:
+				cfg.MCPServers[name] = server
+				origin[name] = src.Label
+				imported++
+				s.Logger.Infof("   🔁 %s: replaced existing definition: %s\n", name, describeMCPServer(server))
+			default:
+				conflicts++
+				origin[name] = "the project config"
+				s.warnf("%s: differs from the project config (kept; use --force to replace)\n      existing: %s\n      imported: %s",
+					name, describeMCPServer(existing), describeMCPServer(server))
+			}
+		}
+	}
+
+	s.Logger.Infof("\nSummary: %d imported, %d already configured, %d conflict(s)\n", imported, unchanged, conflicts)
+	if imported == 0 {
+		return nil
+	}
+
+	if dryRun {
+		s.dryRunf("record", "", "Would record %d MCP server(s) in .anyagent/config.yaml", imported)
+		return nil
+	}
+	if err := cfg.Save(s.Env, cfgPath); err != nil {
+		return fmt.Errorf("failed to save project config: %w", err)
+	}
+	if err := s.writeOrUpdateProjectMCP(projectDir, cfg.MCPServers, dryRun); err != nil {
+		return err
+	}
+	if err := s.ensureMCPFilesForEnabledAgents(projectDir, dryRun); err != nil {
+		return err
+	}
+	s.Logger.Infof("✅ Imported %d MCP server(s)\n", imported)
+	return nil
+}
@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunImportMCP(t *testing.T) {
+	s := newTestSession()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "AGENTS.md"), "# AGENTS")
+	if err := config.SaveProjectConfig(s.Env, dir, &config.ProjectConfig{
+		EnabledAgents: []string{"claude"},
+		MCPServers:    map[string]config.MCPServer{"kept": {Command: "kept-server"}},
+	}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	writeTestFile(t, filepath.Join(dir, ".vscode", "mcp.json"), `{
+  "inputs": [{"id": "token", "type": "promptString"}],
+  "servers": {
+    "postgres": {"type": "stdio", "command": "npx", "args": ["-y", "server-postgres"], "env": {"DATABASE_URL": "postgresql://localhost/db"}},
+    "remote": {"type": "sse", "url": "https://example.com/sse", "headers": {"Authorization": "Bearer ${input:token}"}}
+  }
+}`)
+	// Same postgres definition (deduplicated) and a conflicting one for kept
+	writeTestFile(t, filepath.Join(dir, ".cursor", "mcp.json"), `{"mcpServers": {
+  "postgres": {"command": "npx", "args": ["-y", "server-postgres"], "env": {"DATABASE_URL": "postgresql://localhost/db"}},
+  "kept": {"command": "other-server"}
+}}`)
+	writeTestFile(t, filepath.Join(home, ".codex", "config.toml"), `model = "o3"
+
+[mcp_servers.files]
+command = "node"
+args = ["server.js", "--root", "/path with spaces"]
+cwd = "tools"
+startup_timeout_sec = 20
+tool_timeout_sec = 90.5
+
+[mcp_servers.files.env]
+LOG_LEVEL = "debug"
+`)
+
+	if err := s.RunImportMCP(dir, "", false, false); err != nil {
+		t.Fatalf("RunImportMCP failed: %v", err)
+	}
+
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]config.MCPServer{
+		"kept": {Command: "kept-server"},
+		"postgres": {
+			Command: "npx",
+			Args:    []string{"-y", "server-postgres"},
+			Env:     map[string]string{"DATABASE_URL": "postgresql://localhost/db"},
+		},
+		"remote": {
+			Transport: config.MCPTransportSSE,
+			URL:       "https://example.com/sse",
+			Headers:   map[string]string{"Authorization": "Bearer ${input:token}"},
+		},
+		"files": {
+			Command:        "node",
+			Args:           []string{"server.js", "--root", "/path with spaces"},
+			Env:            map[string]string{"LOG_LEVEL": "debug"},
+			Cwd:            "tools",
+			StartupTimeout: 20,
+			ToolTimeout:    91,
+		},
+	}
+	if !reflect.DeepEqual(cfg.MCPServers, want) {
+		t.Errorf("imported servers mismatch\n got: %+v\nwant: %+v", cfg.MCPServers, want)
+	}
+
+	// Enabled agent file regenerated
+	names, err := s.installedMCPServerNames("claude", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name := range want {
+		if !names[name] {
+			t.Errorf(".mcp.json missing %s", name)
+		}
+	}
+
+	// --force replaces conflicting definitions from the chosen source
+	if err := s.RunImportMCP(dir, "cursor", false, true); err != nil {
+		t.Fatalf("RunImportMCP --force failed: %v", err)
+	}
+	cfg, _ = config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(dir))
+	if cfg.MCPServers["kept"].Command != "other-server" {
+		t.Errorf("--force should replace kept: %+v", cfg.MCPServers["kept"])
+	}
+
+	if err := s.RunImportMCP(dir, "nonexistent-agent", false, false); err == nil {
+		t.Error("unknown --from should fail")
+	}
+}
+
+func TestRunImportMCPWithoutProjectConfig(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "AGENTS.md"), "# AGENTS")
+	writeTestFile(t, filepath.Join(dir, ".cursor", "mcp.json"), `{"mcpServers": {"files": {"command": "node", "args": ["server.js"]}}}`)
+
+	if err := s.RunImportMCP(dir, "cursor", false, false); err != nil {
+		t.Fatalf("RunImportMCP failed: %v", err)
+	}
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.MCPServers["files"]; got.Command != "node" {
+		t.Errorf("files not imported: %+v", cfg.MCPServers)
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 // TestEditTemplateCommand tests the edit-template command functionality
 func TestEditTemplateCommand(t *testing.T) {
+	s := newTestSession()
 	tempDir := t.TempDir()
 	testConfigDir := filepath.Join(tempDir, "anyagent")
 
@@ -44,7 +45,7 @@ func TestEditTemplateCommand(t *testing.T) {
 				}
 			}
 
-			err := RunEditTemplate(tt.configDir, true, false) // dryRun = true, hardReset = false for testing
+			err := s.RunEditTemplate(tt.configDir, false)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("RunEditTemplate() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -58,14 +59,19 @@ func TestEditTemplateCommand(t *testing.T) {
 	}
 }
 
-// TestEditTemplateWithoutVSCode tests the edit-template command without VSCode launch
+// TestEditTemplateWithoutVSCode tests that the edit-template command only sets up the templates
 func TestEditTemplateWithoutVSCode(t *testing.T) {
 	tempDir := t.TempDir()
 	testConfigDir := filepath.Join(tempDir, "anyagent")
 
-	err := RunEditTemplate(testConfigDir, true, false) // dryRun = true, hardReset = false
+	report, err := RunSession(*newTestSession(), func(s *Session) error {
+		return s.RunEditTemplate(testConfigDir, false)
+	})
 	if err != nil {
-		t.Errorf("RunEditTemplate() with dryRun failed: %v", err)
+		t.Errorf("RunEditTemplate() failed: %v", err)
+	}
+	if env, ok := report.Data.(*TemplateEnvironment); !ok || env.ConfigDir != testConfigDir || len(report.Operations) != 0 {
+		t.Errorf("unexpected report: %+v", report)
 	}
 
 	// Verify all necessary components were created
@@ -74,6 +80,7 @@ func TestEditTemplateWithoutVSCode(t *testing.T) {
 
 // TestEditTemplateHardReset tests the edit-template command with hard reset
 func TestEditTemplateHardReset(t *testing.T) {
+	s := newTestSession()
 	tempDir := t.TempDir()
 	testConfigDir := filepath.Join(tempDir, "anyagent")
 
@@ -92,7 +99,7 @@ func TestEditTemplateHardReset(t *testing.T) {
 	}
 
 	// Perform hard reset
-	err = RunEditTemplate(testConfigDir, true, true) // dryRun = true, hardReset = true
+	err = s.RunEditTemplate(testConfigDir, true)
 	if err != nil {
 		t.Errorf("RunEditTemplate() with hard reset failed: %v", err)
 	}
@@ -114,6 +121,7 @@ func TestEditTemplateHardReset(t *testing.T) {
 
 // TestValidateTemplateEnvironment tests template environment validation
 func TestValidateTemplateEnvironment(t *testing.T) {
+	s := newTestSession()
 	tempDir := t.TempDir()
 
 	tests := []struct {
@@ -133,7 +141,7 @@ func TestValidateTemplateEnvironment(t *testing.T) {
 		{
 			name: "missing template files",
 			setupFunc: func(dir string) error {
-				return config.CreateUserConfigDir(dir) // Only create directory
+				return config.CreateUserConfigDir(s.Env, dir) // Only create directory
 			},
 			configDir: filepath.Join(tempDir, "incomplete"),
 			wantValid: false,
@@ -155,7 +163,7 @@ func TestValidateTemplateEnvironment(t *testing.T) {
 				t.Fatalf("Setup failed: %v", err)
 			}
 
-			valid := ValidateTemplateEnvironment(tt.configDir)
+			valid := s.ValidateTemplateEnvironment(tt.configDir)
 			if valid != tt.wantValid {
 				t.Errorf("ValidateTemplateEnvironment() = %v, want %v", valid, tt.wantValid)
 			}
@@ -163,45 +171,11 @@ func TestValidateTemplateEnvironment(t *testing.T) {
 	}
 }
 
-// TestLaunchVSCode tests VSCode launching functionality (dry run)
-func TestLaunchVSCode(t *testing.T) {
-	tempDir := t.TempDir()
-	testConfigDir := filepath.Join(tempDir, "anyagent")
-
-	// Setup a complete configuration
-	err := setupCompleteConfig(testConfigDir)
-	if err != nil {
-		t.Fatalf("Failed to setup config: %v", err)
-	}
-
-	tests := []struct {
-		name      string
-		configDir string
-		dryRun    bool
-		wantErr   bool
-	}{
-		{
-			name:      "launch VSCode dry run",
-			configDir: testConfigDir,
-			dryRun:    true,
-			wantErr:   false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := LaunchVSCode(tt.configDir, tt.dryRun)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("LaunchVSCode() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
-}
-
 // --- test helpers -----------------------------------------------------------
 
 func setupExistingConfig(dir string) error {
-	if err := config.CreateUserConfigDir(dir); err != nil {
+	s := newTestSession()
+	if err := config.CreateUserConfigDir(s.Env, dir); err != nil {
 		return err
 	}
 	// Leave the rest incomplete to trigger update path
@@ -209,24 +183,26 @@ func setupExistingConfig(dir string) error {
 }
 
 func setupCompleteConfig(dir string) error {
-	if err := config.CreateUserConfigDir(dir); err != nil {
+	s := newTestSession()
+	if err := config.CreateUserConfigDir(s.Env, dir); err != nil {
 		return err
 	}
-	if err := config.CreateTemplateStructure(dir); err != nil {
+	if err := config.CreateTemplateStructure(s.Env, dir); err != nil {
 		return err
 	}
-	if err := config.CreateTemplateFiles(dir); err != nil {
+	if err := config.CreateTemplateFiles(s.Env, dir); err != nil {
 		return err
 	}
-	if err := config.CreateAnyagentProject(dir); err != nil {
+	if err := config.CreateAnyagentProject(s.Env, dir); err != nil {
 		return err
 	}
 	return nil
 }
 
 func verifyTemplateEnvironment(t *testing.T, dir string) {
+	s := newTestSession()
 	t.Helper()
-	if !ValidateTemplateEnvironment(dir) {
+	if !s.ValidateTemplateEnvironment(dir) {
 		t.Fatalf("template environment is not valid at %s", dir)
 	}
 }
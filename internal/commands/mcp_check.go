@@ -0,0 +1,343 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/internal/fsys"
+)
+
+// mcpProtocolVersion is the protocol revision sent in the initialize request
+const mcpProtocolVersion = "2025-06-18"
+
+// MCPCheckResult is the outcome of probing one MCP server
+type MCPCheckResult struct {
+	Name            string
+	ServerName      string
+	ServerVersion   string
+	ProtocolVersion string
+	ToolCount       int
+	Latency         time.Duration // time until the initialize response arrived
+	Skipped         string        // reason the server was not probed (remote, disabled)
+	Err             error
+}
+
+// jsonrpcMessage is the subset of a JSON-RPC 2.0 message the check needs
+type jsonrpcMessage struct {
+	ID     *int            `json:"id,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// stdioSession exchanges newline-delimited JSON-RPC messages with a launched server
+type stdioSession struct {
+	stdin  io.WriteCloser
+	lines  chan []byte
+	exited chan error
+	stderr *bytes.Buffer
+	nextID int
+}
+
+// checkMCPServer launches a stdio server, performs the initialize handshake and lists its tools
+func checkMCPServer(ctx context.Context, env *fsys.Env, name string, s config.MCPServer, projectDir string, timeout time.Duration) MCPCheckResult {
+	result := MCPCheckResult{Name: name}
+	if s.Disabled {
+		result.Skipped = "disabled"
+		return result
+	}
+	if s.IsRemote() {
+		result.Skipped = fmt.Sprintf("%s server (only stdio servers are checked)", s.TransportType())
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Resolve ${env:NAME}/${input:name} the way an agent would before launching
+	var missing []string
+	args := make([]string, len(s.Args))
+	for i, a := range s.Args {
+		var m []string
+		args[i], m = expandMCPValue(a, env.LookupEnv)
+		missing = append(missing, m...)
+	}
+	vars := append([]string{}, env.Environ()...)
+	for k, v := range s.Env {
+		v, m := expandMCPValue(v, env.LookupEnv)
+		missing = append(missing, m...)
+		vars = append(vars, k+"="+v)
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		result.Err = fmt.Errorf("missing environment variable(s): %s", strings.Join(missing, ", "))
+		return result
+	}
+
+	cmd := exec.CommandContext(ctx, s.Command, args...)
+	cmd.Env = vars
+	cmd.Dir = projectDir
+	// Do not let Wait hang on pipes still held by processes the server started
+	cmd.WaitDelay = time.Second
+	if s.Cwd != "" {
+		cmd.Dir = s.Cwd
+		if !filepath.IsAbs(s.Cwd) {
+			cmd.Dir = filepath.Join(projectDir, s.Cwd)
+		}
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	session := &stdioSession{
+		stdin:  stdin,
+		lines:  make(chan []byte),
+		exited: make(chan error, 1),
+		stderr: &bytes.Buffer{},
+	}
+	cmd.Stderr = session.stderr
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			result.Err = fmt.Errorf("command not found: %s", s.Command)
+		} else {
+			result.Err = fmt.Errorf("failed to start %s: %w", s.Command, err)
+		}
+		return result
+	}
+	// The reader always ends with cmd.Wait, which releases the process and its pipes; on return
+	// the server is killed and the reader is waited for
+	stop, waited := make(chan struct{}), make(chan struct{})
+	defer func() {
+		close(stop)
+		_ = stdin.Close()
+		_ = cmd.Process.Kill()
+		<-waited
+	}()
+
+	go func() {
+		defer close(waited)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	read:
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case session.lines <- line:
+			case <-ctx.Done():
+				break read
+			case <-stop:
+				break read
+			}
+		}
+		session.exited <- cmd.Wait()
+	}()
+
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+	}
+	err = session.call(ctx, "initialize", map[string]any{
+		"protocolVersion": mcpProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": "anyagent", "version": "dev"},
+	}, &init, timeout)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Latency = time.Since(start)
+	result.ProtocolVersion = init.ProtocolVersion
+	result.ServerName = init.ServerInfo.Name
+	result.ServerVersion = init.ServerInfo.Version
+
+	if err := session.notify("notifications/initialized"); err != nil {
+		result.Err = err
+		return result
+	}
+
+	// tools/list is paginated through nextCursor
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var page struct {
+			Tools      []json.RawMessage `json:"tools"`
+			NextCursor string            `json:"nextCursor"`
+		}
+		if err := session.call(ctx, "tools/list", params, &page, timeout); err != nil {
+			result.Err = err
+			return result
+		}
+		result.ToolCount += len(page.Tools)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	return result
+}
+
+// notify sends a JSON-RPC notification
+func (s *stdioSession) notify(method string) error {
+	return s.write(map[string]any{"jsonrpc": "2.0", "method": method})
+}
+
+// call sends a request and waits for the response with the same id, skipping
+// notifications and server-initiated requests in between.
+func (s *stdioSession) call(ctx context.Context, method string, params any, out any, timeout time.Duration) error {
+	s.nextID++
+	id := s.nextID
+	if err := s.write(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case line := <-s.lines:
+			var msg jsonrpcMessage
+			if err := json.Unmarshal(line, &msg); err != nil {
+				return fmt.Errorf("invalid JSON-RPC message from server: %s", truncate(string(line), 200))
+			}
+			if msg.ID == nil || *msg.ID != id || (msg.Result == nil && msg.Error == nil) {
+				continue
+			}
+			if msg.Error != nil {
+				return fmt.Errorf("%s failed: %s (code %d)", method, msg.Error.Message, msg.Error.Code)
+			}
+			if err := json.Unmarshal(msg.Result, out); err != nil {
+				return fmt.Errorf("invalid %s result: %w", method, err)
+			}
+			return nil
+		case err := <-s.exited:
+			if ctx.Err() != nil {
+				// Killed by the deadline or the caller rather than crashed on its own
+				return waitError(ctx, method, timeout)
+			}
+			detail := "exited"
+			if err != nil {
+				detail = err.Error()
+			}
+			if tail := strings.TrimSpace(s.stderr.String()); tail != "" {
+				return fmt.Errorf("server crashed during %s (%s): %s", method, detail, truncate(tail, 500))
+			}
+			return fmt.Errorf("server crashed during %s (%s)", method, detail)
+		case <-ctx.Done():
+			return waitError(ctx, method, timeout)
+		}
+	}
+}
+
+// waitError tells the per-server timeout from the caller cancelling the check
+func waitError(ctx context.Context, method string, timeout time.Duration) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s waiting for %s", timeout, method)
+	}
+	return fmt.Errorf("cancelled while waiting for %s: %w", method, ctx.Err())
+}
+
+func (s *stdioSession) write(msg map[string]any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := s.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write to server: %w", err)
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes, keeping the end (the most recent output)
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return "…" + s[len(s)-n:]
+}
+
+// RunMCPCheck probes the configured MCP servers (all, or only the given names) and prints a report.
+// It fails when any probed server fails. Cancelling ctx stops the running server and the check.
+func (s *Session) RunMCPCheck(ctx context.Context, projectDir string, names []string, timeout time.Duration) error {
+	// Resolve project directory
+	if projectDir == "" {
+		var err error
+		projectDir, err = s.Env.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
+	}
+
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
+	if err != nil {
+		return fmt.Errorf("failed to load project config: %w", err)
+	}
+	if len(names) == 0 {
+		for name := range cfg.MCPServers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		s.Logger.Println("No MCP servers configured.")
+		return nil
+	}
+
+	s.Logger.Printf("Checking %d MCP server(s)...\n\n", len(names))
+	failed := 0
+	for _, name := range names {
+		server, ok := cfg.MCPServers[name]
+		if !ok {
+			s.Logger.Printf("  ❌ %s: not configured\n", name)
+			failed++
+			continue
+		}
+		r := checkMCPServer(ctx, s.Env, name, server, projectDir, timeout)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		switch {
+		case r.Skipped != "":
+			s.Logger.Printf("  ⏭️  %s: skipped (%s)\n", name, r.Skipped)
+		case r.Err != nil:
+			s.Logger.Printf("  ❌ %s: %v\n", name, r.Err)
+			failed++
+		default:
+			s.Logger.Printf("  ✅ %s: %s %s, protocol %s, %d tools, %s\n",
+				name, r.ServerName, r.ServerVersion, r.ProtocolVersion, r.ToolCount, r.Latency.Round(time.Millisecond))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d MCP server(s) failed the check", failed, len(names))
+	}
+	s.Logger.Printf("\n✅ All MCP servers responded\n")
+	return nil
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/internal/fsys"
+)
+
+// TestHelperMCPServer is not a real test: it is the stand-in MCP server launched by the
+// check tests (helper-process pattern). MCP_HELPER_MODE selects its behaviour.
+func TestHelperMCPServer(t *testing.T) {
+	mode := os.Getenv("MCP_HELPER_MODE")
+	if mode == "" {
+		return
+	}
+	defer os.Exit(0)
+
+	if mode == "crash" {
+		fmt.Fprintln(os.Stderr, "panic: missing DATABASE_URL")
+		os.Exit(3)
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	out := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		var req struct {
+			ID     *int   `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Cursor string `json:"cursor"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil || req.ID == nil {
+			continue
+		}
+		if mode == "hang" {
+			continue
+		}
+		var result any
+		switch req.Method {
+		case "initialize":
+			// Log noise before the response must be skipped
+			_ = out.Encode(map[string]any{"jsonrpc": "2.0", "method": "notifications/message", "params": map[string]any{"level": "info"}})
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "helper", "version": "1.2.3"},
+			}
+		case "tools/list":
+			// Two pages: 2 tools, then 1
+			if req.Params.Cursor == "" {
+				result = map[string]any{"tools": []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}}, "nextCursor": "p2"}
+			} else {
+				result = map[string]any{"tools": []any{map[string]any{"name": "c"}}}
+			}
+		}
+		_ = out.Encode(map[string]any{"jsonrpc": "2.0", "id": *req.ID, "result": result})
+	}
+}
+
+func helperMCPServer(mode string) config.MCPServer {
+	return config.MCPServer{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestHelperMCPServer$"},
+		Env:     map[string]string{"MCP_HELPER_MODE": mode},
+	}
+}
+
+func TestCheckMCPServer(t *testing.T) {
+	dir := t.TempDir()
+
+	r := checkMCPServer(context.Background(), fsys.Default(), "ok", helperMCPServer("ok"), dir, 10*time.Second)
+	if r.Err != nil {
+		t.Fatalf("healthy server failed: %v", r.Err)
+	}
+	if r.ServerName != "helper" || r.ServerVersion != "1.2.3" || r.ProtocolVersion != "2025-06-18" || r.ToolCount != 3 {
+		t.Errorf("unexpected result: %+v", r)
+	}
+	if r.Latency <= 0 {
+		t.Errorf("latency not measured: %v", r.Latency)
+	}
+
+	r = checkMCPServer(context.Background(), fsys.Default(), "crash", helperMCPServer("crash"), dir, 10*time.Second)
+	if r.Err == nil || !strings.Contains(r.Err.Error(), "crashed") || !strings.Contains(r.Err.Error(), "missing DATABASE_URL") {
+		t.Errorf("expected crash with stderr, got %v", r.Err)
+	}
+
+	r = checkMCPServer(context.Background(), fsys.Default(), "hang", helperMCPServer("hang"), dir, 300*time.Millisecond)
+	if r.Err == nil || !strings.Contains(r.Err.Error(), "timed out") {
+		t.Errorf("expected timeout, got %v", r.Err)
+	}
+
+	r = checkMCPServer(context.Background(), fsys.Default(), "missing", config.MCPServer{Command: "anyagent-no-such-mcp-server"}, dir, time.Second)
+	if r.Err == nil || !strings.Contains(r.Err.Error(), "command not found") {
+		t.Errorf("expected command not found, got %v", r.Err)
+	}
+
+	r = checkMCPServer(context.Background(), fsys.Default(), "remote", config.MCPServer{URL: "https://example.com/mcp"}, dir, time.Second)
+	if r.Skipped == "" {
+		t.Errorf("remote servers should be skipped")
+	}
+}
+
+func TestRunMCPCheckReportsFailures(t *testing.T) {
+	s := newTestSession()
+	dir := t.TempDir()
+	cfg := &config.ProjectConfig{MCPServers: map[string]config.MCPServer{
+		"good": helperMCPServer("ok"),
+		"bad":  helperMCPServer("crash"),
+	}}
+	if err := config.SaveProjectConfig(s.Env, dir, cfg); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	if err := s.RunMCPCheck(context.Background(), dir, []string{"good"}, 10*time.Second); err != nil {
+		t.Errorf("check of healthy server failed: %v", err)
+	}
+	if err := s.RunMCPCheck(context.Background(), dir, nil, 10*time.Second); err == nil {
+		t.Error("expected failure when a server crashes")
+	}
+}
+
+func TestRunMCPCheckStopsOnCancel(t *testing.T) {
+	s := newTestSession()
+	dir := t.TempDir()
+	cfg := &config.ProjectConfig{MCPServers: map[string]config.MCPServer{"hang": helperMCPServer("hang")}}
+	if err := config.SaveProjectConfig(s.Env, dir, cfg); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := s.RunMCPCheck(ctx, dir, nil, time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the caller's deadline, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("check ran for %s after ctx was done", elapsed)
+	}
+}
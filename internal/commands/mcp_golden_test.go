@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files under testdata")
+
+// goldenMCPServers covers a stdio server with env/cwd plus streamable HTTP and SSE remotes
+var goldenMCPServers = map[string]config.MCPServer{
+	"postgres": {
+		Command: "npx",
+		Args:    []string{"-y", "@modelcontextprotocol/server-postgres"},
+		Env:     map[string]string{"DATABASE_URL": "postgresql://localhost/mydb"},
+		Cwd:     "tools",
+	},
+	"remote": {
+		URL:     "https://mcp.example.com/mcp",
+		Headers: map[string]string{"X-Team": "platform"},
+	},
+	"events": {Transport: config.MCPTransportSSE, URL: "https://mcp.example.com/sse"},
+	"paused": {Command: "paused-server", Disabled: true},
+}
+
+func TestEnsureMCPFilesForAgentGolden(t *testing.T) {
+	s := newTestSession()
+	tests := []struct {
+		agent    string
+		existing string // pre-existing file content, merged rather than overwritten
+		golden   string
+	}{
+		{
+			agent:    "copilot",
+			existing: `{"inputs": [{"id": "token", "type": "promptString", "password": true}], "servers": {"mine": {"type": "stdio", "command": "my-server"}}, "mcpServers": {"postgres": {"command": "old"}}}`,
+			golden:   "copilot.mcp.json",
+		},
+		{agent: "claude", golden: "claude.mcp.json"},
+		{
+			agent:    "gemini",
+			existing: `{"theme": "Dracula", "mcpServers": {"mine": {"command": "my-server"}}}`,
+			golden:   "gemini.settings.json",
+		},
+		{agent: "qdev", golden: "qdev.mcp.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.agent, func(t *testing.T) {
+			dir := t.TempDir()
+			path, _ := s.mcpConfigPath(tt.agent, dir)
+			if tt.existing != "" {
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if legacy := legacyMCPYAMLPath(tt.agent, dir); legacy != "" {
+				if err := os.MkdirAll(filepath.Dir(legacy), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(legacy, []byte("servers: {}\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := s.ensureMCPFilesForAgent(tt.agent, dir, goldenMCPServers, false); err != nil {
+				t.Fatalf("ensureMCPFilesForAgent failed: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("MCP config not written: %v", err)
+			}
+
+			goldenPath := filepath.Join("testdata", "mcp", tt.golden)
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("failed to read golden file (run with -update): %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("%s mismatch\n--- got ---\n%s\n--- want ---\n%s", tt.golden, got, want)
+			}
+
+			if legacy := legacyMCPYAMLPath(tt.agent, dir); legacy != "" {
+				if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+					t.Errorf("legacy %s should be removed", legacy)
+				}
+			}
+		})
+	}
+}
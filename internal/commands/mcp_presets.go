@@ -0,0 +1,208 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+// presetMCPServer builds a server from a catalog preset. Values given with --env are used as is;
+// other required variables are prompted for, with secrets defaulting to ${env:NAME}.
+func (s *Session) presetMCPServer(params AddMCPParams, projectDir string) (config.MCPServer, error) {
+	if params.Cmd != "" || params.URL != "" {
+		return config.MCPServer{}, fmt.Errorf("--preset cannot be combined with --cmd or --url")
+	}
+	preset, err := config.GetMCPPreset(s.Env, projectDir, params.Preset)
+	if err != nil {
+		return config.MCPServer{}, err
+	}
+	given, err := ParseKeyValues(params.Env, "--env")
+	if err != nil {
+		return config.MCPServer{}, err
+	}
+	env, err := s.resolvePresetEnv(preset, given, params.DryRun)
+	if err != nil {
+		return config.MCPServer{}, err
+	}
+	server := preset.Server(env)
+	if !params.Disabled {
+		s.warnUnrecommendedAgents(preset, params, projectDir)
+	}
+	if params.Transport != "" {
+		server.Transport = params.Transport
+	}
+	if params.StartupTimeout > 0 {
+		server.StartupTimeout = params.StartupTimeout
+	}
+	if params.ToolTimeout > 0 {
+		server.ToolTimeout = params.ToolTimeout
+	}
+	server.Args = append(server.Args, params.Args...)
+	headers, err := ParseKeyValues(params.Headers, "--header")
+	if err != nil {
+		return config.MCPServer{}, err
+	}
+	for k, v := range headers {
+		if server.Headers == nil {
+			server.Headers = map[string]string{}
+		}
+		server.Headers[k] = v
+	}
+	return server, nil
+}
+
+// warnUnrecommendedAgents warns about the enabled agents the server is written for that the preset
+// is not recommended for. The recommendation does not restrict the server; --agent does.
+func (s *Session) warnUnrecommendedAgents(preset config.MCPPreset, params AddMCPParams, projectDir string) {
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
+	if err != nil {
+		return
+	}
+	for _, agent := range cfg.EnabledAgents {
+		if len(params.Agents) > 0 && !slices.Contains(params.Agents, agent) || slices.Contains(params.ExcludeAgents, agent) {
+			continue
+		}
+		if !preset.RecommendedFor(agent) {
+			s.warnf("Warning: Preset '%s' is recommended for %s, not %s; pass --agent to restrict it", preset.ID, strings.Join(preset.Agents, ", "), agentDisplayName(agent))
+		}
+	}
+}
+
+// resolvePresetEnv returns the environment for a preset: given values, then defaults, prompting
+// for required variables that have neither
+func (s *Session) resolvePresetEnv(preset config.MCPPreset, given map[string]string, dryRun bool) (map[string]string, error) {
+	env := map[string]string{}
+	for k, v := range given {
+		env[k] = v
+	}
+	interactive := !dryRun && s.canPrompt()
+	reader := s.reader()
+	var missing []string
+	for _, name := range preset.EnvNames() {
+		if _, ok := env[name]; ok {
+			continue
+		}
+		spec := preset.Env[name]
+		def := spec.Default
+		if def == "" && spec.Secret {
+			def = "${env:" + name + "}"
+		}
+		if !spec.Required {
+			if spec.Default != "" {
+				env[name] = spec.Default
+			}
+			continue
+		}
+		if !interactive {
+			if def == "" {
+				missing = append(missing, name)
+			} else {
+				env[name] = def
+			}
+			continue
+		}
+		v, err := s.promptPresetEnv(reader, name, spec.Description, def)
+		if err != nil {
+			return nil, err
+		}
+		if v == "" {
+			missing = append(missing, name)
+			continue
+		}
+		env[name] = v
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("preset '%s' requires %s; pass them with --env NAME=VALUE", preset.ID, strings.Join(missing, ", "))
+	}
+	return env, nil
+}
+
+// promptPresetEnv asks for one environment variable; an empty answer takes the default
+func (s *Session) promptPresetEnv(reader *bufio.Reader, name, description, def string) (string, error) {
+	prompt := name
+	if description != "" {
+		prompt += " (" + description + ")"
+	}
+	if def != "" {
+		prompt += " [" + def + "]"
+	}
+	s.Logger.Printf("Enter %s: ", prompt)
+	v, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if v = strings.TrimSpace(v); v == "" {
+		return def, nil
+	}
+	return v, nil
+}
+
+// MCPPresetInfo is a catalog entry, the JSON result of 'add mcp --list-presets'
+type MCPPresetInfo struct {
+	ID          string             `json:"id"`
+	Source      string             `json:"source"`
+	Description string             `json:"description,omitempty"`
+	Server      string             `json:"server"` // command line or transport and URL
+	Env         []MCPPresetEnvInfo `json:"env"`
+	Agents      []string           `json:"agents"` // recommended agents; empty means all
+}
+
+// MCPPresetEnvInfo is an environment variable a preset asks for
+type MCPPresetEnvInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required"`
+	Secret      bool   `json:"secret"`
+}
+
+// ListMCPPresets displays the MCP server catalog
+func (s *Session) ListMCPPresets(projectDir string) error {
+	presets, err := config.LoadMCPCatalog(s.Env, projectDir)
+	if err != nil {
+		return err
+	}
+	list := []MCPPresetInfo{}
+	for _, p := range presets {
+		info := MCPPresetInfo{ID: p.ID, Source: p.Source, Description: p.Description, Server: describeMCPServer(p.Server(nil)), Env: []MCPPresetEnvInfo{}, Agents: append([]string{}, p.Agents...)}
+		for _, name := range p.EnvNames() {
+			spec := p.Env[name]
+			info.Env = append(info.Env, MCPPresetEnvInfo{Name: name, Description: spec.Description, Default: spec.Default, Required: spec.Required, Secret: spec.Secret})
+		}
+		list = append(list, info)
+	}
+	s.setResultData(list)
+	if len(presets) == 0 {
+		s.Logger.Println("No MCP presets available.")
+		return nil
+	}
+	s.Logger.Println("Available MCP presets:")
+	for _, p := range presets {
+		s.Logger.Printf("  • %s (%s)", p.ID, p.Source)
+		if p.Description != "" {
+			s.Logger.Printf(": %s", p.Description)
+		}
+		s.Logger.Println()
+		s.Logger.Printf("      %s\n", describeMCPServer(p.Server(nil)))
+		for _, name := range p.EnvNames() {
+			spec := p.Env[name]
+			label := "optional"
+			if spec.Required {
+				label = "required"
+			}
+			if spec.Description != "" {
+				label += ", " + spec.Description
+			}
+			s.Logger.Printf("      env %s (%s)\n", name, label)
+		}
+		if len(p.Agents) > 0 {
+			s.Logger.Printf("      recommended for: %s\n", strings.Join(p.Agents, ", "))
+		}
+	}
+	s.Logger.Printf("\nUsage: anyagent add mcp --preset <id> [name]\n")
+	return nil
+}
@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Operation is a change a command makes, or would make with --dry-run. Action is one of
+// create, write, update, remove, symlink, mkdir, copy, record, unregister and launch.
+type Operation struct {
+	Action  string `json:"action"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// setResultData sets the command-specific part of the report
+func (s *Session) setResultData(v any) {
+	s.data = v
+}
+
+// warnf prints a warning and records it for the report
+func (s *Session) warnf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	s.Logger.Warnf("%s", msg)
+	s.warnings = append(s.warnings, strings.TrimPrefix(msg, "Warning: "))
+}
+
+// dryRunf prints a change skipped by --dry-run and records it as an operation
+func (s *Session) dryRunf(action, path, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	s.Logger.Printf("[DRY RUN] %s\n", msg)
+	s.operations = append(s.operations, Operation{Action: action, Path: path, Message: msg})
+}
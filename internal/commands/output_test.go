@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReport_ListRule(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := newSyncedTestProject(t, "api")
+	if err := s.RunAddRule("go", dir, false); err != nil {
+		t.Fatalf("RunAddRule failed: %v", err)
+	}
+
+	report, err := RunSession(*s, func(s *Session) error { return s.RunListRules(dir) })
+	if err != nil {
+		t.Fatalf("RunListRules failed: %v", err)
+	}
+	list, ok := report.Data.(*RuleList)
+	if !ok || !list.Initialized || list.Agent != "claude" || len(list.Rules) != len(SupportedRules) {
+		t.Fatalf("unexpected rule list: %+v", report.Data)
+	}
+	for _, r := range list.Rules {
+		if r.Installed != (r.Name == "go") {
+			t.Errorf("rule %s: installed = %v", r.Name, r.Installed)
+		}
+	}
+}
+
+func TestReport_SyncDryRun(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := newSyncedTestProject(t, "api")
+
+	report, err := RunSession(*s, func(s *Session) error { return s.RunSyncWithOptions(dir, []string{"copilot"}, true, false) })
+	if err != nil {
+		t.Fatalf("RunSyncWithOptions failed: %v", err)
+	}
+	sync, ok := report.Data.(*SyncResult)
+	if !ok || !sync.DryRun || len(sync.Agents) != 1 || sync.Agents[0] != "copilot" || len(sync.RemovedAgents) != 1 || sync.RemovedAgents[0] != "claude" {
+		t.Fatalf("unexpected sync result: %+v", report.Data)
+	}
+	found := false
+	for _, op := range report.Operations {
+		if op.Action == "remove" && op.Path == filepath.Join(dir, "CLAUDE.md") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the CLAUDE.md removal among the operations: %+v", report.Operations)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "CLAUDE.md")); err != nil {
+		t.Errorf("dry run must not remove CLAUDE.md: %v", err)
+	}
+}
+
+func TestReport_Error(t *testing.T) {
+	report, err := RunSession(Session{}, func(s *Session) error {
+		s.warnf("Warning: Could not load project registry: %v", errors.New("broken"))
+		return errors.New("project is not initialized")
+	})
+	if err == nil || err.Error() != "project is not initialized" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0] != "Could not load project registry: broken" {
+		t.Errorf("unexpected warnings: %v", report.Warnings)
+	}
+}
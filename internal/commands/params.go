@@ -1,10 +1,8 @@
 package commands
 
 import (
-	"bufio"
 	"fmt"
 	"io"
-	"os"
 	"sort"
 	"strings"
 
@@ -15,9 +13,9 @@ import (
 // AGENTS.md template have corresponding values in the project config. When run in
 // interactive mode (non-dry-run), it prompts the user for any missing values and
 // saves them to .anyagent/config.yaml. In dry-run, it only reports missing keys.
-func ensureTemplateParameters(projectDir string, cfg *config.ProjectConfig, dryRun bool) error {
+func (s *Session) ensureTemplateParameters(projectDir string, cfg *config.ProjectConfig, dryRun bool) error {
 	// Resolve effective template using unified precedence
-	tpl, _ := config.ResolveTemplateContent(projectDir, "AGENTS.md.tmpl", func() (string, error) {
+	tpl, _ := config.ResolveTemplateContent(s.Env, projectDir, "AGENTS.md.tmpl", func() (string, error) {
 		return config.GetAGENTSTemplate(), nil
 	})
 
@@ -55,19 +53,22 @@ func ensureTemplateParameters(projectDir string, cfg *config.ProjectConfig, dryR
 		return nil
 	}
 
-	// Detect interactivity (TTY-like stdin)
-	fi, _ := os.Stdin.Stat()
-	interactive := (fi.Mode() & os.ModeCharDevice) != 0
+	// Detect interactivity (TTY-like stdin, prompts not disabled by the caller)
+	interactive := s.canPrompt()
 
-	if dryRun || !interactive {
-		fmt.Printf("[DRY RUN] Missing template parameters: %s\n", strings.Join(missing, ", "))
+	if dryRun {
+		s.Logger.Printf("[DRY RUN] Missing template parameters: %s\n", strings.Join(missing, ", "))
+		return nil
+	}
+	if !interactive {
+		s.warnf("Missing template parameters: %s (placeholders are left in AGENTS.md)", strings.Join(missing, ", "))
 		return nil
 	}
 
 	// Interactive prompt for missing values
-	reader := bufio.NewReader(os.Stdin)
+	reader := s.reader()
 	for _, key := range missing {
-		fmt.Printf("Enter %s: ", key)
+		s.Logger.Printf("Enter %s: ", key)
 		v, err := reader.ReadString('\n')
 		if err != nil {
 			if err == io.EOF {
@@ -80,7 +81,7 @@ func ensureTemplateParameters(projectDir string, cfg *config.ProjectConfig, dryR
 
 		// Empty input is allowed (placeholder remains), but we do not save empty to avoid re-prompt loops
 		if v == "" {
-			fmt.Printf("Warning: %s is empty, will leave placeholder in template\n", key)
+			s.warnf("Warning: %s is empty, will leave placeholder in template", key)
 			continue
 		}
 
@@ -98,7 +99,7 @@ func ensureTemplateParameters(projectDir string, cfg *config.ProjectConfig, dryR
 	}
 
 	// Persist updated config
-	if err := config.SaveProjectConfig(projectDir, cfg); err != nil {
+	if err := config.SaveProjectConfig(s.Env, projectDir, cfg); err != nil {
 		return fmt.Errorf("failed to save updated project parameters: %w", err)
 	}
 	return nil
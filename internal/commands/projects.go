@@ -0,0 +1,278 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/internal/fsys"
+)
+
+// registerProject records a synced project in the user-level project registry
+func (s *Session) registerProject(projectDir string, pc *config.ProjectConfig, dryRun bool) {
+	if dryRun {
+		return
+	}
+	err := config.UpdateProjectRegistry(s.Env, func(registry *config.ProjectRegistry) error {
+		registry.Register(config.RegisteredProject{
+			Path:     projectDir,
+			Name:     pc.ProjectName,
+			Agents:   pc.EnabledAgents,
+			LastSync: time.Now().UTC().Truncate(time.Second),
+		})
+		return nil
+	})
+	if err != nil {
+		s.warnf("Warning: Could not update project registry: %v", err)
+	}
+}
+
+// ProjectInfo is a registered project, the JSON result of 'projects list'
+type ProjectInfo struct {
+	Path     string    `json:"path"`
+	Name     string    `json:"name,omitempty"`
+	Agents   []string  `json:"agents"`
+	LastSync time.Time `json:"last_sync"`
+	Missing  bool      `json:"missing"` // the directory no longer exists
+}
+
+// RunListProjects shows every registered project with its agents and last sync time
+func (s *Session) RunListProjects() error {
+	registry, err := config.LoadProjectRegistry(s.Env)
+	if err != nil {
+		return err
+	}
+	list := []ProjectInfo{}
+	for _, p := range registry.Projects {
+		list = append(list, ProjectInfo{Path: p.Path, Name: p.Name, Agents: append([]string{}, p.Agents...), LastSync: p.LastSync, Missing: !p.Exists(s.Env)})
+	}
+	s.setResultData(list)
+	if len(registry.Projects) == 0 {
+		s.Logger.Println("No projects registered. Projects are registered when 'anyagent sync' runs in them.")
+		return nil
+	}
+	w := tabwriter.NewWriter(s.Logger.Writer(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tNAME\tAGENTS\tLAST SYNC")
+	missing := 0
+	for _, p := range list {
+		path := p.Path
+		if p.Missing {
+			path += " (missing)"
+			missing++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", path, p.Name, strings.Join(p.Agents, ","), p.LastSync.Local().Format("2006-01-02 15:04"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if missing > 0 {
+		s.Logger.Printf("\n%d project(s) no longer exist. Remove them with: anyagent projects prune\n", missing)
+	}
+	return nil
+}
+
+// RunPruneProjects removes the registered projects whose directory no longer exists
+func (s *Session) RunPruneProjects(dryRun bool) error {
+	var pruned []config.RegisteredProject
+	if dryRun {
+		registry, err := config.LoadProjectRegistry(s.Env)
+		if err != nil {
+			return err
+		}
+		pruned = registry.Prune(s.Env)
+	} else {
+		err := config.UpdateProjectRegistry(s.Env, func(registry *config.ProjectRegistry) error {
+			pruned = registry.Prune(s.Env)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update project registry: %w", err)
+		}
+	}
+	if len(pruned) == 0 {
+		s.Logger.Infof("✅ No missing projects to prune\n")
+		return nil
+	}
+	for _, p := range pruned {
+		if dryRun {
+			s.dryRunf("unregister", p.Path, "Would remove missing project: %s", p.Path)
+		} else {
+			s.Logger.Infof("🗑️  Removed missing project: %s\n", p.Path)
+		}
+	}
+	if !dryRun {
+		s.Logger.Infof("✅ Pruned %d project(s)\n", len(pruned))
+	}
+	return nil
+}
+
+// SyncAllResult is one row of the 'sync --all' summary, and of its JSON result
+type SyncAllResult struct {
+	Path    string   `json:"path"`
+	Status  string   `json:"status"`
+	Changed []string `json:"changed,omitempty"`
+	Err     error    `json:"-"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// RunSyncAll re-syncs every registered project and prints a summary of what changed or failed.
+// It never prompts: a project that needs input fails and the batch goes on. With force,
+// generated files edited by hand are overwritten; a project's .anyagent (its customized
+// templates) is never reset, which only 'anyagent sync --force' in the project does.
+func (s *Session) RunSyncAll(dryRun bool, force bool) error {
+	registry, err := config.LoadProjectRegistry(s.Env)
+	if err != nil {
+		return err
+	}
+	if len(registry.Projects) == 0 {
+		s.Logger.Printf("No projects registered. Projects are registered when 'anyagent sync' runs in them.\n")
+		return nil
+	}
+	savedPrompts := s.Interactive
+	s.Interactive = false
+	defer func() { s.Interactive = savedPrompts }()
+
+	var results []SyncAllResult
+	failed := 0
+	for _, p := range registry.Projects {
+		result := SyncAllResult{Path: p.Path}
+		if !p.Exists(s.Env) {
+			result.Status = "missing"
+			results = append(results, result)
+			continue
+		}
+		s.Logger.Infof("\n=== %s ===\n", p.Path)
+		before := s.snapshotManagedFiles(p.Path)
+		if err := s.runSync(p.Path, nil, dryRun, false, force); err != nil {
+			result.Status = "failed"
+			result.Err = err
+			result.Error = err.Error()
+			failed++
+		} else {
+			result.Changed = diffSnapshots(before, s.snapshotManagedFiles(p.Path))
+			switch {
+			case dryRun:
+				result.Status = "dry-run"
+			case len(result.Changed) > 0:
+				result.Status = "updated"
+			default:
+				result.Status = "unchanged"
+			}
+		}
+		results = append(results, result)
+	}
+
+	s.Logger.Printf("\n")
+	s.printSyncAllSummary(results)
+	s.setResultData(results)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d project(s) failed to sync", failed, len(results))
+	}
+	return nil
+}
+
+// printSyncAllSummary prints the result table of 'sync --all'
+func (s *Session) printSyncAllSummary(results []SyncAllResult) {
+	w := tabwriter.NewWriter(s.Logger.Writer(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tSTATUS\tDETAILS")
+	for _, r := range results {
+		details := ""
+		switch {
+		case r.Err != nil:
+			details = r.Err.Error()
+		case r.Status == "missing":
+			details = "directory no longer exists (anyagent projects prune)"
+		case len(r.Changed) > 0:
+			details = summarizeChangedFiles(r.Changed)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Path, r.Status, details)
+	}
+	_ = w.Flush()
+}
+
+// summarizeChangedFiles lists the first few changed files, e.g. "AGENTS.md, CLAUDE.md (+3 more)"
+func summarizeChangedFiles(changed []string) string {
+	const shown = 3
+	if len(changed) <= shown {
+		return strings.Join(changed, ", ")
+	}
+	return fmt.Sprintf("%s (+%d more)", strings.Join(changed[:shown], ", "), len(changed)-shown)
+}
+
+// managedPaths are the project paths anyagent writes to
+var managedPaths = []string{
+	"AGENTS.md",
+	"CLAUDE.md",
+	".anyagent",
+	".amazonq",
+	".claude",
+	".gemini",
+	".github",
+	".junie",
+	".mcp.json",
+	filepath.Join(".vscode", "mcp.json"),
+}
+
+// snapshotManagedFiles fingerprints the files (and symlink targets) under managedPaths, in the
+// project and in each workspace
+func (s *Session) snapshotManagedFiles(projectDir string) map[string]string {
+	snapshot := map[string]string{}
+	roots := []string{projectDir}
+	if pc, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir)); err == nil {
+		workspaces, _ := pc.ResolveWorkspaces(s.Env, projectDir)
+		for _, ws := range workspaces {
+			roots = append(roots, filepath.Join(projectDir, filepath.FromSlash(ws.Dir)))
+		}
+	}
+	for _, base := range roots {
+		s.snapshotFiles(projectDir, base, snapshot)
+	}
+	return snapshot
+}
+
+// snapshotFiles adds the managed files under base to snapshot, keyed by path relative to projectDir
+func (s *Session) snapshotFiles(projectDir, base string, snapshot map[string]string) {
+	for _, rel := range managedPaths {
+		root := filepath.Join(base, rel)
+		_ = fsys.WalkDir(s.Env.FS, root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			key, _ := filepath.Rel(projectDir, path)
+			if d.Type()&fs.ModeSymlink != 0 {
+				target, _ := s.Env.FS.Readlink(path)
+				snapshot[key] = "-> " + target
+				return nil
+			}
+			data, err := s.Env.FS.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			snapshot[key] = fmt.Sprintf("%x", sha256.Sum256(data))
+			return nil
+		})
+	}
+}
+
+// diffSnapshots returns the sorted relative paths that were added, changed or removed
+func diffSnapshots(before, after map[string]string) []string {
+	var changed []string
+	for path, sum := range after {
+		if before[path] != sum {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
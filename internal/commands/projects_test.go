@@ -0,0 +1,178 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/internal/fsys"
+	"github.com/shibukawa/anyagent/internal/logging"
+)
+
+// newSyncedTestProject creates a project whose sync needs no prompts
+func newSyncedTestProject(t *testing.T, name string) string {
+	s := newTestSession()
+	t.Helper()
+	dir := t.TempDir()
+	pc := &config.ProjectConfig{
+		ProjectName:        name,
+		ProjectDescription: "test project",
+		EnabledAgents:      []string{"claude"},
+		Parameters: map[string]string{
+			"PROJECT_NAME":        name,
+			"PROJECT_DESCRIPTION": "test project",
+			"PRIMARY_LANGUAGE":    "Go",
+			"TEAM_NAME":           "core",
+		},
+	}
+	if err := config.SaveProjectConfig(s.Env, dir, pc); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	if err := s.RunSyncWithOptions(dir, nil, false, false); err != nil {
+		t.Fatalf("RunSyncWithOptions failed: %v", err)
+	}
+	return dir
+}
+
+func TestProjectRegistryAndSyncAll(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	api := newSyncedTestProject(t, "api")
+	web := newSyncedTestProject(t, "web")
+	gone := filepath.Join(t.TempDir(), "gone")
+	if err := os.MkdirAll(gone, 0755); err != nil {
+		t.Fatal(err)
+	}
+	registry, _ := config.LoadProjectRegistry(s.Env)
+	registry.Register(config.RegisteredProject{Path: gone, Name: "gone"})
+	if err := registry.Save(s.Env); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(gone); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := config.LoadProjectRegistry(s.Env)
+	if err != nil {
+		t.Fatalf("LoadProjectRegistry failed: %v", err)
+	}
+	if len(registry.Projects) != 3 {
+		t.Fatalf("expected 3 registered projects, got %+v", registry.Projects)
+	}
+	if err := s.RunListProjects(); err != nil {
+		t.Fatalf("RunListProjects failed: %v", err)
+	}
+
+	// A deleted AGENTS.md is regenerated; the other project stays as is
+	if err := os.Remove(filepath.Join(web, "AGENTS.md")); err != nil {
+		t.Fatal(err)
+	}
+	before := s.snapshotManagedFiles(api)
+	if err := s.RunSyncAll(false, false); err != nil {
+		t.Fatalf("RunSyncAll failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(web, "AGENTS.md")); err != nil {
+		t.Errorf("AGENTS.md not regenerated: %v", err)
+	}
+	if changed := diffSnapshots(before, s.snapshotManagedFiles(api)); len(changed) != 0 {
+		t.Errorf("unchanged project reported changes: %v", changed)
+	}
+
+	if err := s.RunPruneProjects(false); err != nil {
+		t.Fatalf("RunPruneProjects failed: %v", err)
+	}
+	registry, _ = config.LoadProjectRegistry(s.Env)
+	if len(registry.Projects) != 2 {
+		t.Errorf("expected the missing project to be pruned, got %+v", registry.Projects)
+	}
+}
+
+func TestRegisterProject_Concurrent(t *testing.T) {
+	tmp := t.TempDir()
+	envs := map[string]*fsys.Env{
+		"memory": fsys.Memory("/work", "/home/dev"),
+		"os":     {FS: fsys.OS, HomeDir: filepath.Join(tmp, "home"), ConfigDir: filepath.Join(tmp, "config")},
+	}
+	for name, env := range envs {
+		t.Run(name, func(t *testing.T) {
+			const projects = 30
+			warnings := make([][]string, projects)
+			var wg sync.WaitGroup
+			for i := range projects {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					s := &Session{Logger: logging.New(io.Discard, io.Discard, logging.Options{}), Env: env}
+					dir := filepath.Join(tmp, fmt.Sprintf("project-%02d", i))
+					s.registerProject(dir, &config.ProjectConfig{ProjectName: filepath.Base(dir)}, false)
+					warnings[i] = s.warnings
+				}()
+			}
+			wg.Wait()
+			for i, w := range warnings {
+				if len(w) != 0 {
+					t.Errorf("project %d: unexpected warnings: %v", i, w)
+				}
+			}
+			registry, err := config.LoadProjectRegistry(env)
+			if err != nil {
+				t.Fatalf("LoadProjectRegistry failed: %v", err)
+			}
+			if len(registry.Projects) != projects {
+				t.Errorf("expected %d registered projects, got %d", projects, len(registry.Projects))
+			}
+			dir, _ := config.GetUserConfigDir(env)
+			entries, _ := env.FS.ReadDir(dir)
+			for _, e := range entries {
+				if e.Name() != "projects.json" {
+					t.Errorf("leftover file in the config dir: %s", e.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	before := map[string]string{"AGENTS.md": "a", "CLAUDE.md": "-> AGENTS.md", ".claude/commands/old.md": "x"}
+	after := map[string]string{"AGENTS.md": "b", "CLAUDE.md": "-> AGENTS.md", ".claude/commands/new.md": "y"}
+	got := diffSnapshots(before, after)
+	want := []string{".claude/commands/new.md", ".claude/commands/old.md", "AGENTS.md"}
+	if len(got) != len(want) {
+		t.Fatalf("diffSnapshots = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("diffSnapshots = %v, want %v", got, want)
+		}
+	}
+	if s := summarizeChangedFiles([]string{"a", "b", "c", "d", "e"}); s != "a, b, c (+2 more)" {
+		t.Errorf("summarizeChangedFiles = %q", s)
+	}
+}
+
+func TestSyncAllForceKeepsProjectTemplates(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	dir := newSyncedTestProject(t, "api")
+	custom := filepath.Join(dir, ".anyagent", "custom.md")
+	writeTestFile(t, custom, "# my template\n")
+
+	s.Interactive = true
+	if err := s.RunSyncAll(false, true); err != nil {
+		t.Fatalf("RunSyncAll failed: %v", err)
+	}
+	if !s.Interactive {
+		t.Error("the session should stay interactive after the batch")
+	}
+	if got := mustReadFile(t, custom); got != "# my template\n" {
+		t.Errorf("project template was reset by sync --all --force: %q", got)
+	}
+}
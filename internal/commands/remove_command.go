@@ -4,35 +4,34 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/shibukawa/anyagent/internal/config"
 )
 
 // RunRemoveCommand executes the remove command functionality
-func RunRemoveCommand(command, projectDir string, dryRun bool) error {
-	fmt.Printf("Removing %s command from project...\n", command)
+func (s *Session) RunRemoveCommand(command, projectDir string, dryRun bool) error {
+	s.Logger.Infof("Removing %s command from project...\n", command)
 
 	// Get project directory (current directory if not specified)
 	if projectDir == "" {
 		var err error
-		projectDir, err = os.Getwd()
+		projectDir, err = s.Env.Getwd()
 		if err != nil {
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 	}
 
 	// Make sure the project directory exists
-	if _, err := os.Stat(projectDir); os.IsNotExist(err) {
-		return fmt.Errorf("project directory does not exist: %s", projectDir)
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
 	}
 
-	fmt.Printf("Project directory: %s\n", projectDir)
+	s.Logger.Infof("Project directory: %s\n", projectDir)
 
 	// Check if project is initialized (has AGENTS.md)
 	agentsPath := filepath.Join(projectDir, "AGENTS.md")
-	if _, err := os.Stat(agentsPath); os.IsNotExist(err) {
-		return fmt.Errorf("project is not initialized with anyagent. Run 'anyagent sync' first")
+	if _, err := s.Env.FS.Stat(agentsPath); os.IsNotExist(err) {
+		return ErrNotInitialized
 	}
 
 	// Validate command name
@@ -43,18 +42,17 @@ func RunRemoveCommand(command, projectDir string, dryRun bool) error {
 	// Check if Copilot command file exists
 	copilotCommandFilePath := filepath.Join(projectDir, ".github", "prompts", fmt.Sprintf("%s.prompt.md", command))
 	copilotExists := false
-	if _, err := os.Stat(copilotCommandFilePath); err == nil {
+	if _, err := s.Env.FS.Stat(copilotCommandFilePath); err == nil {
 		copilotExists = true
 	}
 
 	// Check if Amazon Q Developer command file exists
 	qdevExists := false
 	qdevCommandFilePath := ""
-	homeDir, err := os.UserHomeDir()
+	homeDir, err := s.Env.UserHomeDir()
 	if err == nil {
-		qdevCommandName := strings.ReplaceAll(strings.ReplaceAll(command, "-", " "), "_", " ")
-		qdevCommandFilePath = filepath.Join(homeDir, ".aws", "amazonq", "prompts", fmt.Sprintf("%s.md", qdevCommandName))
-		if _, err := os.Stat(qdevCommandFilePath); err == nil {
+		qdevCommandFilePath = qdevGlobalPromptPath(homeDir, command)
+		if _, err := s.Env.FS.Stat(qdevCommandFilePath); err == nil {
 			qdevExists = true
 		}
 	}
@@ -63,11 +61,11 @@ func RunRemoveCommand(command, projectDir string, dryRun bool) error {
 	codexExists := false
 	codexCommandFilePath := ""
 	if homeDir == "" {
-		homeDir, _ = os.UserHomeDir()
+		homeDir, _ = s.Env.UserHomeDir()
 	}
 	if homeDir != "" {
-		codexCommandFilePath = filepath.Join(homeDir, ".codex", "prompts", fmt.Sprintf("%s.md", command))
-		if _, err := os.Stat(codexCommandFilePath); err == nil {
+		codexCommandFilePath = codexGlobalPromptPath(homeDir, command)
+		if _, err := s.Env.FS.Stat(codexCommandFilePath); err == nil {
 			codexExists = true
 		}
 	}
@@ -75,14 +73,14 @@ func RunRemoveCommand(command, projectDir string, dryRun bool) error {
 	// Check if Claude command file exists
 	claudeExists := false
 	claudeCommandFilePath := filepath.Join(projectDir, ".claude", "commands", fmt.Sprintf("%s.md", command))
-	if _, err := os.Stat(claudeCommandFilePath); err == nil {
+	if _, err := s.Env.FS.Stat(claudeCommandFilePath); err == nil {
 		claudeExists = true
 	}
 
 	// Check if Gemini command file exists
 	geminiExists := false
 	geminiCommandFilePath := filepath.Join(projectDir, ".gemini", "commands", fmt.Sprintf("%s.toml", command))
-	if _, err := os.Stat(geminiCommandFilePath); err == nil {
+	if _, err := s.Env.FS.Stat(geminiCommandFilePath); err == nil {
 		geminiExists = true
 	}
 
@@ -92,123 +90,173 @@ func RunRemoveCommand(command, projectDir string, dryRun bool) error {
 
 	// Remove Copilot command file
 	if copilotExists {
-		if err := removeCommandFile(copilotCommandFilePath, "VS Code Copilot", dryRun); err != nil {
+		if err := s.removeCommandFile(copilotCommandFilePath, "VS Code Copilot", dryRun); err != nil {
 			return fmt.Errorf("failed to remove VS Code Copilot command file: %w", err)
 		}
 	}
 
-	// Remove Amazon Q Developer command file
+	// Remove Amazon Q Developer command file unless another project still uses it
 	if qdevExists {
-		if err := removeCommandFile(qdevCommandFilePath, "Amazon Q Developer", dryRun); err != nil {
-			fmt.Printf("⚠️  Warning: Could not remove Amazon Q Developer command file: %v\n", err)
+		err := s.releaseGlobalArtifact(projectDir, qdevCommandFilePath, "Amazon Q Developer command", dryRun, func() error {
+			return s.removeCommandFile(qdevCommandFilePath, "Amazon Q Developer", dryRun)
+		})
+		if err != nil {
+			s.warnf("Warning: Could not remove Amazon Q Developer command file: %v", err)
 		}
 	}
 
-	// Remove Codex command file
+	// Remove Codex command file unless another project still uses it
 	if codexExists {
-		if err := removeCommandFile(codexCommandFilePath, "Codex", dryRun); err != nil {
-			fmt.Printf("⚠️  Warning: Could not remove Codex command file: %v\n", err)
+		err := s.releaseGlobalArtifact(projectDir, codexCommandFilePath, "Codex command", dryRun, func() error {
+			return s.removeCommandFile(codexCommandFilePath, "Codex", dryRun)
+		})
+		if err != nil {
+			s.warnf("Warning: Could not remove Codex command file: %v", err)
 		}
 	}
 
 	// Remove Claude command file
 	if claudeExists {
-		if err := removeCommandFile(claudeCommandFilePath, "Claude Code", dryRun); err != nil {
-			fmt.Printf("⚠️  Warning: Could not remove Claude command file: %v\n", err)
+		if err := s.removeCommandFile(claudeCommandFilePath, "Claude Code", dryRun); err != nil {
+			s.warnf("Warning: Could not remove Claude command file: %v", err)
 		}
 	}
 
 	// Remove Gemini command file
 	if geminiExists {
-		if err := removeCommandFile(geminiCommandFilePath, "Gemini Code", dryRun); err != nil {
-			fmt.Printf("⚠️  Warning: Could not remove Gemini command file: %v\n", err)
+		if err := s.removeCommandFile(geminiCommandFilePath, "Gemini Code", dryRun); err != nil {
+			s.warnf("Warning: Could not remove Gemini command file: %v", err)
 		}
 	}
 
-	fmt.Printf("✅ %s command removed successfully\n", command)
+	s.Logger.Infof("✅ %s command removed successfully\n", command)
 
 	// Update project config to remove the command from installed_commands
-	if err := removeInstalledCommandFromConfig(projectDir, command, dryRun); err != nil {
-		fmt.Printf("⚠️  Warning: Failed to update project config when removing '%s': %v\n", command, err)
+	if err := s.removeInstalledCommandFromConfig(projectDir, command, dryRun); err != nil {
+		s.warnf("Warning: Failed to update project config when removing '%s': %v", command, err)
 	}
 	return nil
 }
 
+// CommandList is the JSON result of 'list command'
+type CommandList struct {
+	ProjectDir  string          `json:"project_dir"`
+	Initialized bool            `json:"initialized"`
+	Commands    []CommandStatus `json:"commands"`
+}
+
+// CommandStatus is the install status of an available command template
+type CommandStatus struct {
+	Name      string `json:"name"`
+	Source    string `json:"source"`
+	Installed bool   `json:"installed"`
+}
+
 // RunListCommands executes the list commands command functionality
-func RunListCommands(projectDir string) error {
+func (s *Session) RunListCommands(projectDir string) error {
 	// Get project directory (current directory if not specified)
 	if projectDir == "" {
 		var err error
-		projectDir, err = os.Getwd()
+		projectDir, err = s.Env.Getwd()
 		if err != nil {
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 	}
 
 	// Make sure the project directory exists
-	if _, err := os.Stat(projectDir); os.IsNotExist(err) {
-		return fmt.Errorf("project directory does not exist: %s", projectDir)
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
 	}
 
-	fmt.Printf("Command status for project: %s\n\n", projectDir)
+	s.Logger.Printf("Command status for project: %s\n\n", projectDir)
+	list := &CommandList{ProjectDir: projectDir, Commands: []CommandStatus{}}
+	s.setResultData(list)
 
 	// Check if project is initialized
 	agentsPath := filepath.Join(projectDir, "AGENTS.md")
-	if _, err := os.Stat(agentsPath); os.IsNotExist(err) {
-		fmt.Println("❌ Project is not initialized with anyagent")
+	if _, err := s.Env.FS.Stat(agentsPath); os.IsNotExist(err) {
+		s.Logger.Println("❌ Project is not initialized with anyagent")
 		return nil
 	}
+	list.Initialized = true
 
-	// Get available commands from templates
-	availableCommands, err := config.GetAvailableCommands()
+	// Get available commands from all template layers
+	availableCommands, err := config.ListCommandTemplates(s.Env, projectDir)
 	if err != nil {
 		return fmt.Errorf("failed to get available commands: %w", err)
 	}
 
 	if len(availableCommands) == 0 {
-		fmt.Println("No commands available.")
+		s.Logger.Println("No commands available.")
 		return nil
 	}
 
 	promptsDir := filepath.Join(projectDir, ".github", "prompts")
 
 	// Check each available command
-	fmt.Println("Available commands:")
+	s.Logger.Println("Available commands:")
 	installedCount := 0
 	for _, command := range availableCommands {
-		commandFilePath := filepath.Join(promptsDir, fmt.Sprintf("%s.prompt.md", command))
-		if _, err := os.Stat(commandFilePath); err == nil {
-			fmt.Printf("  ✅ %s (installed)\n", command)
+		commandFilePath := filepath.Join(promptsDir, fmt.Sprintf("%s.prompt.md", command.Name))
+		_, err := s.Env.FS.Stat(commandFilePath)
+		list.Commands = append(list.Commands, CommandStatus{Name: command.Name, Source: command.Source, Installed: err == nil})
+		if err == nil {
+			s.Logger.Printf("  ✅ %s (installed, %s template)\n", command.Name, command.Source)
 			installedCount++
 		} else {
-			fmt.Printf("  ⬜ %s (not installed)\n", command)
+			s.Logger.Printf("  ⬜ %s (not installed, %s template)\n", command.Name, command.Source)
 		}
 	}
 
-	fmt.Printf("\nSummary: %d/%d commands installed\n", installedCount, len(availableCommands))
+	s.Logger.Printf("\nSummary: %d/%d commands installed\n", installedCount, len(availableCommands))
 
 	if installedCount == 0 {
-		fmt.Println("\n💡 Use 'anyagent add command <command-name>' to install commands")
+		s.Logger.Infof("\n💡 Use 'anyagent add command <command-name>' to install commands\n")
 	}
 
 	return nil
 }
 
 // removeCommandFile removes a command file
-func removeCommandFile(filePath, agentType string, dryRun bool) error {
+func (s *Session) removeCommandFile(filePath, agentType string, dryRun bool) error {
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would remove %s command file: %s\n", agentType, filePath)
+		s.dryRunf("remove", filePath, "Would remove %s command file: %s", agentType, filePath)
 		return nil
 	}
 
-	fmt.Printf("🗑️  Removing %s command file: %s\n", agentType, filePath)
-	return os.Remove(filePath)
+	s.Logger.Infof("🗑️  Removing %s command file: %s\n", agentType, filePath)
+	return s.Env.FS.Remove(filePath)
+}
+
+// removeCommandForAgent removes the agent's file of a command. Q Dev and Codex prompts are
+// user-global and only removed when no other project uses them.
+func (s *Session) removeCommandForAgent(agentName, projectDir, command string, dryRun bool) error {
+	label := fmt.Sprintf("%s command '%s'", agentDisplayName(agentName), command)
+	switch agentName {
+	case "copilot":
+		return s.removePath(filepath.Join(projectDir, ".github", "prompts", fmt.Sprintf("%s.prompt.md", command)), label, dryRun)
+	case "claude":
+		return s.removePath(filepath.Join(projectDir, ".claude", "commands", fmt.Sprintf("%s.md", command)), label, dryRun)
+	case "gemini":
+		return s.removePath(filepath.Join(projectDir, ".gemini", "commands", fmt.Sprintf("%s.toml", command)), label, dryRun)
+	case "qdev", "codex":
+		homeDir, err := s.Env.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		path := codexGlobalPromptPath(homeDir, command)
+		if agentName == "qdev" {
+			path = qdevGlobalPromptPath(homeDir, command)
+		}
+		return s.removeGlobalArtifact(projectDir, path, label, dryRun)
+	}
+	return nil
 }
 
 // removeInstalledCommandFromConfig removes a command entry from .anyagent.yaml
-func removeInstalledCommandFromConfig(projectDir, command string, dryRun bool) error {
+func (s *Session) removeInstalledCommandFromConfig(projectDir, command string, dryRun bool) error {
 	configPath := config.GetProjectConfigPath(projectDir)
-	projectConfig, err := config.LoadProjectConfig(configPath)
+	projectConfig, err := config.LoadProjectConfig(s.Env, configPath)
 	if err != nil {
 		return err
 	}
@@ -228,8 +276,8 @@ func removeInstalledCommandFromConfig(projectDir, command string, dryRun bool) e
 	}
 	projectConfig.InstalledCommands = newCommands
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would remove command '%s' from .anyagent.yaml\n", command)
+		s.dryRunf("record", "", "Would remove command '%s' from .anyagent.yaml", command)
 		return nil
 	}
-	return projectConfig.Save(configPath)
+	return projectConfig.Save(s.Env, configPath)
 }
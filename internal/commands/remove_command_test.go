@@ -4,9 +4,12 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/shibukawa/anyagent/internal/config"
 )
 
 func TestRunRemoveCommand(t *testing.T) {
+	s := newTestSession()
 	// Create temporary directory for testing
 	tempDir := t.TempDir()
 
@@ -51,7 +54,7 @@ func TestRunRemoveCommand(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := RunRemoveCommand(tt.command, tempDir, true) // Use dry run
+			err := s.RunRemoveCommand(tt.command, tempDir, true) // Use dry run
 
 			if tt.expectError && err == nil {
 				t.Error("Expected error but got none")
@@ -63,6 +66,7 @@ func TestRunRemoveCommand(t *testing.T) {
 }
 
 func TestRunListCommands(t *testing.T) {
+	s := newTestSession()
 	// Create temporary directory for testing
 	tempDir := t.TempDir()
 
@@ -84,18 +88,98 @@ func TestRunListCommands(t *testing.T) {
 		t.Fatalf("Failed to create command file: %v", err)
 	}
 
-	err := RunListCommands(tempDir)
+	err := s.RunListCommands(tempDir)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
 }
 
 func TestRunListCommandsNotInitialized(t *testing.T) {
+	s := newTestSession()
 	// Create temporary directory without AGENTS.md
 	tempDir := t.TempDir()
 
-	err := RunListCommands(tempDir)
+	err := s.RunListCommands(tempDir)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
 }
+
+func TestRunRemoveCommand_SharedGlobalPrompt(t *testing.T) {
+	s := newTestSession()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var projects []string
+	for range 2 {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("# AGENTS"), 0644); err != nil {
+			t.Fatalf("failed to write AGENTS.md: %v", err)
+		}
+		if err := config.SaveProjectConfig(s.Env, dir, &config.ProjectConfig{EnabledAgents: []string{"codex"}}); err != nil {
+			t.Fatalf("failed to save config: %v", err)
+		}
+		if err := s.RunAddCommand("general", dir, false, true); err != nil {
+			t.Fatalf("RunAddCommand failed: %v", err)
+		}
+		projects = append(projects, dir)
+	}
+	prompt := codexGlobalPromptPath(home, "general")
+
+	// The first project releases its reference; the prompt stays for the second one
+	if err := s.RunRemoveCommand("general", projects[0], false); err != nil {
+		t.Fatalf("RunRemoveCommand failed: %v", err)
+	}
+	if _, err := os.Stat(prompt); err != nil {
+		t.Fatalf("prompt still used by another project was removed: %v", err)
+	}
+
+	if err := s.RunRemoveCommand("general", projects[1], false); err != nil {
+		t.Fatalf("RunRemoveCommand failed: %v", err)
+	}
+	if _, err := os.Stat(prompt); !os.IsNotExist(err) {
+		t.Errorf("unused prompt should be removed, stat err = %v", err)
+	}
+	state, err := config.LoadGlobalState(s.Env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refs := state.References(prompt); len(refs) != 0 {
+		t.Errorf("expected no references left, got %v", refs)
+	}
+}
+
+func TestRunRemoveCommand_KeepsUnreferencedGlobalPrompt(t *testing.T) {
+	s := newTestSession()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("# AGENTS"), 0644); err != nil {
+		t.Fatalf("failed to write AGENTS.md: %v", err)
+	}
+	if err := config.SaveProjectConfig(s.Env, dir, &config.ProjectConfig{EnabledAgents: []string{"codex"}, InstalledCommands: []string{"general"}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	// A prompt the user wrote by hand; no project references it
+	prompt := codexGlobalPromptPath(home, "general")
+	if err := os.MkdirAll(filepath.Dir(prompt), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(prompt, []byte("# my prompt\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	released := false
+	if err := s.releaseGlobalArtifact(dir, prompt, "Codex command", false, func() error { released = true; return nil }); err != nil || released {
+		t.Errorf("release should not delete an artifact the project never referenced (err %v)", err)
+	}
+	if err := s.RunRemoveCommand("general", dir, false); err != nil {
+		t.Fatalf("RunRemoveCommand failed: %v", err)
+	}
+	if _, err := os.Stat(prompt); err != nil {
+		t.Errorf("hand-written prompt was removed: %v", err)
+	}
+}
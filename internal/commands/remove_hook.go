@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+// RunRemoveHook unmerges an installed hook from every agent settings file and the project config
+func (s *Session) RunRemoveHook(name, projectDir string, dryRun bool) error {
+	s.Logger.Infof("Removing %s hook from project...\n", name)
+
+	// Get project directory (current directory if not specified)
+	if projectDir == "" {
+		var err error
+		projectDir, err = s.Env.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+
+	// Make sure the project directory exists
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
+	}
+
+	// Check if project is initialized (has AGENTS.md)
+	if _, err := s.Env.FS.Stat(filepath.Join(projectDir, "AGENTS.md")); os.IsNotExist(err) {
+		return ErrNotInitialized
+	}
+
+	if name == "" {
+		return fmt.Errorf("hook name cannot be empty")
+	}
+
+	configPath := config.GetProjectConfigPath(projectDir)
+	projectConfig, err := config.LoadProjectConfig(s.Env, configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load project config: %w", err)
+	}
+
+	recorded := false
+	var remaining []string
+	for _, h := range projectConfig.InstalledHooks {
+		if h == name {
+			recorded = true
+			continue
+		}
+		remaining = append(remaining, h)
+	}
+	if !recorded {
+		return fmt.Errorf("hook '%s' is not installed", name)
+	}
+
+	// Settings of every agent are checked, so hooks left behind by a previous agent go too
+	for _, agent := range SupportedAgents {
+		if err := s.uninstallHookForAgent(agent.Name, projectDir, name, dryRun); err != nil {
+			return err
+		}
+	}
+
+	projectConfig.InstalledHooks = remaining
+	if dryRun {
+		s.dryRunf("record", "", "Would remove hook '%s' from .anyagent/config.yaml", name)
+	} else if err := projectConfig.Save(s.Env, configPath); err != nil {
+		return fmt.Errorf("failed to save project config: %w", err)
+	}
+
+	s.Logger.Infof("✅ %s hook removed successfully\n", name)
+	return nil
+}
+
+// HookList is the JSON result of 'list hook'
+type HookList struct {
+	ProjectDir  string       `json:"project_dir"`
+	Initialized bool         `json:"initialized"`
+	Hooks       []HookStatus `json:"hooks"`
+}
+
+// HookStatus is the install status of an available hook template
+type HookStatus struct {
+	Name      string `json:"name"`
+	Source    string `json:"source"`
+	Installed bool   `json:"installed"`
+}
+
+// RunListHooks shows available hooks and whether they are installed in the project
+func (s *Session) RunListHooks(projectDir string) error {
+	// Get project directory (current directory if not specified)
+	if projectDir == "" {
+		var err error
+		projectDir, err = s.Env.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+
+	// Make sure the project directory exists
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
+	}
+
+	s.Logger.Printf("Hook status for project: %s\n\n", projectDir)
+	list := &HookList{ProjectDir: projectDir, Hooks: []HookStatus{}}
+	s.setResultData(list)
+
+	// Check if project is initialized
+	if _, err := s.Env.FS.Stat(filepath.Join(projectDir, "AGENTS.md")); os.IsNotExist(err) {
+		s.Logger.Println("❌ Project is not initialized with anyagent")
+		return nil
+	}
+	list.Initialized = true
+
+	available, err := config.ListHookTemplates(s.Env, projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to get available hooks: %w", err)
+	}
+	if len(available) == 0 {
+		s.Logger.Println("No hooks available.")
+		return nil
+	}
+
+	cfg, _ := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
+	installed := map[string]bool{}
+	for _, h := range cfg.InstalledHooks {
+		installed[h] = true
+	}
+
+	s.Logger.Println("Available hooks:")
+	installedCount := 0
+	for _, h := range available {
+		list.Hooks = append(list.Hooks, HookStatus{Name: h.Name, Source: h.Source, Installed: installed[h.Name]})
+		if installed[h.Name] {
+			s.Logger.Printf("  ✅ %s (installed, %s template)\n", h.Name, h.Source)
+			installedCount++
+		} else {
+			s.Logger.Printf("  ⬜ %s (not installed, %s template)\n", h.Name, h.Source)
+		}
+	}
+
+	s.Logger.Printf("\nSummary: %d/%d hooks installed\n", installedCount, len(available))
+
+	if installedCount == 0 {
+		s.Logger.Infof("\n💡 Use 'anyagent add hook <hook-name>' to install hooks\n")
+	}
+	return nil
+}
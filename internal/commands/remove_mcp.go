@@ -0,0 +1,350 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+// mcpConfigPath returns the agent's MCP config file; ok is false for agents without one
+func (s *Session) mcpConfigPath(agentName, projectDir string) (string, bool) {
+	switch agentName {
+	case "copilot":
+		return filepath.Join(projectDir, ".vscode", "mcp.json"), true
+	case "qdev":
+		return filepath.Join(projectDir, ".amazonq", "mcp.json"), true
+	case "claude":
+		return filepath.Join(projectDir, ".mcp.json"), true
+	case "junie":
+		return filepath.Join(projectDir, ".junie", "mcp.yaml"), true
+	case "gemini":
+		return filepath.Join(projectDir, ".gemini", "settings.json"), true
+	case "codex":
+		path, err := s.codexConfigPath()
+		return path, err == nil
+	}
+	return "", false
+}
+
+// installedMCPServerNames returns the server names present in the agent's MCP config file
+func (s *Session) installedMCPServerNames(agentName, projectDir string) (map[string]bool, error) {
+	names := map[string]bool{}
+	path, ok := s.mcpConfigPath(agentName, projectDir)
+	if !ok {
+		return names, nil
+	}
+	b, err := s.Env.FS.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return names, nil
+		}
+		return nil, err
+	}
+	switch filepath.Ext(path) {
+	case ".json":
+		obj, err := s.readJSONObject(path)
+		if err != nil {
+			return nil, err
+		}
+		servers, _ := obj[mcpServersKey(agentName)].(map[string]any)
+		for name := range servers {
+			names[name] = true
+		}
+	case ".yaml":
+		var doc struct {
+			Servers map[string]any `yaml:"servers"`
+		}
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		for name := range doc.Servers {
+			names[name] = true
+		}
+	case ".toml":
+		if names, err = codexMCPServerNames(string(b)); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	return names, nil
+}
+
+// removeMCPServerFromAgent deletes a server from the agent's MCP config file, leaving other
+// servers and keys untouched. It reports whether the file contained the server. A Codex server
+// is only removed when the project references it in the global state and no other project does.
+func (s *Session) removeMCPServerFromAgent(agentName, projectDir, name string, dryRun bool) (bool, error) {
+	installed, err := s.installedMCPServerNames(agentName, projectDir)
+	if err != nil || !installed[name] {
+		return false, err
+	}
+	path, _ := s.mcpConfigPath(agentName, projectDir)
+	remove := func() error { return s.removeMCPServerFromFile(agentName, path, name, dryRun) }
+	// ~/.codex/config.toml is shared by every project; keep servers other projects still use
+	if agentName == "codex" {
+		return true, s.releaseGlobalArtifact(projectDir, s.codexMCPArtifact(name), fmt.Sprintf("Codex MCP server '%s'", name), dryRun, remove)
+	}
+	return true, remove()
+}
+
+// removeMCPServerFromFile deletes a server from an MCP config file
+func (s *Session) removeMCPServerFromFile(agentName, path, name string, dryRun bool) error {
+	if dryRun {
+		s.dryRunf("update", path, "Would remove MCP server '%s' from %s", name, path)
+		return nil
+	}
+	switch filepath.Ext(path) {
+	case ".json":
+		obj, err := s.readJSONObject(path)
+		if err != nil {
+			return err
+		}
+		servers, _ := obj[mcpServersKey(agentName)].(map[string]any)
+		delete(servers, name)
+		if err := s.writeJSONObject(path, obj); err != nil {
+			return err
+		}
+	case ".yaml":
+		b, err := s.Env.FS.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var doc map[string]any
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		servers, _ := doc["servers"].(map[string]any)
+		delete(servers, name)
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		if err := s.Env.FS.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	case ".toml":
+		b, err := s.Env.FS.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		content, err := removeCodexMCPServer(string(b), name)
+		if err != nil {
+			return fmt.Errorf("failed to update %s: %w", path, err)
+		}
+		if err := s.Env.FS.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	s.Logger.Infof("🗑️  Removed MCP server '%s' from %s\n", name, path)
+	return nil
+}
+
+// RunRemoveMCP removes an MCP server from the project config, mcp.yaml and every agent MCP config
+func (s *Session) RunRemoveMCP(name, projectDir string, dryRun bool) error {
+	s.Logger.Infof("Removing MCP server '%s' from project...\n", name)
+
+	// Resolve project directory
+	if projectDir == "" {
+		var err error
+		projectDir, err = s.Env.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
+	}
+
+	// Must be initialized (AGENTS.md present)
+	if _, err := s.Env.FS.Stat(filepath.Join(projectDir, "AGENTS.md")); os.IsNotExist(err) {
+		return ErrNotInitialized
+	}
+
+	if name == "" {
+		return fmt.Errorf("MCP server name cannot be empty")
+	}
+
+	cfgPath := config.GetProjectConfigPath(projectDir)
+	cfg, err := config.LoadProjectConfig(s.Env, cfgPath)
+	if err != nil {
+		return fmt.Errorf("failed to load project config: %w", err)
+	}
+	_, recorded := cfg.MCPServers[name]
+
+	// Agent files are cleaned even when the server is no longer recorded (e.g. hand-edited config).
+	// ~/.codex/config.toml is not the project's: it is only edited for a project using Codex.
+	removed := false
+	for _, agent := range mcpAgents() {
+		if agent == "codex" && !slices.Contains(cfg.EnabledAgents, agent) {
+			continue
+		}
+		ok, err := s.removeMCPServerFromAgent(agent, projectDir, name, dryRun)
+		if err != nil {
+			return err
+		}
+		removed = removed || ok
+	}
+
+	if !recorded && !removed {
+		return fmt.Errorf("MCP server '%s' is not installed", name)
+	}
+
+	if recorded {
+		delete(cfg.MCPServers, name)
+		if dryRun {
+			s.dryRunf("record", "", "Would remove MCP server '%s' from .anyagent/config.yaml", name)
+		} else if err := cfg.Save(s.Env, cfgPath); err != nil {
+			return fmt.Errorf("failed to save project config: %w", err)
+		}
+		if err := s.writeOrUpdateProjectMCP(projectDir, cfg.MCPServers, dryRun); err != nil {
+			return err
+		}
+	}
+
+	s.Logger.Infof("✅ MCP server '%s' removed successfully\n", name)
+	return nil
+}
+
+// mcpAgents lists the agents that have an MCP config file, in display order
+func mcpAgents() []string {
+	agents := []string{}
+	for _, a := range SupportedAgents {
+		agents = append(agents, a.Name)
+	}
+	return append(agents, "junie")
+}
+
+// MCPList is the JSON result of 'list mcp'
+type MCPList struct {
+	ProjectDir  string            `json:"project_dir"`
+	Initialized bool              `json:"initialized"`
+	Agents      []string          `json:"agents"`
+	Servers     []MCPServerStatus `json:"servers"`
+}
+
+// MCPServerStatus is a recorded MCP server and its install status per enabled agent
+type MCPServerStatus struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Disabled    bool             `json:"disabled"`
+	Agents      []MCPAgentStatus `json:"agents"`
+}
+
+// MCPAgentStatus is one of installed, missing or excluded (by the agents/exclude_agents settings)
+type MCPAgentStatus struct {
+	Agent  string `json:"agent"`
+	Status string `json:"status"`
+}
+
+// RunListMCP shows the recorded MCP servers and whether each enabled agent has them installed
+func (s *Session) RunListMCP(projectDir string) error {
+	// Resolve project directory
+	if projectDir == "" {
+		var err error
+		projectDir, err = s.Env.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
+	}
+
+	s.Logger.Printf("MCP server status for project: %s\n\n", projectDir)
+	list := &MCPList{ProjectDir: projectDir, Agents: []string{}, Servers: []MCPServerStatus{}}
+	s.setResultData(list)
+
+	// Check if project is initialized
+	if _, err := s.Env.FS.Stat(filepath.Join(projectDir, "AGENTS.md")); os.IsNotExist(err) {
+		s.Logger.Println("❌ Project is not initialized with anyagent")
+		return nil
+	}
+	list.Initialized = true
+
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
+	if err != nil {
+		return fmt.Errorf("failed to load project config: %w", err)
+	}
+	list.Agents = append(list.Agents, cfg.EnabledAgents...)
+	if len(cfg.MCPServers) == 0 {
+		s.Logger.Println("No MCP servers configured.")
+		s.Logger.Infof("\n💡 Use 'anyagent add mcp <name> --cmd \"<command>\"' to add one\n")
+		return nil
+	}
+
+	installed := map[string]map[string]bool{}
+	for _, agent := range cfg.EnabledAgents {
+		names, err := s.installedMCPServerNames(agent, projectDir)
+		if err != nil {
+			s.warnf("Warning: Could not read MCP config for %s: %v", agent, err)
+		}
+		installed[agent] = names
+	}
+
+	names := make([]string, 0, len(cfg.MCPServers))
+	for name := range cfg.MCPServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s.Logger.Println("MCP servers:")
+	for _, name := range names {
+		server := cfg.MCPServers[name]
+		status := MCPServerStatus{Name: name, Description: describeMCPServer(server), Disabled: server.Disabled, Agents: []MCPAgentStatus{}}
+		if server.Disabled {
+			s.Logger.Printf("  ⏸️  %s (disabled): %s\n", name, describeMCPServer(server))
+			list.Servers = append(list.Servers, status)
+			continue
+		}
+		s.Logger.Printf("  • %s: %s%s\n", name, describeMCPServer(server), describeMCPAgents(server))
+		for _, agent := range cfg.EnabledAgents {
+			if _, ok := s.mcpConfigPath(agent, projectDir); !ok {
+				continue
+			}
+			if !server.EnabledFor(agent) {
+				s.Logger.Printf("      ➖ %s: not used (agents setting)\n", agentDisplayName(agent))
+				status.Agents = append(status.Agents, MCPAgentStatus{Agent: agent, Status: "excluded"})
+			} else if installed[agent][name] {
+				s.Logger.Printf("      ✅ %s: installed\n", agentDisplayName(agent))
+				status.Agents = append(status.Agents, MCPAgentStatus{Agent: agent, Status: "installed"})
+			} else {
+				s.Logger.Printf("      ❌ %s: missing\n", agentDisplayName(agent))
+				status.Agents = append(status.Agents, MCPAgentStatus{Agent: agent, Status: "missing"})
+			}
+		}
+		list.Servers = append(list.Servers, status)
+	}
+
+	s.Logger.Printf("\nSummary: %d MCP servers configured\n", len(names))
+	return nil
+}
+
+// describeMCPAgents summarizes the agents and exclude_agents settings of a server
+func describeMCPAgents(s config.MCPServer) string {
+	var parts []string
+	if len(s.Agents) > 0 {
+		parts = append(parts, "only "+strings.Join(s.Agents, ", "))
+	}
+	if len(s.ExcludeAgents) > 0 {
+		parts = append(parts, "not "+strings.Join(s.ExcludeAgents, ", "))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, "; ") + ")"
+}
+
+// agentDisplayName returns the display name of a supported agent, or the name itself
+func agentDisplayName(name string) string {
+	for _, a := range SupportedAgents {
+		if a.Name == name {
+			return a.DisplayName
+		}
+	}
+	return name
+}
@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+func TestRunRemoveMCP_CleansAllAgentFiles(t *testing.T) {
+	s := newTestSession()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("# AGENTS"), 0644); err != nil {
+		t.Fatalf("failed to write AGENTS.md: %v", err)
+	}
+	if err := config.SaveProjectConfig(s.Env, dir, &config.ProjectConfig{EnabledAgents: []string{"copilot", "codex"}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	for _, name := range []string{"postgres", "context7"} {
+		if err := s.RunAddMCP(AddMCPParams{Name: name, Cmd: "npx -y " + name, ProjectDir: dir}); err != nil {
+			t.Fatalf("RunAddMCP failed: %v", err)
+		}
+	}
+	// Leftovers from a previously selected agent are cleaned too
+	cfg, _ := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(dir))
+	if err := s.ensureMCPFilesForAgent("claude", dir, cfg.MCPServers, false); err != nil {
+		t.Fatal(err)
+	}
+	codexFile := filepath.Join(home, ".codex", "config.toml")
+	if err := os.MkdirAll(filepath.Dir(codexFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	codex := "model = \"o3\"\n\n[mcp_servers.postgres]\ncommand = \"npx\"\n\n[mcp_servers.context7]\ncommand = \"npx\"\n"
+	if err := os.WriteFile(codexFile, []byte(codex), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The project installed the Codex servers, so it may remove them again
+	s.acquireGlobalArtifacts(dir, []string{s.codexMCPArtifact("postgres"), s.codexMCPArtifact("context7")}, false)
+
+	if err := s.RunRemoveMCP("postgres", dir, false); err != nil {
+		t.Fatalf("RunRemoveMCP failed: %v", err)
+	}
+
+	cfg, _ = config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(dir))
+	if _, ok := cfg.MCPServers["postgres"]; ok {
+		t.Errorf("postgres still recorded in config")
+	}
+	for _, agent := range []string{"copilot", "claude", "codex"} {
+		names, err := s.installedMCPServerNames(agent, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if names["postgres"] || !names["context7"] {
+			t.Errorf("%s: expected only context7 to remain, got %v", agent, names)
+		}
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, "mcp.yaml"))
+	if strings.Contains(string(b), "postgres") {
+		t.Errorf("mcp.yaml still contains postgres:\n%s", b)
+	}
+	b, _ = os.ReadFile(codexFile)
+	if want := "model = \"o3\"\n\n[mcp_servers.context7]\ncommand = \"npx\"\n"; string(b) != want {
+		t.Errorf("codex config = %q, want %q", b, want)
+	}
+
+	if err := s.RunRemoveMCP("postgres", dir, false); err == nil {
+		t.Error("removing an unknown server should fail")
+	}
+	if err := s.RunListMCP(dir); err != nil {
+		t.Fatalf("RunListMCP failed: %v", err)
+	}
+}
+
+func TestRunRemoveMCP_KeepsCodexServerUsedByOtherProject(t *testing.T) {
+	s := newTestSession()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var projects []string
+	for range 2 {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("# AGENTS"), 0644); err != nil {
+			t.Fatalf("failed to write AGENTS.md: %v", err)
+		}
+		if err := config.SaveProjectConfig(s.Env, dir, &config.ProjectConfig{EnabledAgents: []string{"codex"}}); err != nil {
+			t.Fatalf("failed to save config: %v", err)
+		}
+		if err := s.RunAddMCP(AddMCPParams{Name: "postgres", Cmd: "npx -y postgres", ProjectDir: dir, Global: true}); err != nil {
+			t.Fatalf("RunAddMCP failed: %v", err)
+		}
+		projects = append(projects, dir)
+	}
+
+	if err := s.RunRemoveMCP("postgres", projects[0], false); err != nil {
+		t.Fatalf("RunRemoveMCP failed: %v", err)
+	}
+	names, err := s.installedMCPServerNames("codex", projects[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !names["postgres"] {
+		t.Fatalf("server still used by another project was removed from the Codex config")
+	}
+
+	if err := s.RunRemoveMCP("postgres", projects[1], false); err != nil {
+		t.Fatalf("RunRemoveMCP failed: %v", err)
+	}
+	if names, _ = s.installedMCPServerNames("codex", projects[1]); names["postgres"] {
+		t.Errorf("unused server should be removed from the Codex config")
+	}
+}
+
+func TestRunRemoveMCP_LeavesCodexConfigOfOtherAgents(t *testing.T) {
+	s := newTestSession()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("# AGENTS"), 0644); err != nil {
+		t.Fatalf("failed to write AGENTS.md: %v", err)
+	}
+	if err := config.SaveProjectConfig(s.Env, dir, &config.ProjectConfig{EnabledAgents: []string{"claude"}}); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	if err := s.RunAddMCP(AddMCPParams{Name: "github", Cmd: "npx -y server-github", ProjectDir: dir}); err != nil {
+		t.Fatalf("RunAddMCP failed: %v", err)
+	}
+	// Written by hand for Codex, not by this project
+	codexFile := filepath.Join(home, ".codex", "config.toml")
+	if err := os.MkdirAll(filepath.Dir(codexFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	codex := "[mcp_servers.github]\ncommand = \"github-mcp\"\n"
+	if err := os.WriteFile(codexFile, []byte(codex), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.RunRemoveMCP("github", dir, false); err != nil {
+		t.Fatalf("RunRemoveMCP failed: %v", err)
+	}
+	if names, _ := s.installedMCPServerNames("claude", dir); names["github"] {
+		t.Error("github still in .mcp.json")
+	}
+	if b, _ := os.ReadFile(codexFile); string(b) != codex {
+		t.Errorf("codex config of a Claude-only project was edited: %q", b)
+	}
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+// RunRemovePersona removes an installed persona from every agent location and the project config
+func (s *Session) RunRemovePersona(name, projectDir string, dryRun bool) error {
+	s.Logger.Infof("Removing %s persona from project...\n", name)
+
+	// Get project directory (current directory if not specified)
+	if projectDir == "" {
+		var err error
+		projectDir, err = s.Env.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+
+	// Make sure the project directory exists
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
+	}
+
+	// Check if project is initialized (has AGENTS.md)
+	if _, err := s.Env.FS.Stat(filepath.Join(projectDir, "AGENTS.md")); os.IsNotExist(err) {
+		return ErrNotInitialized
+	}
+
+	if name == "" {
+		return fmt.Errorf("persona name cannot be empty")
+	}
+
+	configPath := config.GetProjectConfigPath(projectDir)
+	projectConfig, err := config.LoadProjectConfig(s.Env, configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load project config: %w", err)
+	}
+
+	recorded := false
+	var remaining []string
+	for _, p := range projectConfig.InstalledPersonas {
+		if p == name {
+			recorded = true
+			continue
+		}
+		remaining = append(remaining, p)
+	}
+
+	removed := false
+	for _, agent := range SupportedAgents {
+		path, ok := personaFilePath(agent.Name, projectDir, name)
+		if !ok {
+			continue
+		}
+		if _, err := s.Env.FS.Lstat(path); err != nil {
+			continue
+		}
+		if err := s.removePath(path, fmt.Sprintf("%s persona '%s'", agent.DisplayName, name), dryRun); err != nil {
+			return err
+		}
+		removed = true
+	}
+
+	if !recorded && !removed {
+		return fmt.Errorf("persona '%s' is not installed", name)
+	}
+
+	if recorded {
+		projectConfig.InstalledPersonas = remaining
+		if dryRun {
+			s.dryRunf("record", "", "Would remove persona '%s' from .anyagent/config.yaml", name)
+		} else if err := projectConfig.Save(s.Env, configPath); err != nil {
+			return fmt.Errorf("failed to save project config: %w", err)
+		}
+	}
+
+	s.Logger.Infof("✅ %s persona removed successfully\n", name)
+	return nil
+}
+
+// PersonaList is the JSON result of 'list persona'
+type PersonaList struct {
+	ProjectDir  string          `json:"project_dir"`
+	Initialized bool            `json:"initialized"`
+	Personas    []PersonaStatus `json:"personas"`
+}
+
+// PersonaStatus is the install status of an available persona template
+type PersonaStatus struct {
+	Name      string `json:"name"`
+	Source    string `json:"source"`
+	Installed bool   `json:"installed"`
+}
+
+// RunListPersonas shows available personas and whether they are installed in the project
+func (s *Session) RunListPersonas(projectDir string) error {
+	// Get project directory (current directory if not specified)
+	if projectDir == "" {
+		var err error
+		projectDir, err = s.Env.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+
+	// Make sure the project directory exists
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
+	}
+
+	s.Logger.Printf("Persona status for project: %s\n\n", projectDir)
+	list := &PersonaList{ProjectDir: projectDir, Personas: []PersonaStatus{}}
+	s.setResultData(list)
+
+	// Check if project is initialized
+	if _, err := s.Env.FS.Stat(filepath.Join(projectDir, "AGENTS.md")); os.IsNotExist(err) {
+		s.Logger.Println("❌ Project is not initialized with anyagent")
+		return nil
+	}
+	list.Initialized = true
+
+	available, err := config.ListPersonaTemplates(s.Env, projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to get available personas: %w", err)
+	}
+	if len(available) == 0 {
+		s.Logger.Println("No personas available.")
+		return nil
+	}
+
+	cfg, _ := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
+	installed := map[string]bool{}
+	for _, p := range cfg.InstalledPersonas {
+		installed[p] = true
+	}
+
+	s.Logger.Println("Available personas:")
+	installedCount := 0
+	for _, p := range available {
+		list.Personas = append(list.Personas, PersonaStatus{Name: p.Name, Source: p.Source, Installed: installed[p.Name]})
+		if installed[p.Name] {
+			s.Logger.Printf("  ✅ %s (installed, %s template)\n", p.Name, p.Source)
+			installedCount++
+		} else {
+			s.Logger.Printf("  ⬜ %s (not installed, %s template)\n", p.Name, p.Source)
+		}
+	}
+
+	s.Logger.Printf("\nSummary: %d/%d personas installed\n", installedCount, len(available))
+
+	if installedCount == 0 {
+		s.Logger.Infof("\n💡 Use 'anyagent add persona <persona-name>' to install personas\n")
+	}
+	return nil
+}
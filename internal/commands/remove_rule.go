@@ -10,29 +10,29 @@ import (
 )
 
 // RunRemoveRule executes the remove rule command functionality
-func RunRemoveRule(language, projectDir string, dryRun bool) error {
-	fmt.Printf("Removing %s rules from project...\n", language)
+func (s *Session) RunRemoveRule(language, projectDir string, dryRun bool) error {
+	s.Logger.Infof("Removing %s rules from project...\n", language)
 
 	// Get project directory (current directory if not specified)
 	if projectDir == "" {
 		var err error
-		projectDir, err = os.Getwd()
+		projectDir, err = s.Env.Getwd()
 		if err != nil {
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 	}
 
 	// Make sure the project directory exists
-	if _, err := os.Stat(projectDir); os.IsNotExist(err) {
-		return fmt.Errorf("project directory does not exist: %s", projectDir)
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
 	}
 
-	fmt.Printf("Project directory: %s\n", projectDir)
+	s.Logger.Infof("Project directory: %s\n", projectDir)
 
 	// Check if project is initialized (has AGENTS.md)
 	agentsPath := filepath.Join(projectDir, "AGENTS.md")
-	if _, err := os.Stat(agentsPath); os.IsNotExist(err) {
-		return fmt.Errorf("project is not initialized with anyagent. Run 'anyagent sync' first")
+	if _, err := s.Env.FS.Stat(agentsPath); os.IsNotExist(err) {
+		return ErrNotInitialized
 	}
 
 	// Validate and normalize language name
@@ -43,127 +43,146 @@ func RunRemoveRule(language, projectDir string, dryRun bool) error {
 
 	// When Copilot is enabled, remove external rule file. For Codex-only projects
 	// there is no external rule file, so skip this step.
-	if shouldCreateCopilotRuleFiles(projectDir) {
+	if s.shouldCreateCopilotRuleFiles(projectDir) {
 		// Check if rule file exists
 		ruleFilePath := filepath.Join(projectDir, ".github", "instructions", fmt.Sprintf("%s.instructions.md", normalizedLanguage))
-		if _, err := os.Stat(ruleFilePath); os.IsNotExist(err) {
+		if _, err := s.Env.FS.Stat(ruleFilePath); os.IsNotExist(err) {
 			return fmt.Errorf("rule file does not exist: %s", ruleFilePath)
 		}
 
 		// Remove the rule file
-		if err := removeRuleFile(ruleFilePath, dryRun); err != nil {
+		if err := s.removeRuleFile(ruleFilePath, dryRun); err != nil {
 			return fmt.Errorf("failed to remove rule file: %w", err)
 		}
-	} else if shouldCreateQDevRuleFiles(projectDir) {
+	} else if s.shouldCreateQDevRuleFiles(projectDir) {
 		// Remove Q Developer rule file if present
 		qdevRulePath := filepath.Join(projectDir, ".amazonq", "rules", fmt.Sprintf("%s.md", normalizedLanguage))
-		if _, err := os.Stat(qdevRulePath); os.IsNotExist(err) {
+		if _, err := s.Env.FS.Stat(qdevRulePath); os.IsNotExist(err) {
 			return fmt.Errorf("rule file does not exist: %s", qdevRulePath)
 		}
-		if err := removeRuleFile(qdevRulePath, dryRun); err != nil {
+		if err := s.removeRuleFile(qdevRulePath, dryRun); err != nil {
 			return fmt.Errorf("failed to remove rule file: %w", err)
 		}
 	} else {
-		fmt.Printf("ℹ️  Codex selected: no external rule files to remove; updating AGENTS.md only.\n")
+		s.Logger.Infof("ℹ️  Codex selected: no external rule files to remove; updating AGENTS.md only.\n")
 	}
 
 	// Update project configuration and regenerate AGENTS.md
 	if !dryRun {
-		if err := removeFromProjectConfigAndRegenerate(projectDir, normalizedLanguage); err != nil {
-			fmt.Printf("⚠️  Warning: Failed to update configuration: %v\n", err)
+		if err := s.removeFromProjectConfigAndRegenerate(projectDir, normalizedLanguage); err != nil {
+			s.warnf("Warning: Failed to update configuration: %v", err)
 		}
 	}
 
-	fmt.Printf("✅ %s rules removed successfully\n", normalizedLanguage)
+	s.Logger.Infof("✅ %s rules removed successfully\n", normalizedLanguage)
 	return nil
 }
 
+// RuleList is the JSON result of 'list rule'
+type RuleList struct {
+	ProjectDir  string       `json:"project_dir"`
+	Initialized bool         `json:"initialized"`
+	Agent       string       `json:"agent,omitempty"`
+	Rules       []RuleStatus `json:"rules"`
+}
+
+// RuleStatus is the install status of a supported rule
+type RuleStatus struct {
+	Name      string `json:"name"`
+	Installed bool   `json:"installed"`
+}
+
 // RunListRules executes the list rules command functionality
-func RunListRules(projectDir string) error {
+func (s *Session) RunListRules(projectDir string) error {
 	// Get project directory (current directory if not specified)
 	if projectDir == "" {
 		var err error
-		projectDir, err = os.Getwd()
+		projectDir, err = s.Env.Getwd()
 		if err != nil {
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 	}
 
 	// Make sure the project directory exists
-	if _, err := os.Stat(projectDir); os.IsNotExist(err) {
-		return fmt.Errorf("project directory does not exist: %s", projectDir)
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
 	}
 
-	fmt.Printf("Rule status for project: %s\n\n", projectDir)
+	s.Logger.Printf("Rule status for project: %s\n\n", projectDir)
+	list := &RuleList{ProjectDir: projectDir, Rules: []RuleStatus{}}
+	s.setResultData(list)
 
 	// Check if project is initialized
 	agentsPath := filepath.Join(projectDir, "AGENTS.md")
-	if _, err := os.Stat(agentsPath); os.IsNotExist(err) {
-		fmt.Println("❌ Project is not initialized with anyagent")
+	if _, err := s.Env.FS.Stat(agentsPath); os.IsNotExist(err) {
+		s.Logger.Println("❌ Project is not initialized with anyagent")
 		return nil
 	}
+	list.Initialized = true
 
 	instructionsDir := filepath.Join(projectDir, ".github", "instructions")
 
 	// Load installed rules from config and detect selected agent
-	cfg, _ := config.LoadProjectConfig(config.GetProjectConfigPath(projectDir))
+	cfg, _ := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
 	installed := map[string]bool{}
 	for _, r := range cfg.InstalledRules {
 		installed[r] = true
 	}
-	agent := selectedAgent(projectDir)
+	agent := s.selectedAgent(projectDir)
+	list.Agent = agent
 
 	// Check each supported rule
-	fmt.Println("Available rules:")
+	s.Logger.Println("Available rules:")
 	installedCount := 0
 	for _, rule := range SupportedRules {
 		isInstalled := installed[rule]
 		// If not recorded in config, consider Copilot file presence
 		if !isInstalled {
-			if _, err := os.Stat(filepath.Join(instructionsDir, fmt.Sprintf("%s.instructions.md", rule))); err == nil {
+			if _, err := s.Env.FS.Stat(filepath.Join(instructionsDir, fmt.Sprintf("%s.instructions.md", rule))); err == nil {
 				isInstalled = true
 			}
 		}
+		list.Rules = append(list.Rules, RuleStatus{Name: rule, Installed: isInstalled})
 
 		if isInstalled {
 			// Add hint for Codex listing
 			if agent == "codex" {
-				fmt.Printf("  ✅ %s (installed in AGENTS.md)\n", rule)
+				s.Logger.Printf("  ✅ %s (installed in AGENTS.md)\n", rule)
 			} else {
-				fmt.Printf("  ✅ %s (installed)\n", rule)
+				s.Logger.Printf("  ✅ %s (installed)\n", rule)
 			}
 			installedCount++
 		} else {
-			fmt.Printf("  ⬜ %s (not installed)\n", rule)
+			s.Logger.Printf("  ⬜ %s (not installed)\n", rule)
 		}
 	}
 
-	fmt.Printf("\nSummary: %d/%d rules installed\n", installedCount, len(SupportedRules))
+	s.Logger.Printf("\nSummary: %d/%d rules installed\n", installedCount, len(SupportedRules))
 
 	if installedCount == 0 {
-		fmt.Println("\n💡 Use 'anyagent add rule <language>' to install rules")
+		s.Logger.Infof("\n💡 Use 'anyagent add rule <language>' to install rules\n")
 	}
 
 	return nil
 }
 
 // removeRuleFile removes the rule instruction file
-func removeRuleFile(filePath string, dryRun bool) error {
+func (s *Session) removeRuleFile(filePath string, dryRun bool) error {
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would remove rule file: %s\n", filePath)
+		s.dryRunf("remove", filePath, "Would remove rule file: %s", filePath)
 		return nil
 	}
 
-	fmt.Printf("🗑️  Removing rule file: %s\n", filePath)
-	return os.Remove(filePath)
+	s.Logger.Infof("🗑️  Removing rule file: %s\n", filePath)
+	return s.Env.FS.Remove(filePath)
 }
 
 // removeFromProjectConfigAndRegenerate removes a rule from project config and regenerates AGENTS.md
-func removeFromProjectConfigAndRegenerate(projectDir, rule string) error {
+func (s *Session) removeFromProjectConfigAndRegenerate(projectDir, rule string) error {
 	configPath := config.GetProjectConfigPath(projectDir)
 
 	// Load existing config
-	projectConfig, err := config.LoadProjectConfig(configPath)
+	projectConfig, err := config.LoadProjectConfig(s.Env, configPath)
 	if err != nil {
 		return fmt.Errorf("failed to load project config: %w", err)
 	}
@@ -181,20 +200,20 @@ func removeFromProjectConfigAndRegenerate(projectDir, rule string) error {
 
 	if !found {
 		// Rule wasn't in config, just regenerate at the project directory
-		return projectConfig.RegenerateAgentsFileAt(projectDir)
+		return projectConfig.RegenerateAgentsFileAt(s.Env, projectDir)
 	}
 
 	// Update the rules list
 	projectConfig.InstalledRules = newRules
 
 	// Save the updated config
-	if err := projectConfig.Save(configPath); err != nil {
+	if err := projectConfig.Save(s.Env, configPath); err != nil {
 		return fmt.Errorf("failed to save project config: %w", err)
 	}
 
 	// Ensure template parameters, then regenerate AGENTS.md at the specified project directory
-	if err := ensureTemplateParameters(projectDir, projectConfig, false); err != nil {
+	if err := s.ensureTemplateParameters(projectDir, projectConfig, false); err != nil {
 		return fmt.Errorf("failed to resolve template parameters: %w", err)
 	}
-	return projectConfig.RegenerateAgentsFileAt(projectDir)
+	return projectConfig.RegenerateAgentsFileAt(s.Env, projectDir)
 }
@@ -9,6 +9,7 @@ import (
 )
 
 func TestRunRemoveRule(t *testing.T) {
+	s := newTestSession()
 	// Create temporary directory for testing
 	tempDir := t.TempDir()
 
@@ -53,7 +54,7 @@ func TestRunRemoveRule(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := RunRemoveRule(tt.language, tempDir, true) // Use dry run
+			err := s.RunRemoveRule(tt.language, tempDir, true) // Use dry run
 
 			if tt.expectError && err == nil {
 				t.Error("Expected error but got none")
@@ -65,13 +66,14 @@ func TestRunRemoveRule(t *testing.T) {
 }
 
 func TestRunRemoveRuleQDev(t *testing.T) {
+	s := newTestSession()
 	tempDir := t.TempDir()
 	// AGENTS.md present
 	if err := os.WriteFile(filepath.Join(tempDir, "AGENTS.md"), []byte("# AGENTS"), 0644); err != nil {
 		t.Fatalf("failed to write AGENTS.md: %v", err)
 	}
 	// Enable qdev
-	if err := config.SaveProjectConfig(tempDir, &config.ProjectConfig{EnabledAgents: []string{"qdev"}}); err != nil {
+	if err := config.SaveProjectConfig(s.Env, tempDir, &config.ProjectConfig{EnabledAgents: []string{"qdev"}}); err != nil {
 		t.Fatalf("failed to save config: %v", err)
 	}
 	// Create Q Dev rule file
@@ -84,12 +86,13 @@ func TestRunRemoveRuleQDev(t *testing.T) {
 		t.Fatalf("failed to create rule file: %v", err)
 	}
 	// Dry run should succeed
-	if err := RunRemoveRule("go", tempDir, true); err != nil {
+	if err := s.RunRemoveRule("go", tempDir, true); err != nil {
 		t.Fatalf("dry run failed: %v", err)
 	}
 }
 
 func TestRunListRules(t *testing.T) {
+	s := newTestSession()
 	// Create temporary directory for testing
 	tempDir := t.TempDir()
 
@@ -111,17 +114,18 @@ func TestRunListRules(t *testing.T) {
 		t.Fatalf("Failed to create go rule file: %v", err)
 	}
 
-	err := RunListRules(tempDir)
+	err := s.RunListRules(tempDir)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
 }
 
 func TestRunListRulesNotInitialized(t *testing.T) {
+	s := newTestSession()
 	// Create temporary directory without AGENTS.md
 	tempDir := t.TempDir()
 
-	err := RunListRules(tempDir)
+	err := s.RunListRules(tempDir)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
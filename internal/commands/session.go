@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"bufio"
+
+	"github.com/shibukawa/anyagent/internal/fsys"
+	"github.com/shibukawa/anyagent/internal/logging"
+)
+
+// Session is what commands run with: the file system and directories they work in, the
+// logger they print with and whether they may prompt. Commands are methods of a Session and
+// keep what they report in it, so sessions share no state and may run concurrently.
+type Session struct {
+	Logger      *logging.Logger // RunSession discards all output when nil
+	Interactive bool            // allow prompts on Env's input; otherwise missing input fails with ErrInputRequired
+	Env         *fsys.Env       // RunSession uses the process environment and the real file system when nil
+
+	// what the command reported, returned by RunSession as a Report
+	data       any
+	operations []Operation
+	warnings   []string
+
+	input *bufio.Reader // shared by the prompts so buffered answers are not lost between them
+}
+
+// Report is what a command recorded while it ran in a session
+type Report struct {
+	Data       any
+	Operations []Operation
+	Warnings   []string
+}
+
+// RunSession runs fn with a copy of s, filling in the defaults of Logger and Env, and returns
+// what the command recorded
+func RunSession(s Session, fn func(s *Session) error) (Report, error) {
+	if s.Logger == nil {
+		s.Logger = logging.Discard()
+	}
+	if s.Env == nil {
+		s.Env = fsys.Default()
+	}
+	s.data, s.operations, s.warnings, s.input = nil, []Operation{}, []string{}, nil
+	err := fn(&s)
+	return Report{Data: s.data, Operations: s.operations, Warnings: s.warnings}, err
+}
+
+// canPrompt reports whether a command may ask for missing input: the session is interactive
+// and its input is a terminal or was given explicitly
+func (s *Session) canPrompt() bool {
+	return s.Interactive && s.Env.InputIsTerminal()
+}
+
+// reader returns the reader prompts read answers from
+func (s *Session) reader() *bufio.Reader {
+	if s.input == nil {
+		s.input = bufio.NewReader(s.Env.Input())
+	}
+	return s.input
+}
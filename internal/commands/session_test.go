@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/internal/fsys"
+	"github.com/shibukawa/anyagent/internal/logging"
+)
+
+// newTestSession returns a session printing to stdout, on the real file system and the
+// process environment
+func newTestSession() *Session {
+	return &Session{Logger: logging.Default(), Env: fsys.Default()}
+}
+
+func TestRunSession_MemoryEnv(t *testing.T) {
+	projectDir := filepath.Join(t.TempDir(), "app")
+	mem := fsys.Memory(projectDir, filepath.Join(t.TempDir(), "home"))
+
+	report, err := RunSession(Session{Env: mem}, func(s *Session) error {
+		if err := s.Env.FS.WriteFile(filepath.Join(projectDir, "AGENTS.md"), []byte("# app\n"), 0644); err != nil {
+			return err
+		}
+		pc := &config.ProjectConfig{ProjectName: "app", EnabledAgents: []string{"claude"}, Parameters: map[string]string{}}
+		if err := config.SaveProjectConfig(s.Env, projectDir, pc); err != nil {
+			return err
+		}
+		if err := s.RunAddCommand("editorconfig", "", false, false); err != nil {
+			return err
+		}
+		return s.RunStatus("")
+	})
+	if err != nil {
+		t.Fatalf("RunSession failed: %v", err)
+	}
+
+	b, err := mem.FS.ReadFile(filepath.Join(projectDir, ".claude", "commands", "editorconfig.md"))
+	if err != nil {
+		t.Fatalf("command was not written to the memory FS: %v", err)
+	}
+	if len(b) == 0 {
+		t.Errorf("unexpected command content: %q", b)
+	}
+	status, ok := report.Data.(*StatusReport)
+	if !ok || status.ProjectDir != projectDir || len(status.Installed.Commands) != 1 {
+		t.Errorf("unexpected status: %+v", report.Data)
+	}
+	if _, err := os.Stat(projectDir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the session touched the disk: %v", err)
+	}
+}
+
+func TestRunSession_Concurrent(t *testing.T) {
+	names := []string{"api", "web", "worker", "batch"}
+	outputs := make([]bytes.Buffer, len(names))
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			projectDir := "/work/" + name
+			mem := fsys.Memory(projectDir, "/home/dev")
+			_ = mem.FS.WriteFile(filepath.Join(projectDir, "AGENTS.md"), []byte("# "+name+"\n"), 0644)
+			logger := logging.New(&outputs[i], &outputs[i], logging.Options{})
+			_, errs[i] = RunSession(Session{Logger: logger, Env: mem}, func(s *Session) error {
+				return s.RunStatus("")
+			})
+		}()
+	}
+	wg.Wait()
+	for i, name := range names {
+		if errs[i] != nil {
+			t.Errorf("%s: RunStatus failed: %v", name, errs[i])
+		}
+		if got := outputs[i].String(); !strings.Contains(got, "/work/"+name) || strings.Count(got, "Status of project") != 1 {
+			t.Errorf("%s: unexpected output:\n%s", name, got)
+		}
+	}
+}
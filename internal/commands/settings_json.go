@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// readJSONObject reads a JSON object from path. A missing or empty file yields an empty object
+// so callers can merge their keys into it without clobbering anything else.
+func (s *Session) readJSONObject(path string) (map[string]any, error) {
+	obj := map[string]any{}
+	b, err := s.Env.FS.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return obj, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(b) == 0 {
+		return obj, nil
+	}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return obj, nil
+}
+
+// writeJSONObject writes obj as indented JSON, creating the parent directory
+func (s *Session) writeJSONObject(path string, obj map[string]any) error {
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", path, err)
+	}
+	if err := s.Env.FS.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	return s.Env.FS.WriteFile(path, append(data, '\n'), 0644)
+}
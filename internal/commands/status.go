@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+// StatusReport is the JSON result of 'status'
+type StatusReport struct {
+	ProjectDir  string         `json:"project_dir"`
+	Initialized bool           `json:"initialized"`
+	ProjectName string         `json:"project_name,omitempty"`
+	Agents      []string       `json:"agents"`
+	Installed   InstalledItems `json:"installed"`
+	Workspaces  []string       `json:"workspaces"`
+	Registered  bool           `json:"registered"`
+	LastSync    *time.Time     `json:"last_sync,omitempty"`
+}
+
+// InstalledItems are the rules, commands, personas, hooks and MCP servers recorded in .anyagent/config.yaml
+type InstalledItems struct {
+	Rules      []string `json:"rules"`
+	Commands   []string `json:"commands"`
+	Personas   []string `json:"personas"`
+	Hooks      []string `json:"hooks"`
+	MCPServers []string `json:"mcp_servers"`
+}
+
+// installedItems lists what the project config records, MCP servers sorted by name
+func installedItems(pc *config.ProjectConfig) InstalledItems {
+	items := InstalledItems{
+		Rules:      append([]string{}, pc.InstalledRules...),
+		Commands:   append([]string{}, pc.InstalledCommands...),
+		Personas:   append([]string{}, pc.InstalledPersonas...),
+		Hooks:      append([]string{}, pc.InstalledHooks...),
+		MCPServers: []string{},
+	}
+	for name := range pc.MCPServers {
+		items.MCPServers = append(items.MCPServers, name)
+	}
+	sort.Strings(items.MCPServers)
+	return items
+}
+
+// RunStatus shows the project's agents, installed items, workspaces and last sync
+func (s *Session) RunStatus(projectDir string) error {
+	// Get project directory (current directory if not specified)
+	if projectDir == "" {
+		var err error
+		projectDir, err = s.Env.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
+	}
+
+	s.Logger.Printf("Status of project: %s\n\n", projectDir)
+	report := &StatusReport{ProjectDir: projectDir, Agents: []string{}, Installed: installedItems(&config.ProjectConfig{}), Workspaces: []string{}}
+	s.setResultData(report)
+
+	if _, err := s.Env.FS.Stat(filepath.Join(projectDir, "AGENTS.md")); os.IsNotExist(err) {
+		s.Logger.Println("❌ Project is not initialized with anyagent")
+		s.Logger.Infof("\n💡 Use 'anyagent sync' to initialize it\n")
+		return nil
+	}
+	report.Initialized = true
+
+	pc, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
+	if err != nil {
+		return fmt.Errorf("failed to load project config: %w", err)
+	}
+	report.ProjectName = pc.ProjectName
+	report.Agents = append(report.Agents, pc.EnabledAgents...)
+	report.Installed = installedItems(pc)
+	workspaces, err := pc.ResolveWorkspaces(s.Env, projectDir)
+	if err != nil {
+		s.warnf("Warning: Could not resolve workspaces: %v", err)
+	}
+	for _, ws := range workspaces {
+		report.Workspaces = append(report.Workspaces, ws.Dir)
+	}
+	if registry, err := config.LoadProjectRegistry(s.Env); err == nil {
+		if p, ok := registry.Find(projectDir); ok {
+			report.Registered = true
+			report.LastSync = &p.LastSync
+		}
+	}
+
+	if report.ProjectName != "" {
+		s.Logger.Printf("Project:     %s\n", report.ProjectName)
+	}
+	s.Logger.Printf("Agents:      %s\n", joinOrNone(report.Agents))
+	s.Logger.Printf("Rules:       %s\n", joinOrNone(report.Installed.Rules))
+	s.Logger.Printf("Commands:    %s\n", joinOrNone(report.Installed.Commands))
+	s.Logger.Printf("Personas:    %s\n", joinOrNone(report.Installed.Personas))
+	s.Logger.Printf("Hooks:       %s\n", joinOrNone(report.Installed.Hooks))
+	s.Logger.Printf("MCP servers: %s\n", joinOrNone(report.Installed.MCPServers))
+	s.Logger.Printf("Workspaces:  %s\n", joinOrNone(report.Workspaces))
+	if report.LastSync != nil {
+		s.Logger.Printf("Last sync:   %s\n", report.LastSync.Local().Format("2006-01-02 15:04"))
+	} else {
+		s.Logger.Printf("Last sync:   unknown (not in the project registry)\n")
+	}
+	return nil
+}
+
+// joinOrNone joins names with commas, or returns "none"
+func joinOrNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
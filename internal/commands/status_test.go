@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"testing"
+)
+
+func TestRunStatus(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := newSyncedTestProject(t, "api")
+	if err := s.RunAddRule("go", dir, false); err != nil {
+		t.Fatalf("RunAddRule failed: %v", err)
+	}
+
+	result, err := RunSession(*s, func(s *Session) error { return s.RunStatus(dir) })
+	if err != nil {
+		t.Fatalf("RunStatus failed: %v", err)
+	}
+	report, ok := result.Data.(*StatusReport)
+	if !ok {
+		t.Fatalf("unexpected data: %+v", result.Data)
+	}
+	if !report.Initialized || report.ProjectName != "api" {
+		t.Fatalf("unexpected status: %+v", report)
+	}
+	if len(report.Agents) != 1 || report.Agents[0] != "claude" {
+		t.Errorf("unexpected agents: %v", report.Agents)
+	}
+	if len(report.Installed.Rules) != 1 || report.Installed.Rules[0] != "go" {
+		t.Errorf("unexpected rules: %v", report.Installed.Rules)
+	}
+	if !report.Registered || report.LastSync == nil {
+		t.Errorf("synced project should be registered: %+v", report)
+	}
+}
+
+func TestRunStatus_NotInitialized(t *testing.T) {
+	s := newTestSession()
+	result, err := RunSession(*s, func(s *Session) error { return s.RunStatus(t.TempDir()) })
+	if err != nil {
+		t.Fatalf("RunStatus failed: %v", err)
+	}
+	if report, ok := result.Data.(*StatusReport); !ok || report.Initialized {
+		t.Fatalf("unexpected status: %+v", result.Data)
+	}
+}
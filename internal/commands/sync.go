@@ -1,13 +1,14 @@
 package commands
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/internal/fsys"
 )
 
 // AIAgent represents a supported AI agent
@@ -62,29 +63,51 @@ type InitParams struct {
 }
 
 // RunFirstSync executes the initial project sync functionality
-func RunFirstSync(projectDir string, agentNames []string, dryRun bool) error {
-	return RunFirstSyncWithParams(projectDir, agentNames, "", "", dryRun)
+func (s *Session) RunFirstSync(projectDir string, agentNames []string, dryRun bool) error {
+	return s.RunFirstSyncWithParams(projectDir, agentNames, "", "", dryRun)
 }
 
 // RunFirstSyncWithParams executes the initial sync with predefined parameters (for testing)
-func RunFirstSyncWithParams(projectDir string, agentNames []string, projectName, projectDesc string, dryRun bool) error {
-	fmt.Printf("Initializing anyagent configuration for project...\n")
+func (s *Session) RunFirstSyncWithParams(projectDir string, agentNames []string, projectName, projectDesc string, dryRun bool) error {
+	return s.RunFirstSyncWithOptions(FirstSyncOptions{
+		ProjectDir:         projectDir,
+		Agents:             agentNames,
+		ProjectName:        projectName,
+		ProjectDescription: projectDesc,
+		DryRun:             dryRun,
+	})
+}
+
+// FirstSyncOptions are the answers given up front to the first sync; it prompts for the rest
+type FirstSyncOptions struct {
+	ProjectDir         string
+	Agents             []string
+	ProjectName        string
+	ProjectDescription string
+	Parameters         map[string]string // AGENTS.md template parameters
+	DryRun             bool
+}
+
+// RunFirstSyncWithOptions executes the initial sync of a project
+func (s *Session) RunFirstSyncWithOptions(opts FirstSyncOptions) error {
+	projectDir, agentNames, dryRun := opts.ProjectDir, opts.Agents, opts.DryRun
+	s.Logger.Infof("Initializing anyagent configuration for project...\n")
 
 	// Get project directory (current directory if not specified)
 	if projectDir == "" {
 		var err error
-		projectDir, err = os.Getwd()
+		projectDir, err = s.Env.Getwd()
 		if err != nil {
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 	}
 
 	// Make sure the project directory exists
-	if _, err := os.Stat(projectDir); os.IsNotExist(err) {
-		return fmt.Errorf("project directory does not exist: %s", projectDir)
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
 	}
 
-	fmt.Printf("Project directory: %s\n", projectDir)
+	s.Logger.Infof("Project directory: %s\n", projectDir)
 
 	// Initialize parameters
 	params := &InitParams{
@@ -101,7 +124,7 @@ func RunFirstSyncWithParams(projectDir string, agentNames []string, projectName,
 		params.SelectedAgents = selectedAgents
 	} else {
 		// Run wizard to select agents
-		selectedAgents, err := selectAgentsWizard()
+		selectedAgents, err := s.selectAgentsWizard()
 		if err != nil {
 			return fmt.Errorf("agent selection failed: %w", err)
 		}
@@ -109,11 +132,12 @@ func RunFirstSyncWithParams(projectDir string, agentNames []string, projectName,
 	}
 
 	// Get basic project parameters (name, description)
-	if projectName != "" && projectDesc != "" {
-		params.ProjectName = projectName
-		params.ProjectDescription = projectDesc
+	// Without prompts the description may stay empty
+	if opts.ProjectName != "" && (opts.ProjectDescription != "" || !s.Interactive) {
+		params.ProjectName = opts.ProjectName
+		params.ProjectDescription = opts.ProjectDescription
 	} else {
-		if err := getProjectParameters(params); err != nil {
+		if err := s.getProjectParameters(params); err != nil {
 			return fmt.Errorf("failed to get project parameters: %w", err)
 		}
 	}
@@ -134,77 +158,100 @@ func RunFirstSyncWithParams(projectDir string, agentNames []string, projectName,
 			"PROJECT_DESCRIPTION": params.ProjectDescription,
 		},
 	}
+	for k, v := range opts.Parameters {
+		pc.Parameters[k] = v
+	}
 
 	// Prompt for additional template parameters (excluding PROJECT_* and EXTRA_RULES)
-	if err := ensureTemplateParameters(projectDir, pc, dryRun); err != nil {
+	if err := s.ensureTemplateParameters(projectDir, pc, dryRun); err != nil {
 		return fmt.Errorf("failed to resolve template parameters: %w", err)
 	}
 
 	// Distribute user templates to .anyagent after inputs are collected
-	if err := ensureProjectAnyagentTemplates(projectDir, dryRun, false); err != nil {
+	if err := s.ensureProjectAnyagentTemplates(projectDir, dryRun, false); err != nil {
 		return fmt.Errorf("failed to ensure .anyagent templates: %w", err)
 	}
 
 	// Generate AGENTS.md from latest template and parameters
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would generate AGENTS.md using collected parameters and rules\n")
+		s.dryRunf("write", filepath.Join(projectDir, "AGENTS.md"), "Would generate AGENTS.md using collected parameters and rules")
 	} else {
-		if err := pc.RegenerateAgentsFileAt(projectDir); err != nil {
+		if err := pc.RegenerateAgentsFileAt(s.Env, projectDir); err != nil {
 			return fmt.Errorf("failed to generate AGENTS.md: %w", err)
 		}
 	}
 
 	// Create symlinks for selected agents
-	if err := createAgentSymlinks(&InitParams{ProjectDir: projectDir, SelectedAgents: params.SelectedAgents}, dryRun); err != nil {
+	if err := s.createAgentSymlinks(&InitParams{ProjectDir: projectDir, SelectedAgents: params.SelectedAgents}, dryRun); err != nil {
 		return fmt.Errorf("failed to create agent symlinks: %w", err)
 	}
 
 	// Persist final project configuration
 	if !dryRun {
-		if err := config.SaveProjectConfig(projectDir, pc); err != nil {
+		if err := config.SaveProjectConfig(s.Env, projectDir, pc); err != nil {
 			return fmt.Errorf("failed to save project configuration: %w", err)
 		}
 	}
+	s.registerProject(projectDir, pc, dryRun)
+	s.setResultData(&SyncResult{ProjectDir: projectDir, DryRun: dryRun, FirstSync: true, Agents: append([]string{}, pc.EnabledAgents...), RemovedAgents: []string{}, Installed: installedItems(pc)})
 
-	fmt.Printf("✅ Project initialization completed successfully\n")
+	s.Logger.Infof("✅ Project initialization completed successfully\n")
 	return nil
 }
 
+// SyncResult is the JSON result of 'sync'. Operations (with --dry-run) and warnings are
+// reported alongside it in the result document.
+type SyncResult struct {
+	ProjectDir    string         `json:"project_dir"`
+	DryRun        bool           `json:"dry_run"`
+	FirstSync     bool           `json:"first_sync"`
+	Agents        []string       `json:"agents"`
+	RemovedAgents []string       `json:"removed_agents"`
+	Installed     InstalledItems `json:"installed"`
+}
+
 // RunSync executes the sync command functionality
 // - If project is already initialized, it updates AGENTS.md from the latest template using stored parameters
 // - If --agents is specified, it reconfigures enabled agents (removing deselected agent artifacts)
 // - If project is not initialized, it behaves like RunFirstSync
-func RunSync(projectDir string, agentNames []string, dryRun bool) error {
-	return RunSyncWithOptions(projectDir, agentNames, dryRun, false)
+func (s *Session) RunSync(projectDir string, agentNames []string, dryRun bool) error {
+	return s.RunSyncWithOptions(projectDir, agentNames, dryRun, false)
 }
 
 // RunSyncWithOptions executes the sync command with --force support
-func RunSyncWithOptions(projectDir string, agentNames []string, dryRun bool, force bool) error {
-	fmt.Printf("Synchronizing anyagent configuration for project...\n")
+func (s *Session) RunSyncWithOptions(projectDir string, agentNames []string, dryRun bool, force bool) error {
+	return s.runSync(projectDir, agentNames, dryRun, force, force)
+}
+
+// runSync syncs a project. resetTemplates replaces .anyagent with the user templates;
+// overwrite replaces generated files that were edited by hand (nested AGENTS.md).
+func (s *Session) runSync(projectDir string, agentNames []string, dryRun, resetTemplates, overwrite bool) error {
+	s.Logger.Infof("Synchronizing anyagent configuration for project...\n")
 
 	// Determine project directory
 	if projectDir == "" {
 		var err error
-		projectDir, err = os.Getwd()
+		projectDir, err = s.Env.Getwd()
 		if err != nil {
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 	}
-	if _, err := os.Stat(projectDir); os.IsNotExist(err) {
-		return fmt.Errorf("project directory does not exist: %s", projectDir)
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
 	}
 
 	configPath := config.GetProjectConfigPath(projectDir)
-	projectConfig, err := config.LoadProjectConfig(configPath)
+	projectConfig, err := config.LoadProjectConfig(s.Env, configPath)
 	if err != nil {
 		return fmt.Errorf("failed to load project config: %w", err)
 	}
+	s.Logger.Debugf("Project config: %s\n", configPath)
 
 	// If AGENTS.md doesn't exist, treat as first-time initialization
 	agentsPath := filepath.Join(projectDir, "AGENTS.md")
-	if _, err := os.Stat(agentsPath); os.IsNotExist(err) && len(projectConfig.Parameters) == 0 && projectConfig.ProjectName == "" {
+	if _, err := s.Env.FS.Stat(agentsPath); os.IsNotExist(err) && len(projectConfig.Parameters) == 0 && projectConfig.ProjectName == "" {
 		// Fallback to init flow
-		return RunFirstSync(projectDir, agentNames, dryRun)
+		return s.RunFirstSync(projectDir, agentNames, dryRun)
 	}
 
 	// Determine new selection of agents
@@ -217,7 +264,7 @@ func RunSyncWithOptions(projectDir string, agentNames []string, dryRun bool, for
 	} else if len(projectConfig.EnabledAgents) > 0 {
 		selectedAgents = agentsFromNames(projectConfig.EnabledAgents)
 	} else {
-		selectedAgents, err = selectAgentsWizard()
+		selectedAgents, err = s.selectAgentsWizard()
 		if err != nil {
 			return fmt.Errorf("agent selection failed: %w", err)
 		}
@@ -234,6 +281,7 @@ func RunSyncWithOptions(projectDir string, agentNames []string, dryRun bool, for
 		newAgents[a.Name] = true
 		newAgentNames = append(newAgentNames, a.Name)
 	}
+	s.Logger.Debugf("Enabled agents: %s\n", strings.Join(newAgentNames, ", "))
 
 	var removedAgents []string
 	for a := range prevAgents {
@@ -243,7 +291,7 @@ func RunSyncWithOptions(projectDir string, agentNames []string, dryRun bool, for
 	}
 
 	// List installed commands for Copilot and Q Developer
-	installedCopilotCmds, installedQDevCmds, err := listInstalledCommands(projectDir)
+	installedCopilotCmds, installedQDevCmds, err := s.listInstalledCommands(projectDir)
 	if err != nil {
 		return err
 	}
@@ -253,112 +301,129 @@ func RunSyncWithOptions(projectDir string, agentNames []string, dryRun bool, for
 
 	// Remove artifacts for deselected agents
 	for _, agent := range removedAgents {
-		if err := removeAgentArtifacts(agent, projectDir, installedCopilotCmds, installedQDevCmds, installedRules, dryRun); err != nil {
+		if err := s.removeAgentArtifacts(agent, projectDir, installedCopilotCmds, installedQDevCmds, installedRules, dryRun); err != nil {
 			return fmt.Errorf("failed to remove artifacts for %s: %w", agent, err)
 		}
 	}
 
 	// Before regeneration, ensure all required template parameters are present
-	if err := ensureTemplateParameters(projectDir, projectConfig, dryRun); err != nil {
+	if err := s.ensureTemplateParameters(projectDir, projectConfig, dryRun); err != nil {
 		return fmt.Errorf("failed to resolve template parameters: %w", err)
 	}
 
 	// Distribute user templates only if .anyagent doesn't exist or --force is set
-	if _, stErr := os.Stat(filepath.Join(projectDir, ".anyagent")); os.IsNotExist(stErr) || force {
-		if err := ensureProjectAnyagentTemplates(projectDir, dryRun, force); err != nil {
+	if _, stErr := s.Env.FS.Stat(filepath.Join(projectDir, ".anyagent")); os.IsNotExist(stErr) || resetTemplates {
+		if err := s.ensureProjectAnyagentTemplates(projectDir, dryRun, resetTemplates); err != nil {
 			return fmt.Errorf("failed to ensure .anyagent templates: %w", err)
 		}
+	} else {
+		s.Logger.Debugf("Keeping the project's .anyagent templates (use --force to copy user templates again)\n")
 	}
 
 	// Regenerate AGENTS.md using stored parameters and rules (prefers .anyagent templates)
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would regenerate AGENTS.md using stored parameters and rules\n")
+		s.dryRunf("write", filepath.Join(projectDir, "AGENTS.md"), "Would regenerate AGENTS.md using stored parameters and rules")
 	} else {
-		if err := projectConfig.RegenerateAgentsFileAt(projectDir); err != nil {
+		if err := projectConfig.RegenerateAgentsFileAt(s.Env, projectDir); err != nil {
 			return fmt.Errorf("failed to regenerate AGENTS.md: %w", err)
 		}
-		fmt.Printf("📄 AGENTS.md regenerated from latest template\n")
+		s.Logger.Infof("📄 AGENTS.md regenerated from latest template\n")
 	}
 
 	// Recreate symlinks for selected agents
-	if err := createAgentSymlinks(&InitParams{ProjectDir: projectDir, SelectedAgents: selectedAgents}, dryRun); err != nil {
+	if err := s.createAgentSymlinks(&InitParams{ProjectDir: projectDir, SelectedAgents: selectedAgents}, dryRun); err != nil {
 		return fmt.Errorf("failed to create agent symlinks: %w", err)
 	}
 
+	// Nested AGENTS.md and per-path agent files for monorepo workspaces
+	if err := s.syncWorkspaces(projectDir, projectConfig, selectedAgents, dryRun, overwrite); err != nil {
+		return fmt.Errorf("failed to sync workspaces: %w", err)
+	}
+
 	// Reinstall commands for the selected agent from project config (info kept even if agent changes)
 	if len(selectedAgents) == 1 {
-		if err := reinstallCommandsForAgent(selectedAgents[0].Name, projectDir, projectConfig.InstalledCommands, dryRun); err != nil {
-			fmt.Printf("⚠️  Warning: Failed to reinstall commands for agent %s: %v\n", selectedAgents[0].Name, err)
+		if err := s.reinstallCommandsForAgent(selectedAgents[0].Name, projectDir, projectConfig.InstalledCommands, dryRun); err != nil {
+			s.warnf("Warning: Failed to reinstall commands for agent %s: %v", selectedAgents[0].Name, err)
+		}
+		if err := s.reinstallPersonasForAgent(selectedAgents[0].Name, projectDir, projectConfig.InstalledPersonas, dryRun); err != nil {
+			s.warnf("Warning: Failed to reinstall personas for agent %s: %v", selectedAgents[0].Name, err)
+		}
+		if err := s.reinstallHooksForAgent(selectedAgents[0].Name, projectDir, projectConfig.InstalledHooks, dryRun); err != nil {
+			s.warnf("Warning: Failed to reinstall hooks for agent %s: %v", selectedAgents[0].Name, err)
 		}
 	}
 
 	// Update and save project configuration
 	if !dryRun {
 		projectConfig.EnabledAgents = newAgentNames
-		if err := config.SaveProjectConfig(projectDir, projectConfig); err != nil {
+		if err := config.SaveProjectConfig(s.Env, projectDir, projectConfig); err != nil {
 			return fmt.Errorf("failed to save project configuration: %w", err)
 		}
 	} else {
-		fmt.Printf("[DRY RUN] Would save enabled agents to .anyagent.yaml: %v\n", newAgentNames)
+		s.dryRunf("record", "", "Would save enabled agents to .anyagent.yaml: %v", newAgentNames)
 	}
+	s.registerProject(projectDir, projectConfig, dryRun)
+	sort.Strings(removedAgents)
+	s.setResultData(&SyncResult{ProjectDir: projectDir, DryRun: dryRun, Agents: append([]string{}, newAgentNames...), RemovedAgents: append([]string{}, removedAgents...), Installed: installedItems(projectConfig)})
 
-	fmt.Printf("✅ Project synchronization completed successfully\n")
+	s.Logger.Infof("✅ Project synchronization completed successfully\n")
 	return nil
 }
 
 // ensureProjectAnyagentTemplates copies user templates to project .anyagent on first sync
-func ensureProjectAnyagentTemplates(projectDir string, dryRun bool, force bool) error {
+func (s *Session) ensureProjectAnyagentTemplates(projectDir string, dryRun bool, force bool) error {
 	target := filepath.Join(projectDir, ".anyagent")
 
-	userDir, err := config.GetUserConfigDir()
+	userDir, err := config.GetUserConfigDir(s.Env)
 	if err != nil {
 		return fmt.Errorf("failed to get user config dir: %w", err)
 	}
 	src := filepath.Join(userDir, "templates")
-	if _, err := os.Stat(src); os.IsNotExist(err) {
+	s.Logger.Debugf("User templates: %s\n", src)
+	if _, err := s.Env.FS.Stat(src); os.IsNotExist(err) {
 		// Ensure user templates exist
-		if err := config.CreateTemplateStructure(userDir); err != nil {
+		if err := config.CreateTemplateStructure(s.Env, userDir); err != nil {
 			return fmt.Errorf("failed to create template structure: %w", err)
 		}
-		if err := config.CreateTemplateFiles(userDir); err != nil {
+		if err := config.CreateTemplateFiles(s.Env, userDir); err != nil {
 			return fmt.Errorf("failed to create template files: %w", err)
 		}
 	}
 
-	if st, err := os.Stat(target); err == nil && st.IsDir() {
+	if st, err := s.Env.FS.Stat(target); err == nil && st.IsDir() {
 		// .anyagent already exists
 		if force {
 			if dryRun {
-				fmt.Printf("[DRY RUN] Would overwrite existing %s with templates from %s\n", target, src)
+				s.dryRunf("copy", target, "Would overwrite existing %s with templates from %s", target, src)
 				return nil
 			}
-			if err := os.RemoveAll(target); err != nil {
+			if err := s.Env.FS.RemoveAll(target); err != nil {
 				return fmt.Errorf("failed to remove existing .anyagent: %w", err)
 			}
-			fmt.Printf("📁 Re-copying templates to project .anyagent (force)...\n")
-			return copyDir(src, target)
+			s.Logger.Infof("📁 Re-copying templates to project .anyagent (force)...\n")
+			return s.copyDir(src, target)
 		}
 		// Non-destructive update: add only missing files
 		if dryRun {
-			fmt.Printf("[DRY RUN] Would add missing templates from %s into existing %s\n", src, target)
+			s.dryRunf("copy", target, "Would add missing templates from %s into existing %s", src, target)
 			return nil
 		}
-		fmt.Printf("📁 Updating existing .anyagent with any missing templates...\n")
-		return copyDirIfMissing(src, target)
+		s.Logger.Infof("📁 Updating existing .anyagent with any missing templates...\n")
+		return s.copyDirIfMissing(src, target)
 	}
 
 	// Not exists: initial copy
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would copy templates from %s to %s\n", src, target)
+		s.dryRunf("copy", target, "Would copy templates from %s to %s", src, target)
 		return nil
 	}
-	fmt.Printf("📁 Copying templates to project .anyagent...\n")
-	return copyDir(src, target)
+	s.Logger.Infof("📁 Copying templates to project .anyagent...\n")
+	return s.copyDir(src, target)
 }
 
 // copyDir recursively copies files from src to dst (dst created if missing)
-func copyDir(src, dst string) error {
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+func (s *Session) copyDir(src, dst string) error {
+	return fsys.Walk(s.Env.FS, src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -368,20 +433,20 @@ func copyDir(src, dst string) error {
 		}
 		target := filepath.Join(dst, rel)
 		if info.IsDir() {
-			return os.MkdirAll(target, 0755)
+			return s.Env.FS.MkdirAll(target, 0755)
 		}
 		// Copy file
-		data, err := os.ReadFile(path)
+		data, err := s.Env.FS.ReadFile(path)
 		if err != nil {
 			return err
 		}
-		return os.WriteFile(target, data, 0644)
+		return s.Env.FS.WriteFile(target, data, 0644)
 	})
 }
 
 // copyDirIfMissing copies files from src to dst only if they don't already exist in dst.
-func copyDirIfMissing(src, dst string) error {
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+func (s *Session) copyDirIfMissing(src, dst string) error {
+	return fsys.Walk(s.Env.FS, src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -392,14 +457,14 @@ func copyDirIfMissing(src, dst string) error {
 		target := filepath.Join(dst, rel)
 		if info.IsDir() {
 			// ensure directory exists
-			return os.MkdirAll(target, 0755)
+			return s.Env.FS.MkdirAll(target, 0755)
 		}
-		if _, err := os.Stat(target); os.IsNotExist(err) {
-			data, err := os.ReadFile(path)
+		if _, err := s.Env.FS.Stat(target); os.IsNotExist(err) {
+			data, err := s.Env.FS.ReadFile(path)
 			if err != nil {
 				return err
 			}
-			return os.WriteFile(target, data, 0644)
+			return s.Env.FS.WriteFile(target, data, 0644)
 		}
 		// keep existing file
 		return nil
@@ -407,8 +472,8 @@ func copyDirIfMissing(src, dst string) error {
 }
 
 // listInstalledCommands returns installed command names for Copilot (project) and Q Developer (home)
-func listInstalledCommands(projectDir string) ([]string, []string, error) {
-	available, err := config.GetAvailableCommands()
+func (s *Session) listInstalledCommands(projectDir string) ([]string, []string, error) {
+	available, err := config.GetAvailableCommands(s.Env, projectDir)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get available commands: %w", err)
 	}
@@ -417,19 +482,19 @@ func listInstalledCommands(projectDir string) ([]string, []string, error) {
 	promptsDir := filepath.Join(projectDir, ".github", "prompts")
 	for _, c := range available {
 		p := filepath.Join(promptsDir, fmt.Sprintf("%s.prompt.md", c))
-		if _, err := os.Stat(p); err == nil {
+		if _, err := s.Env.FS.Stat(p); err == nil {
 			copilot = append(copilot, c)
 		}
 	}
 
 	var qdev []string
-	homeDir, err := os.UserHomeDir()
+	homeDir, err := s.Env.UserHomeDir()
 	if err == nil {
 		qdevDir := filepath.Join(homeDir, ".aws", "amazonq", "prompts")
 		for _, c := range available {
 			qname := strings.ReplaceAll(strings.ReplaceAll(c, "-", " "), "_", " ")
 			p := filepath.Join(qdevDir, fmt.Sprintf("%s.md", qname))
-			if _, err := os.Stat(p); err == nil {
+			if _, err := s.Env.FS.Stat(p); err == nil {
 				qdev = append(qdev, c)
 			}
 		}
@@ -439,39 +504,48 @@ func listInstalledCommands(projectDir string) ([]string, []string, error) {
 }
 
 // removeAgentArtifacts removes symlinks and agent-specific files for a deselected agent
-func removeAgentArtifacts(agentName, projectDir string, copilotCmds, qdevCmds, rules []string, dryRun bool) error {
+func (s *Session) removeAgentArtifacts(agentName, projectDir string, copilotCmds, qdevCmds, rules []string, dryRun bool) error {
+	if err := s.removePersonaArtifacts(agentName, projectDir, dryRun); err != nil {
+		return err
+	}
+	if err := s.removeHookArtifacts(agentName, projectDir, dryRun); err != nil {
+		return err
+	}
+	if err := s.removeWorkspaceArtifacts(agentName, projectDir, dryRun); err != nil {
+		return err
+	}
 	switch agentName {
 	case "copilot":
 		// Remove symlink
 		symlinkPath := filepath.Join(projectDir, ".github", "copilot-instructions.md")
-		if err := removePath(symlinkPath, "GitHub Copilot symlink", dryRun); err != nil {
+		if err := s.removePath(symlinkPath, "GitHub Copilot symlink", dryRun); err != nil {
 			return err
 		}
 		// Remove commands
 		for _, cmd := range copilotCmds {
 			path := filepath.Join(projectDir, ".github", "prompts", fmt.Sprintf("%s.prompt.md", cmd))
-			if err := removePath(path, fmt.Sprintf("Copilot command '%s'", cmd), dryRun); err != nil {
+			if err := s.removePath(path, fmt.Sprintf("Copilot command '%s'", cmd), dryRun); err != nil {
 				return err
 			}
 		}
 		// Remove rule instruction files
 		for _, r := range rules {
 			path := filepath.Join(projectDir, ".github", "instructions", fmt.Sprintf("%s.instructions.md", r))
-			if err := removePath(path, fmt.Sprintf("Copilot rule '%s'", r), dryRun); err != nil {
+			if err := s.removePath(path, fmt.Sprintf("Copilot rule '%s'", r), dryRun); err != nil {
 				return err
 			}
 		}
 	case "qdev":
 		symlinkPath := filepath.Join(projectDir, ".amazonq", "rules", "AGENTS.md")
-		if err := removePath(symlinkPath, "Amazon Q Developer symlink", dryRun); err != nil {
+		if err := s.removePath(symlinkPath, "Amazon Q Developer symlink", dryRun); err != nil {
 			return err
 		}
-		homeDir, err := os.UserHomeDir()
+		// Global prompts are shared; only remove the ones no other project uses
+		homeDir, err := s.Env.UserHomeDir()
 		if err == nil {
 			for _, cmd := range qdevCmds {
-				qname := strings.ReplaceAll(strings.ReplaceAll(cmd, "-", " "), "_", " ")
-				path := filepath.Join(homeDir, ".aws", "amazonq", "prompts", fmt.Sprintf("%s.md", qname))
-				if err := removePath(path, fmt.Sprintf("Amazon Q command '%s'", cmd), dryRun); err != nil {
+				path := qdevGlobalPromptPath(homeDir, cmd)
+				if err := s.removeGlobalArtifact(projectDir, path, fmt.Sprintf("Amazon Q command '%s'", cmd), dryRun); err != nil {
 					return err
 				}
 			}
@@ -479,16 +553,16 @@ func removeAgentArtifacts(agentName, projectDir string, copilotCmds, qdevCmds, r
 	case "claude":
 		// Remove CLAUDE.md symlink at project root
 		symlinkPath := filepath.Join(projectDir, "CLAUDE.md")
-		if err := removePath(symlinkPath, "Claude Code symlink", dryRun); err != nil {
+		if err := s.removePath(symlinkPath, "Claude Code symlink", dryRun); err != nil {
 			return err
 		}
 		// Remove Claude command files (project-local)
 		configPath := config.GetProjectConfigPath(projectDir)
-		projectConfig, err := config.LoadProjectConfig(configPath)
+		projectConfig, err := config.LoadProjectConfig(s.Env, configPath)
 		if err == nil {
 			for _, cmd := range projectConfig.InstalledCommands {
 				path := filepath.Join(projectDir, ".claude", "commands", fmt.Sprintf("%s.md", cmd))
-				if err := removePath(path, fmt.Sprintf("Claude command '%s'", cmd), dryRun); err != nil {
+				if err := s.removePath(path, fmt.Sprintf("Claude command '%s'", cmd), dryRun); err != nil {
 					return err
 				}
 			}
@@ -496,11 +570,11 @@ func removeAgentArtifacts(agentName, projectDir string, copilotCmds, qdevCmds, r
 	case "gemini":
 		// Remove Gemini command files (project-local TOML)
 		configPath := config.GetProjectConfigPath(projectDir)
-		projectConfig, err := config.LoadProjectConfig(configPath)
+		projectConfig, err := config.LoadProjectConfig(s.Env, configPath)
 		if err == nil {
 			for _, cmd := range projectConfig.InstalledCommands {
 				path := filepath.Join(projectDir, ".gemini", "commands", fmt.Sprintf("%s.toml", cmd))
-				if err := removePath(path, fmt.Sprintf("Gemini command '%s'", cmd), dryRun); err != nil {
+				if err := s.removePath(path, fmt.Sprintf("Gemini command '%s'", cmd), dryRun); err != nil {
 					return err
 				}
 			}
@@ -508,17 +582,18 @@ func removeAgentArtifacts(agentName, projectDir string, copilotCmds, qdevCmds, r
 	case "codex":
 		// Remove Codex command files based on installed commands in project config
 		configPath := config.GetProjectConfigPath(projectDir)
-		projectConfig, err := config.LoadProjectConfig(configPath)
+		projectConfig, err := config.LoadProjectConfig(s.Env, configPath)
 		if err != nil {
 			return nil // silently skip if no config
 		}
-		homeDir, err := os.UserHomeDir()
+		homeDir, err := s.Env.UserHomeDir()
 		if err != nil {
 			return nil
 		}
+		// Global prompts are shared; only remove the ones no other project uses
 		for _, cmd := range projectConfig.InstalledCommands {
-			path := filepath.Join(homeDir, ".codex", "prompts", fmt.Sprintf("%s.md", cmd))
-			if err := removePath(path, fmt.Sprintf("Codex command '%s'", cmd), dryRun); err != nil {
+			path := codexGlobalPromptPath(homeDir, cmd)
+			if err := s.removeGlobalArtifact(projectDir, path, fmt.Sprintf("Codex command '%s'", cmd), dryRun); err != nil {
 				return err
 			}
 		}
@@ -526,17 +601,49 @@ func removeAgentArtifacts(agentName, projectDir string, copilotCmds, qdevCmds, r
 	return nil
 }
 
-func removePath(path, label string, dryRun bool) error {
-	if _, err := os.Lstat(path); err != nil {
+// removePersonaArtifacts removes the agent's persona files for personas recorded in the project config
+func (s *Session) removePersonaArtifacts(agentName, projectDir string, dryRun bool) error {
+	projectConfig, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
+	if err != nil {
+		return nil // silently skip if no config
+	}
+	for _, name := range projectConfig.InstalledPersonas {
+		path, ok := personaFilePath(agentName, projectDir, name)
+		if !ok {
+			continue
+		}
+		if err := s.removePath(path, fmt.Sprintf("%s persona '%s'", agentName, name), dryRun); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeHookArtifacts unmerges the recorded hooks from the agent's settings file
+func (s *Session) removeHookArtifacts(agentName, projectDir string, dryRun bool) error {
+	projectConfig, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
+	if err != nil {
+		return nil // silently skip if no config
+	}
+	for _, name := range projectConfig.InstalledHooks {
+		if err := s.uninstallHookForAgent(agentName, projectDir, name, dryRun); err != nil {
+			s.warnf("Warning: Could not remove hook '%s' for %s: %v", name, agentName, err)
+		}
+	}
+	return nil
+}
+
+func (s *Session) removePath(path, label string, dryRun bool) error {
+	if _, err := s.Env.FS.Lstat(path); err != nil {
 		// Nothing to remove
 		return nil
 	}
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would remove %s: %s\n", label, path)
+		s.dryRunf("remove", path, "Would remove %s: %s", label, path)
 		return nil
 	}
-	fmt.Printf("🗑️  Removing %s: %s\n", label, path)
-	return os.Remove(path)
+	s.Logger.Infof("🗑️  Removing %s: %s\n", label, path)
+	return s.Env.FS.Remove(path)
 }
 
 // agentsFromNames converts agent names to AIAgent definitions
@@ -555,108 +662,113 @@ func agentsFromNames(names []string) []AIAgent {
 
 // reinstallCommandsForAgent installs command files for the selected agent using the project config list.
 // For Codex and other agents that don't support external commands, do nothing.
-func reinstallCommandsForAgent(agentName, projectDir string, commands []string, dryRun bool) error {
+func (s *Session) reinstallCommandsForAgent(agentName, projectDir string, commands []string, dryRun bool) error {
 	if len(commands) == 0 {
 		return nil
 	}
 	switch agentName {
-	case "copilot":
-		// Ensure prompts dir
-		promptsDir := filepath.Join(projectDir, ".github", "prompts")
-		if err := createPromptsDirectory(promptsDir, dryRun); err != nil {
-			return err
-		}
-		for _, c := range commands {
-			content, err := getCommandTemplate(c)
-			if err != nil {
-				fmt.Printf("⚠️  Warning: Command template not found for '%s': %v\n", c, err)
-				continue
-			}
-			path := filepath.Join(promptsDir, fmt.Sprintf("%s.prompt.md", c))
-			if err := createCommandFile(path, content, dryRun); err != nil {
-				fmt.Printf("⚠️  Warning: Could not create Copilot command '%s': %v\n", c, err)
-			}
-		}
+	case "copilot", "claude", "gemini":
+		return s.installLocalCommands(agentName, projectDir, projectDir, commands, dryRun)
 	case "qdev":
 		// Do not modify global prompts automatically; warn if missing
-		homeDir, err := os.UserHomeDir()
+		homeDir, err := s.Env.UserHomeDir()
 		if err != nil {
 			return nil
 		}
-		dir := filepath.Join(homeDir, ".aws", "amazonq", "prompts")
+		// Installed prompts are registered as used by this project
+		var present []string
 		for _, c := range commands {
-			qname := strings.ReplaceAll(strings.ReplaceAll(c, "-", " "), "_", " ")
-			path := filepath.Join(dir, fmt.Sprintf("%s.md", qname))
-			if _, err := os.Stat(path); os.IsNotExist(err) {
-				fmt.Printf("⚠️  Q Dev global command '%s' not installed. Enable with: anyagent add command %s --global\n", c, c)
+			path := qdevGlobalPromptPath(homeDir, c)
+			if _, err := s.Env.FS.Stat(path); os.IsNotExist(err) {
+				s.warnf("Q Dev global command '%s' not installed. Enable with: anyagent add command %s --global", c, c)
+			} else {
+				present = append(present, path)
 			}
 		}
-	case "claude":
-		dir := filepath.Join(projectDir, ".claude", "commands")
-		if err := createPromptsDirectory(dir, dryRun); err != nil {
-			return err
+		s.acquireGlobalArtifacts(projectDir, present, dryRun)
+	case "codex":
+		// Warn-only for missing Codex global commands
+		homeDir, err := s.Env.UserHomeDir()
+		if err != nil {
+			return nil
 		}
+		// Installed prompts are registered as used by this project
+		var present []string
 		for _, c := range commands {
-			content, err := getCommandTemplate(c)
-			if err != nil {
-				fmt.Printf("⚠️  Warning: Command template not found for '%s': %v\n", c, err)
-				continue
-			}
-			content = buildClaudeCommandContent(content)
-			path := filepath.Join(dir, fmt.Sprintf("%s.md", c))
-			if err := createCommandFile(path, content, dryRun); err != nil {
-				fmt.Printf("⚠️  Warning: Could not create Claude command '%s': %v\n", c, err)
+			path := codexGlobalPromptPath(homeDir, c)
+			if _, err := s.Env.FS.Stat(path); os.IsNotExist(err) {
+				s.warnf("Codex global command '%s' not installed. Enable with: anyagent add command %s --global", c, c)
+			} else {
+				present = append(present, path)
 			}
 		}
+		s.acquireGlobalArtifacts(projectDir, present, dryRun)
+	default:
+		return nil
+	}
+	return nil
+}
+
+// localCommandPath returns where a command file lives under dir for agents with project-local
+// commands; ok is false for the others
+func localCommandPath(agentName, dir, command string) (string, bool) {
+	switch agentName {
+	case "copilot":
+		return filepath.Join(dir, ".github", "prompts", fmt.Sprintf("%s.prompt.md", command)), true
+	case "claude":
+		return filepath.Join(dir, ".claude", "commands", fmt.Sprintf("%s.md", command)), true
 	case "gemini":
-		dir := filepath.Join(projectDir, ".gemini", "commands")
-		if err := createPromptsDirectory(dir, dryRun); err != nil {
-			return err
+		return filepath.Join(dir, ".gemini", "commands", fmt.Sprintf("%s.toml", command)), true
+	}
+	return "", false
+}
+
+// installLocalCommands writes command files for Copilot, Claude or Gemini under targetDir,
+// resolving the command templates from projectDir
+func (s *Session) installLocalCommands(agentName, projectDir, targetDir string, commands []string, dryRun bool) error {
+	for i, c := range commands {
+		path, ok := localCommandPath(agentName, targetDir, c)
+		if !ok {
+			return nil
 		}
-		for _, c := range commands {
-			content, err := getCommandTemplate(c)
-			if err != nil {
-				fmt.Printf("⚠️  Warning: Command template not found for '%s': %v\n", c, err)
-				continue
-			}
-			toml := buildGeminiCommandTOML(content)
-			path := filepath.Join(dir, fmt.Sprintf("%s.toml", c))
-			if err := createCommandFile(path, toml, dryRun); err != nil {
-				fmt.Printf("⚠️  Warning: Could not create Gemini command '%s': %v\n", c, err)
+		if i == 0 {
+			if err := s.createPromptsDirectory(filepath.Dir(path), dryRun); err != nil {
+				return err
 			}
 		}
-	case "codex":
-		// Warn-only for missing Codex global commands
-		homeDir, err := os.UserHomeDir()
+		content, err := s.getCommandTemplate(projectDir, c)
 		if err != nil {
-			return nil
+			s.warnf("Warning: Command template not found for '%s': %v", c, err)
+			continue
 		}
-		dir := filepath.Join(homeDir, ".codex", "prompts")
-		for _, c := range commands {
-			path := filepath.Join(dir, fmt.Sprintf("%s.md", c))
-			if _, err := os.Stat(path); os.IsNotExist(err) {
-				fmt.Printf("⚠️  Codex global command '%s' not installed. Enable with: anyagent add command %s --global\n", c, c)
-			}
+		switch agentName {
+		case "copilot":
+			content = buildCopilotPromptContent(content)
+		case "claude":
+			content = buildClaudeCommandContent(content)
+		case "gemini":
+			content = buildGeminiCommandTOML(content)
+		}
+		if err := s.createCommandFile(path, content, dryRun); err != nil {
+			s.warnf("Warning: Could not create %s command '%s': %v", agentDisplayName(agentName), c, err)
 		}
-	default:
-		return nil
 	}
 	return nil
 }
 
 // RunSwitch changes the active agent, updates symlinks/artifacts, and reinstalls commands
-func RunSwitch(projectDir string, agentName string, dryRun bool) error {
-	fmt.Printf("Switching project agent to: %s\n", agentName)
+func (s *Session) RunSwitch(projectDir string, agentName string, dryRun bool) error {
+	s.Logger.Infof("Switching project agent to: %s\n", agentName)
 
 	if projectDir == "" {
 		var err error
-		projectDir, err = os.Getwd()
+		projectDir, err = s.Env.Getwd()
 		if err != nil {
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 	}
-	if _, err := os.Stat(projectDir); os.IsNotExist(err) {
-		return fmt.Errorf("project directory does not exist: %s", projectDir)
+	if _, err := s.Env.FS.Stat(projectDir); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrProjectNotFound, projectDir)
 	}
 
 	// Validate target agent
@@ -668,7 +780,7 @@ func RunSwitch(projectDir string, agentName string, dryRun bool) error {
 
 	// Load config
 	configPath := config.GetProjectConfigPath(projectDir)
-	projectConfig, err := config.LoadProjectConfig(configPath)
+	projectConfig, err := config.LoadProjectConfig(s.Env, configPath)
 	if err != nil {
 		return fmt.Errorf("failed to load project config: %w", err)
 	}
@@ -681,11 +793,11 @@ func RunSwitch(projectDir string, agentName string, dryRun bool) error {
 
 	// Remove artifacts for previous if different
 	if prev != "" && prev != target.Name {
-		installedCopilotCmds, installedQDevCmds, err := listInstalledCommands(projectDir)
+		installedCopilotCmds, installedQDevCmds, err := s.listInstalledCommands(projectDir)
 		if err != nil {
 			return err
 		}
-		if err := removeAgentArtifacts(prev, projectDir, installedCopilotCmds, installedQDevCmds, projectConfig.InstalledRules, dryRun); err != nil {
+		if err := s.removeAgentArtifacts(prev, projectDir, installedCopilotCmds, installedQDevCmds, projectConfig.InstalledRules, dryRun); err != nil {
 			return fmt.Errorf("failed to remove artifacts for %s: %w", prev, err)
 		}
 	}
@@ -693,24 +805,37 @@ func RunSwitch(projectDir string, agentName string, dryRun bool) error {
 	// Update config
 	if !dryRun {
 		projectConfig.EnabledAgents = []string{target.Name}
-		if err := config.SaveProjectConfig(projectDir, projectConfig); err != nil {
+		if err := config.SaveProjectConfig(s.Env, projectDir, projectConfig); err != nil {
 			return fmt.Errorf("failed to save project configuration: %w", err)
 		}
 	} else {
-		fmt.Printf("[DRY RUN] Would set enabled_agents: [%s]\n", target.Name)
+		s.dryRunf("record", "", "Would set enabled_agents: [%s]", target.Name)
 	}
 
 	// Create symlinks for new agent (if needed)
-	if err := createAgentSymlinks(&InitParams{ProjectDir: projectDir, SelectedAgents: []AIAgent{target}}, dryRun); err != nil {
+	if err := s.createAgentSymlinks(&InitParams{ProjectDir: projectDir, SelectedAgents: []AIAgent{target}}, dryRun); err != nil {
 		return fmt.Errorf("failed to create agent symlinks: %w", err)
 	}
+	if err := s.syncWorkspaces(projectDir, projectConfig, []AIAgent{target}, dryRun, false); err != nil {
+		return fmt.Errorf("failed to sync workspaces: %w", err)
+	}
 
 	// Reinstall commands for the new agent
-	if err := reinstallCommandsForAgent(target.Name, projectDir, projectConfig.InstalledCommands, dryRun); err != nil {
-		fmt.Printf("⚠️  Warning: Failed to reinstall commands for agent %s: %v\n", target.Name, err)
+	if err := s.reinstallCommandsForAgent(target.Name, projectDir, projectConfig.InstalledCommands, dryRun); err != nil {
+		s.warnf("Warning: Failed to reinstall commands for agent %s: %v", target.Name, err)
 	}
 
-	fmt.Printf("✅ Switched to %s\n", target.DisplayName)
+	// Reinstall personas for the new agent
+	if err := s.reinstallPersonasForAgent(target.Name, projectDir, projectConfig.InstalledPersonas, dryRun); err != nil {
+		s.warnf("Warning: Failed to reinstall personas for agent %s: %v", target.Name, err)
+	}
+
+	// Reinstall hooks for the new agent
+	if err := s.reinstallHooksForAgent(target.Name, projectDir, projectConfig.InstalledHooks, dryRun); err != nil {
+		s.warnf("Warning: Failed to reinstall hooks for agent %s: %v", target.Name, err)
+	}
+
+	s.Logger.Infof("✅ Switched to %s\n", target.DisplayName)
 	return nil
 }
 
@@ -731,21 +856,24 @@ func validateAgentNames(agentNames []string) ([]AIAgent, error) {
 			}
 		}
 		if !found {
-			return nil, fmt.Errorf("unsupported agent: %s", name)
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedAgent, name)
 		}
 	}
 	return selectedAgents, nil
 }
 
 // selectAgentsWizard runs an interactive wizard to select AI agents
-func selectAgentsWizard() ([]AIAgent, error) {
-	reader := bufio.NewReader(os.Stdin)
+func (s *Session) selectAgentsWizard() ([]AIAgent, error) {
+	if !s.Interactive {
+		return nil, errorOf(ErrInputRequired, "no agent is enabled; choose one of copilot, qdev, claude, gemini, codex")
+	}
+	reader := s.reader()
 	for {
-		fmt.Printf("\nSelect one AI agent to configure (enter number or name):\n")
+		s.Logger.Printf("\nSelect one AI agent to configure (enter number or name):\n")
 		for i, agent := range SupportedAgents {
-			fmt.Printf("  %d. %s (%s)\n", i+1, agent.DisplayName, agent.Name)
+			s.Logger.Printf("  %d. %s (%s)\n", i+1, agent.DisplayName, agent.Name)
 		}
-		fmt.Printf("Enter your selection: ")
+		s.Logger.Printf("Enter your selection: ")
 
 		input, err := reader.ReadString('\n')
 		if err != nil {
@@ -753,7 +881,7 @@ func selectAgentsWizard() ([]AIAgent, error) {
 		}
 		input = strings.TrimSpace(input)
 		if input == "" {
-			fmt.Printf("No selection. Please try again.\n")
+			s.Logger.Printf("No selection. Please try again.\n")
 			continue
 		}
 
@@ -763,7 +891,7 @@ func selectAgentsWizard() ([]AIAgent, error) {
 			if index >= 1 && index <= len(SupportedAgents) {
 				return []AIAgent{SupportedAgents[index-1]}, nil
 			}
-			fmt.Printf("Selection out of range: %d. Please try again.\n", index)
+			s.Logger.Printf("Selection out of range: %d. Please try again.\n", index)
 			continue
 		}
 
@@ -774,16 +902,19 @@ func selectAgentsWizard() ([]AIAgent, error) {
 				return []AIAgent{agent}, nil
 			}
 		}
-		fmt.Printf("Unsupported agent: %s. Please try again.\n", input)
+		s.Logger.Printf("Unsupported agent: %s. Please try again.\n", input)
 	}
 }
 
 // getProjectParameters prompts for project parameters
-func getProjectParameters(params *InitParams) error {
-	reader := bufio.NewReader(os.Stdin)
+func (s *Session) getProjectParameters(params *InitParams) error {
+	if !s.Interactive {
+		return errorOf(ErrInputRequired, "project name is required for a new project")
+	}
+	reader := s.reader()
 
 	// Get project name
-	fmt.Printf("\nEnter project name: ")
+	s.Logger.Printf("\nEnter project name: ")
 	projectName, err := reader.ReadString('\n')
 	if err != nil {
 		return fmt.Errorf("failed to read project name: %w", err)
@@ -795,7 +926,7 @@ func getProjectParameters(params *InitParams) error {
 	}
 
 	// Get project description
-	fmt.Printf("Enter project description: ")
+	s.Logger.Printf("Enter project description: ")
 	projectDesc, err := reader.ReadString('\n')
 	if err != nil {
 		return fmt.Errorf("failed to read project description: %w", err)
@@ -810,7 +941,7 @@ func getProjectParameters(params *InitParams) error {
 }
 
 // createAgentsFile creates the AGENTS.md file with populated parameters
-func createAgentsFile(params *InitParams, dryRun bool) error {
+func (s *Session) createAgentsFile(params *InitParams, dryRun bool) error {
 	// Get the template content
 	template := config.GetAGENTSTemplate()
 
@@ -820,21 +951,21 @@ func createAgentsFile(params *InitParams, dryRun bool) error {
 	agentsPath := filepath.Join(params.ProjectDir, "AGENTS.md")
 
 	if dryRun {
-		fmt.Printf("[DRY RUN] Would create AGENTS.md at: %s\n", agentsPath)
-		fmt.Printf("[DRY RUN] Content preview:\n")
+		s.dryRunf("write", agentsPath, "Would create AGENTS.md at: %s", agentsPath)
+		s.Logger.Infof("[DRY RUN] Content preview:\n")
 		lines := strings.Split(content, "\n")
 		for i, line := range lines {
 			if i >= 10 {
-				fmt.Printf("  ... (truncated)\n")
+				s.Logger.Infof("  ... (truncated)\n")
 				break
 			}
-			fmt.Printf("  %s\n", line)
+			s.Logger.Infof("  %s\n", line)
 		}
 		return nil
 	}
 
-	fmt.Printf("📄 Creating AGENTS.md...\n")
-	if err := os.WriteFile(agentsPath, []byte(content), 0644); err != nil {
+	s.Logger.Infof("📄 Creating AGENTS.md...\n")
+	if err := s.Env.FS.WriteFile(agentsPath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write AGENTS.md: %w", err)
 	}
 
@@ -842,7 +973,7 @@ func createAgentsFile(params *InitParams, dryRun bool) error {
 }
 
 // createAgentSymlinks creates symlinks for selected agents
-func createAgentSymlinks(params *InitParams, dryRun bool) error {
+func (s *Session) createAgentSymlinks(params *InitParams, dryRun bool) error {
 	agentsPath := filepath.Join(params.ProjectDir, "AGENTS.md")
 
 	for _, agent := range params.SelectedAgents {
@@ -852,20 +983,20 @@ func createAgentSymlinks(params *InitParams, dryRun bool) error {
 
 		// Create the directory for the agent config if it doesn't exist
 		configDir := filepath.Dir(filepath.Join(params.ProjectDir, agent.ConfigPath))
-		if err := os.MkdirAll(configDir, 0755); err != nil && !dryRun {
+		if err := s.Env.FS.MkdirAll(configDir, 0755); err != nil && !dryRun {
 			return fmt.Errorf("failed to create directory %s: %w", configDir, err)
 		}
 
 		symlinkPath := filepath.Join(params.ProjectDir, agent.ConfigPath)
 
 		if dryRun {
-			fmt.Printf("[DRY RUN] Would create symlink: %s -> AGENTS.md\n", symlinkPath)
+			s.dryRunf("symlink", symlinkPath, "Would create symlink: %s -> AGENTS.md", symlinkPath)
 			continue
 		}
 
 		// Remove existing file/symlink if it exists
-		if _, err := os.Lstat(symlinkPath); err == nil {
-			if err := os.Remove(symlinkPath); err != nil {
+		if _, err := s.Env.FS.Lstat(symlinkPath); err == nil {
+			if err := s.Env.FS.Remove(symlinkPath); err != nil {
 				return fmt.Errorf("failed to remove existing file %s: %w", symlinkPath, err)
 			}
 		}
@@ -876,18 +1007,18 @@ func createAgentSymlinks(params *InitParams, dryRun bool) error {
 			return fmt.Errorf("failed to calculate relative path: %w", err)
 		}
 
-		fmt.Printf("🔗 Creating symlink for %s: %s -> %s\n", agent.DisplayName, agent.ConfigPath, relPath)
-		if err := os.Symlink(relPath, symlinkPath); err != nil {
+		s.Logger.Infof("🔗 Creating symlink for %s: %s -> %s\n", agent.DisplayName, agent.ConfigPath, relPath)
+		if err := s.Env.FS.Symlink(relPath, symlinkPath); err != nil {
 			return fmt.Errorf("failed to create symlink %s: %w", symlinkPath, err)
 		}
 	}
 
 	// Also ensure MCP symlink for enabled agents to project mcp.yaml (if any)
 	// This keeps MCP wiring consistent when switching agents.
-	cfg, err := config.LoadProjectConfig(config.GetProjectConfigPath(params.ProjectDir))
+	cfg, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(params.ProjectDir))
 	if err == nil && len(cfg.MCPServers) > 0 {
 		for _, agent := range params.SelectedAgents {
-			if err := ensureMCPFilesForAgent(agent.Name, params.ProjectDir, cfg.MCPServers, dryRun); err != nil {
+			if err := s.ensureMCPFilesForAgent(agent.Name, params.ProjectDir, cfg.MCPServers, dryRun); err != nil {
 				return err
 			}
 		}
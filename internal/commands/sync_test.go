@@ -8,6 +8,7 @@ import (
 )
 
 func TestRunFirstSync(t *testing.T) {
+	s := newTestSession()
 	tests := []struct {
 		name        string
 		agentNames  []string
@@ -36,7 +37,7 @@ func TestRunFirstSync(t *testing.T) {
 			tempDir := t.TempDir()
 
 			// Run first sync with dry-run and predefined parameters to avoid interactive prompts
-			err := RunFirstSyncWithParams(tempDir, tt.agentNames, "test-project", "Test project description", true)
+			err := s.RunFirstSyncWithParams(tempDir, tt.agentNames, "test-project", "Test project description", true)
 
 			if tt.expectError && err == nil {
 				t.Errorf("Expected error but got none")
@@ -99,6 +100,7 @@ func TestValidateAgentNames(t *testing.T) {
 }
 
 func TestCreateAgentsFile(t *testing.T) {
+	s := newTestSession()
 	// Create a temporary directory for testing (auto-clean)
 	tempDir := t.TempDir()
 	var err error
@@ -116,7 +118,7 @@ func TestCreateAgentsFile(t *testing.T) {
 	}
 
 	// Test dry run
-	err = createAgentsFile(params, true)
+	err = s.createAgentsFile(params, true)
 	if err != nil {
 		t.Errorf("Dry run failed: %v", err)
 	}
@@ -128,7 +130,7 @@ func TestCreateAgentsFile(t *testing.T) {
 	}
 
 	// Test actual creation
-	err = createAgentsFile(params, false)
+	err = s.createAgentsFile(params, false)
 	if err != nil {
 		t.Errorf("File creation failed: %v", err)
 	}
@@ -153,6 +155,7 @@ func TestCreateAgentsFile(t *testing.T) {
 }
 
 func TestCreateAgentSymlinks(t *testing.T) {
+	s := newTestSession()
 	// Create a temporary directory for testing (auto-clean)
 	tempDir := t.TempDir()
 	var err error
@@ -185,13 +188,13 @@ func TestCreateAgentSymlinks(t *testing.T) {
 	}
 
 	// Test dry run
-	err = createAgentSymlinks(params, true)
+	err = s.createAgentSymlinks(params, true)
 	if err != nil {
 		t.Errorf("Dry run failed: %v", err)
 	}
 
 	// Test actual creation (no-op for Copilot now)
-	err = createAgentSymlinks(params, false)
+	err = s.createAgentSymlinks(params, false)
 	if err != nil {
 		t.Errorf("createAgentSymlinks should not fail when symlink not needed: %v", err)
 	}
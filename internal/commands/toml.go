@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// TOML documents anyagent imports and edits (~/.codex/config.toml) are read with go-toml.
+// Edits are made on the original text, located by the statement positions scanToml reports.
+
+// tomlStatement is a table header or key/value pair of a document, located by byte offsets
+// so the document can be edited without reformatting unrelated lines
+type tomlStatement struct {
+	Path   []string // table path of a header, full key path of a key/value pair
+	Header bool
+	Inline bool // the value of a key/value pair is an inline table
+	Start  int  // start of the first line
+	End    int  // end of the last line, including its newline
+}
+
+// decodeToml parses a TOML document into nested map[string]any values
+func decodeToml(src string) (map[string]any, error) {
+	doc := map[string]any{}
+	if err := toml.Unmarshal([]byte(src), &doc); err != nil {
+		return nil, tomlError(err)
+	}
+	return doc, nil
+}
+
+// scanToml parses a TOML document and returns its statements in order
+func scanToml(src string) ([]tomlStatement, error) {
+	// The parser only checks the syntax; decoding also rejects redefined keys and tables
+	if _, err := decodeToml(src); err != nil {
+		return nil, err
+	}
+	data := []byte(src)
+	p := &unstable.Parser{}
+	p.Reset(data)
+	var statements []tomlStatement
+	var tablePath []string
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			path, start := tomlKeyPath(expr.Key())
+			tablePath = path
+			statements = append(statements, tomlStatement{
+				Path:   path,
+				Header: true,
+				Start:  lineStart(src, start),
+				End:    lineEnd(src, start),
+			})
+		case unstable.KeyValue:
+			path, _ := tomlKeyPath(expr.Key())
+			start := int(expr.Raw.Offset)
+			statements = append(statements, tomlStatement{
+				Path:   append(append([]string{}, tablePath...), path...),
+				Inline: expr.Value().Kind == unstable.InlineTable,
+				Start:  lineStart(src, start),
+				End:    lineEnd(src, start+int(expr.Raw.Length)),
+			})
+		}
+	}
+	if err := p.Error(); err != nil {
+		return nil, tomlError(err)
+	}
+	return statements, nil
+}
+
+// tomlKeyPath returns the parts of a (dotted) key and the offset of its first part
+func tomlKeyPath(it unstable.Iterator) ([]string, int) {
+	var path []string
+	start := -1
+	for it.Next() {
+		n := it.Node()
+		path = append(path, string(n.Data))
+		if start < 0 {
+			start = int(n.Raw.Offset)
+		}
+	}
+	return path, start
+}
+
+// lineStart returns the start of the line holding offset
+func lineStart(src string, offset int) int {
+	return strings.LastIndexByte(src[:offset], '\n') + 1
+}
+
+// lineEnd returns the end of the line holding offset, including its newline
+func lineEnd(src string, offset int) int {
+	if i := strings.IndexByte(src[offset:], '\n'); i >= 0 {
+		return offset + i + 1
+	}
+	return len(src)
+}
+
+// tomlError adds the line and column to a go-toml decoding error
+func tomlError(err error) error {
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		row, col := decodeErr.Position()
+		return fmt.Errorf("line %d, column %d: %w", row, col, err)
+	}
+	return err
+}
@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeToml(t *testing.T) {
+	src := `# Codex config
+model = "o3"
+approval_policy = 'on-request' # trailing comment
+
+[mcp_servers.context7]
+command = "npx"
+args = [
+  "-y",
+  "@upstash/context7-mcp", # comment inside array
+]
+env = { API_KEY = "${env:KEY}", "odd key" = 'x' }
+startup_timeout_sec = 20
+
+[mcp_servers."my.server"]
+url = "https://example.com/mcp"
+enabled = false
+
+[mcp_servers."my.server".http_headers]
+X-Team = "platform"
+
+[[profiles]]
+name = "a"
+[[profiles]]
+name = "b"
+
+[notes]
+text = """
+line1 \
+  continued"""
+escaped = "tab\tquote\" é"
+dotted.key = 1.5
+`
+	got, err := decodeToml(src)
+	if err != nil {
+		t.Fatalf("decodeToml failed: %v", err)
+	}
+	want := map[string]any{
+		"model":           "o3",
+		"approval_policy": "on-request",
+		"mcp_servers": map[string]any{
+			"context7": map[string]any{
+				"command":             "npx",
+				"args":                []any{"-y", "@upstash/context7-mcp"},
+				"env":                 map[string]any{"API_KEY": "${env:KEY}", "odd key": "x"},
+				"startup_timeout_sec": int64(20),
+			},
+			"my.server": map[string]any{
+				"url":          "https://example.com/mcp",
+				"enabled":      false,
+				"http_headers": map[string]any{"X-Team": "platform"},
+			},
+		},
+		"profiles": []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}},
+		"notes": map[string]any{
+			"text":    "line1 continued",
+			"escaped": "tab\tquote\" é",
+			"dotted":  map[string]any{"key": 1.5},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeToml mismatch\n got: %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestDecodeTomlErrors(t *testing.T) {
+	for _, src := range []string{
+		"a = ",
+		"a = \"unterminated",
+		"a = 1\na = 2",
+		"[t\nx = 1",
+		"a = [1, 2",
+		"a = 1 b = 2",
+	} {
+		if _, err := decodeToml(src); err == nil {
+			t.Errorf("decodeToml(%q) should fail", src)
+		}
+	}
+}
+
+// TestTomlConformance decodes and scans TOML forms a hand-edited config.toml may use
+func TestTomlConformance(t *testing.T) {
+	docs := map[string]string{
+		"offset datetime": "created = 1979-05-27 07:32:00Z\nupdated = 1979-05-27T00:32:00.999999-07:00\n",
+		"local datetime":  "at = 1979-05-27T07:32:00\n",
+		"local date":      "day = 1979-05-27\n",
+		"local time":      "time = 07:32:00\n",
+		"integers":        "hex = 0xDEAD_beef\noct = 0o755\nbin = 0b1101\nbig = 1_000_000\n",
+		"floats":          "inf = -inf\nnan = nan\nexp = 6.626e-34\n",
+		"strings":         "lit = '''\nC:\\path\n'''\nbasic = \"\\u00e9\\U0001F600\"\n",
+		"crlf":            "[mcp_servers.fs]\r\ncommand = \"npx\"\r\n",
+		"nested arrays":   "[[fruits]]\nname = \"apple\"\n[[fruits.varieties]]\nname = \"red\"\n",
+		"whitespace":      "[ mcp_servers . \"a b\" ]\n\tcommand\t=\t\"x\"\n",
+	}
+	for name, src := range docs {
+		if _, err := decodeToml(src); err != nil {
+			t.Errorf("%s: decodeToml failed: %v", name, err)
+		}
+		if _, err := scanToml(src); err != nil {
+			t.Errorf("%s: scanToml failed: %v", name, err)
+		}
+	}
+
+	doc, _ := decodeToml(docs["offset datetime"])
+	if created, ok := doc["created"].(time.Time); !ok || !created.Equal(time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC)) {
+		t.Errorf("unexpected datetime: %#v", doc["created"])
+	}
+}
+
+func TestScanToml(t *testing.T) {
+	src := "model = \"o3\" # comment\n\n# the server\n[mcp_servers.fs]\nargs = [\n  \"-y\",\n]\nenv = { A = \"1\" }\n"
+	statements, err := scanToml(src)
+	if err != nil {
+		t.Fatalf("scanToml failed: %v", err)
+	}
+	var got []string
+	for _, st := range statements {
+		got = append(got, strings.Join(st.Path, ".")+"|"+src[st.Start:st.End])
+	}
+	want := []string{
+		"model|model = \"o3\" # comment\n",
+		"mcp_servers.fs|[mcp_servers.fs]\n",
+		"mcp_servers.fs.args|args = [\n  \"-y\",\n]\n",
+		"mcp_servers.fs.env|env = { A = \"1\" }\n",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected statements\n got: %q\nwant: %q", got, want)
+	}
+	if !statements[1].Header || !statements[3].Inline || statements[2].Inline {
+		t.Errorf("unexpected statement kinds: %+v", statements)
+	}
+}
+
+func TestTomlErrorPosition(t *testing.T) {
+	_, err := decodeToml("a = 1\nb = \n")
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected the error to name line 2, got %v", err)
+	}
+}
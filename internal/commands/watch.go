@@ -0,0 +1,343 @@
+package commands
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/internal/fsys"
+	"github.com/shibukawa/anyagent/internal/logging"
+)
+
+// WatchOptions tunes 'sync --watch'
+type WatchOptions struct {
+	Interval time.Duration // how often the templates are scanned (default 300ms)
+	Debounce time.Duration // quiet time after the last change before regenerating (default 500ms)
+}
+
+const (
+	defaultWatchInterval = 300 * time.Millisecond
+	defaultWatchDebounce = 500 * time.Millisecond
+)
+
+// RunSyncWatch syncs the project, then keeps watching .anyagent/ (including config.yaml) and
+// the user templates. After each burst of changes it regenerates only the affected artifacts
+// and prints one line with the files that changed. It returns when ctx is done.
+func (s *Session) RunSyncWatch(ctx context.Context, projectDir string, agentNames []string, force bool, opts WatchOptions) error {
+	if projectDir == "" {
+		var err error
+		projectDir, err = s.Env.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+	if err := s.RunSyncWithOptions(projectDir, agentNames, false, force); err != nil {
+		return err
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultWatchInterval
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = defaultWatchDebounce
+	}
+
+	w := s.newTemplateWatcher(projectDir)
+	var roots []string
+	for _, root := range w.roots {
+		roots = append(roots, w.display(s, root))
+	}
+	s.Logger.Printf("👀 Watching %s (Ctrl+C to stop)\n", strings.Join(roots, ", "))
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	var pending []string
+	var lastChange time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			s.Logger.Infof("Stopped watching\n")
+			return nil
+		case now := <-ticker.C:
+			if changed := w.scan(s); len(changed) > 0 {
+				for _, path := range changed {
+					if !slices.Contains(pending, path) {
+						pending = append(pending, path)
+					}
+				}
+				lastChange = now
+			}
+			if len(pending) > 0 && now.Sub(lastChange) >= opts.Debounce {
+				w.regenerate(s, pending)
+				pending = nil
+			}
+		}
+	}
+}
+
+// templateWatcher remembers the content of the watched files between scans
+type templateWatcher struct {
+	projectDir string
+	anyagent   string // <project>/.anyagent
+	userDir    string // <user config dir>/anyagent
+	roots      []string
+	hashes     map[string][sha256.Size]byte
+	config     *config.ProjectConfig // project config at the last regeneration
+}
+
+func (s *Session) newTemplateWatcher(projectDir string) *templateWatcher {
+	w := &templateWatcher{projectDir: projectDir, anyagent: filepath.Join(projectDir, ".anyagent")}
+	w.roots = []string{w.anyagent}
+	if userDir, err := config.GetUserConfigDir(s.Env); err == nil {
+		w.userDir = userDir
+		w.roots = append(w.roots, filepath.Join(userDir, "templates"))
+	}
+	w.scan(s)
+	w.config = w.loadConfig(s)
+	return w
+}
+
+// scan hashes the watched files and returns the ones added, modified or removed since the
+// previous scan
+func (w *templateWatcher) scan(s *Session) []string {
+	hashes := map[string][sha256.Size]byte{}
+	for _, root := range w.roots {
+		_ = fsys.Walk(s.Env.FS, root, func(path string, info fs.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+			if b, err := s.Env.FS.ReadFile(path); err == nil {
+				hashes[path] = sha256.Sum256(b)
+			}
+			return nil
+		})
+	}
+	var changed []string
+	for path, h := range hashes {
+		if old, ok := w.hashes[path]; !ok || old != h {
+			changed = append(changed, path)
+		}
+	}
+	for path := range w.hashes {
+		if _, ok := hashes[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	w.hashes = hashes
+	return changed
+}
+
+func (w *templateWatcher) loadConfig(s *Session) *config.ProjectConfig {
+	pc, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(w.projectDir))
+	if err != nil {
+		return &config.ProjectConfig{}
+	}
+	return pc
+}
+
+// watchPlan is what a set of changed files requires regenerating
+type watchPlan struct {
+	full     bool     // everything, as 'anyagent sync' does
+	agents   bool     // AGENTS.md, nested AGENTS.md and Copilot instructions
+	mcp      bool     // mcp.yaml and the agents' MCP configs
+	commands []string // command files; allCommands for every installed command
+	removed  []string // commands no longer installed, whose files are deleted
+	rules    []string // per-rule files of Copilot and Amazon Q Developer
+}
+
+const allCommands = "*"
+
+// plan decides what the changed files affect
+func (w *templateWatcher) plan(s *Session, changed []string) watchPlan {
+	var p watchPlan
+	for _, path := range changed {
+		rel, ok := relativeTo(w.anyagent, path)
+		if !ok {
+			rel, _ = relativeTo(filepath.Join(w.userDir, "templates"), path)
+		} else if rel == "config.yaml" {
+			w.planConfig(&p, w.loadConfig(s))
+			continue
+		}
+		switch dir, name := filepath.Split(rel); {
+		case rel == "AGENTS.md.tmpl":
+			p.agents = true
+		case dir == "extra_rules/":
+			p.agents = true
+			if rule, ok := ruleOfTemplate(name); ok {
+				p.rules = append(p.rules, rule)
+			}
+		case dir == "commands/":
+			p.commands = append(p.commands, strings.TrimSuffix(name, ".md"))
+		case rel == "mcp.yaml":
+			// The MCP catalog only matters to 'add mcp --preset'
+		case rel == "installed-hooks.json":
+			// Written by anyagent itself when hooks are installed
+		default:
+			p.full = true
+		}
+	}
+	return p
+}
+
+// planConfig compares the project config with the one of the last regeneration
+func (w *templateWatcher) planConfig(p *watchPlan, cur *config.ProjectConfig) {
+	old := w.config
+	switch {
+	case !slices.Equal(old.EnabledAgents, cur.EnabledAgents),
+		!slices.Equal(old.InstalledPersonas, cur.InstalledPersonas),
+		!slices.Equal(old.InstalledHooks, cur.InstalledHooks):
+		p.full = true
+	}
+	if old.ProjectName != cur.ProjectName || old.ProjectDescription != cur.ProjectDescription ||
+		!reflect.DeepEqual(old.Parameters, cur.Parameters) || !slices.Equal(old.InstalledRules, cur.InstalledRules) ||
+		!reflect.DeepEqual(old.Workspaces, cur.Workspaces) {
+		p.agents = true
+	}
+	if !slices.Equal(old.InstalledCommands, cur.InstalledCommands) {
+		p.commands = append(p.commands, allCommands)
+		for _, c := range old.InstalledCommands {
+			if !slices.Contains(cur.InstalledCommands, c) {
+				p.removed = append(p.removed, c)
+			}
+		}
+	}
+	if !reflect.DeepEqual(old.MCPServers, cur.MCPServers) {
+		p.mcp = true
+	}
+}
+
+// regenerate applies the plan for the changed files without prompts, recording the files it
+// changes, and prints them on one line
+func (w *templateWatcher) regenerate(s *Session, changed []string) {
+	recorder, err := w.applyRecorded(s, w.plan(s, changed))
+
+	// Our own writes (e.g. config.yaml) are not changes to react to
+	w.scan(s)
+	w.config = w.loadConfig(s)
+
+	trigger := w.display(s, changed[0])
+	if len(changed) > 1 {
+		trigger = fmt.Sprintf("%d files", len(changed))
+	}
+	if err != nil {
+		s.warnf("Regeneration after %s changed failed: %v", trigger, err)
+		return
+	}
+	var outputs []string
+	for _, path := range recorder.Changed() {
+		if _, ok := relativeTo(w.anyagent, path); ok {
+			continue
+		}
+		if _, ok := relativeTo(w.userDir, path); ok && w.userDir != "" {
+			continue
+		}
+		outputs = append(outputs, w.display(s, path))
+	}
+	if len(outputs) == 0 {
+		s.Logger.Printf("🔄 %s changed: no files changed\n", trigger)
+		return
+	}
+	s.Logger.Printf("🔄 %s changed: %s\n", trigger, strings.Join(outputs, ", "))
+}
+
+// applyRecorded applies the plan in a session derived from s: quiet unless s is verbose,
+// without prompts and on a file system that records what changes. s is left as it is; only
+// the warnings are passed on to it.
+func (w *templateWatcher) applyRecorded(s *Session, p watchPlan) (*fsys.Recorder, error) {
+	recorder := fsys.NewRecorder(s.Env.FS)
+	env := *s.Env
+	env.FS = recorder
+	rs := &Session{Logger: s.Logger, Env: &env}
+	if rs.Logger.Level() < logging.LevelVerbose {
+		rs.Logger = rs.Logger.WithLevel(logging.LevelQuiet)
+	}
+	err := w.apply(rs, p)
+	s.warnings = append(s.warnings, rs.warnings...)
+	return recorder, err
+}
+
+// apply regenerates the artifacts in the plan
+func (w *templateWatcher) apply(s *Session, p watchPlan) error {
+	pc := w.loadConfig(s)
+	// Sync only writes the installed commands, so the files of removed ones are deleted here
+	if len(pc.EnabledAgents) == 1 {
+		for _, c := range p.removed {
+			if err := s.removeCommandForAgent(pc.EnabledAgents[0], w.projectDir, c, false); err != nil {
+				return fmt.Errorf("failed to remove command '%s': %w", c, err)
+			}
+		}
+	}
+	if p.full {
+		return s.RunSyncWithOptions(w.projectDir, nil, false, false)
+	}
+	if p.agents {
+		if err := pc.RegenerateAgentsFileAt(s.Env, w.projectDir); err != nil {
+			return fmt.Errorf("failed to regenerate AGENTS.md: %w", err)
+		}
+		if err := s.syncWorkspaces(w.projectDir, pc, agentsFromNames(pc.EnabledAgents), false, false); err != nil {
+			return fmt.Errorf("failed to sync workspaces: %w", err)
+		}
+	}
+	for _, rule := range p.rules {
+		if !slices.Contains(pc.InstalledRules, rule) {
+			continue
+		}
+		content, err := s.getRuleTemplate(w.projectDir, rule)
+		if err != nil {
+			return fmt.Errorf("failed to get rule template: %w", err)
+		}
+		if err := s.writeRuleFiles(w.projectDir, rule, content, false); err != nil {
+			return err
+		}
+	}
+	if len(p.commands) > 0 && len(pc.EnabledAgents) == 1 {
+		var commands []string
+		for _, c := range pc.InstalledCommands {
+			if slices.Contains(p.commands, allCommands) || slices.Contains(p.commands, c) {
+				commands = append(commands, c)
+			}
+		}
+		if err := s.reinstallCommandsForAgent(pc.EnabledAgents[0], w.projectDir, commands, false); err != nil {
+			return fmt.Errorf("failed to reinstall commands: %w", err)
+		}
+	}
+	if p.mcp {
+		if err := s.writeOrUpdateProjectMCP(w.projectDir, pc.MCPServers, false); err != nil {
+			return err
+		}
+		if err := s.ensureMCPFilesForEnabledAgents(w.projectDir, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// display shortens path relative to the project or the home directory
+func (w *templateWatcher) display(s *Session, path string) string {
+	if rel, ok := relativeTo(w.projectDir, path); ok {
+		return filepath.ToSlash(rel)
+	}
+	if home, err := s.Env.UserHomeDir(); err == nil {
+		if rel, ok := relativeTo(home, path); ok {
+			return "~/" + filepath.ToSlash(rel)
+		}
+	}
+	return path
+}
+
+// relativeTo returns path relative to dir (with slashes) when it is inside dir
+func relativeTo(dir, path string) (string, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
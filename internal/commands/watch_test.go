@@ -0,0 +1,251 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/internal/fsys"
+	"github.com/shibukawa/anyagent/internal/logging"
+)
+
+// lockedBuffer is a bytes.Buffer the watch loop can write while the test reads it
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRunSyncWatch(t *testing.T) {
+	projectDir := "/work/app"
+	mem := fsys.Memory(projectDir, "/home/dev")
+	var out lockedBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The steps run while the watch loop runs; the first missing line is reported afterwards
+	var missing string
+	waitFor := func(want string) bool {
+		deadline := time.Now().Add(5 * time.Second)
+		for !strings.Contains(out.String(), want) {
+			if time.Now().After(deadline) {
+				missing = want
+				return false
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return true
+	}
+	go func() {
+		defer cancel()
+		if !waitFor("Watching") {
+			return
+		}
+		_ = mem.FS.WriteFile(filepath.Join(projectDir, ".anyagent", "AGENTS.md.tmpl"), []byte("# {{PROJECT_NAME}} (watched)\n"), 0644)
+		if !waitFor("🔄 .anyagent/AGENTS.md.tmpl changed: AGENTS.md\n") {
+			return
+		}
+		_ = mem.FS.MkdirAll(filepath.Join(projectDir, ".anyagent", "commands"), 0755)
+		_ = mem.FS.WriteFile(filepath.Join(projectDir, ".anyagent", "commands", "review-code.md"), []byte("Review it.\n"), 0644)
+		waitFor("🔄 .anyagent/commands/review-code.md changed: .claude/commands/review-code.md\n")
+	}()
+
+	_, err := RunSession(Session{Env: mem, Logger: logging.New(&out, &out, logging.Options{})}, func(s *Session) error {
+		pc := &config.ProjectConfig{
+			ProjectName:        "app",
+			ProjectDescription: "demo",
+			EnabledAgents:      []string{"claude"},
+			InstalledCommands:  []string{"review-code"},
+			Parameters:         map[string]string{"PRIMARY_LANGUAGE": "Go", "TEAM_NAME": "core"},
+		}
+		if err := config.SaveProjectConfig(s.Env, projectDir, pc); err != nil {
+			return err
+		}
+		return s.RunSyncWatch(ctx, projectDir, nil, false, WatchOptions{Interval: 10 * time.Millisecond, Debounce: 30 * time.Millisecond})
+	})
+	if err != nil {
+		t.Fatalf("RunSyncWatch failed: %v", err)
+	}
+	if missing != "" {
+		t.Fatalf("timed out waiting for %q; output:\n%s", missing, out.String())
+	}
+
+	b, err := mem.FS.ReadFile(filepath.Join(projectDir, "AGENTS.md"))
+	if err != nil || string(b) != "# app (watched)\n" {
+		t.Errorf("AGENTS.md = %q, %v", b, err)
+	}
+	if strings.Count(out.String(), "🔄") != 2 {
+		t.Errorf("expected one line per regeneration, got:\n%s", out.String())
+	}
+}
+
+func TestTemplateWatcherPlan(t *testing.T) {
+	s := newTestSession()
+	w := &templateWatcher{
+		projectDir: "/p",
+		anyagent:   "/p/.anyagent",
+		userDir:    "/home/dev/.config/anyagent",
+		config:     &config.ProjectConfig{EnabledAgents: []string{"claude"}},
+	}
+	tests := []struct {
+		path string
+		want watchPlan
+	}{
+		{"/p/.anyagent/AGENTS.md.tmpl", watchPlan{agents: true}},
+		{"/home/dev/.config/anyagent/templates/extra_rules/go.md", watchPlan{agents: true, rules: []string{"go"}}},
+		{"/p/.anyagent/commands/review.md", watchPlan{commands: []string{"review"}}},
+		{"/home/dev/.config/anyagent/templates/mcp.yaml", watchPlan{}},
+		{"/p/.anyagent/agents/reviewer.md", watchPlan{full: true}},
+	}
+	for _, tt := range tests {
+		got := w.plan(s, []string{tt.path})
+		if got.full != tt.want.full || got.agents != tt.want.agents || got.mcp != tt.want.mcp || strings.Join(got.commands, ",") != strings.Join(tt.want.commands, ",") ||
+			strings.Join(got.rules, ",") != strings.Join(tt.want.rules, ",") {
+			t.Errorf("plan(%s) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+
+	var p watchPlan
+	w.planConfig(&p, &config.ProjectConfig{
+		EnabledAgents:     []string{"claude"},
+		InstalledCommands: []string{"review"},
+		MCPServers:        map[string]config.MCPServer{"ctx": {Command: "npx"}},
+	})
+	if p.full || p.agents || !p.mcp || len(p.commands) != 1 || len(p.removed) != 0 {
+		t.Errorf("unexpected config plan: %+v", p)
+	}
+
+	w.config = &config.ProjectConfig{EnabledAgents: []string{"claude"}, InstalledCommands: []string{"review", "lint"}}
+	p = watchPlan{}
+	w.planConfig(&p, &config.ProjectConfig{EnabledAgents: []string{"claude"}, InstalledCommands: []string{"lint"}})
+	if !slices.Equal(p.removed, []string{"review"}) {
+		t.Errorf("expected review to be removed, got %+v", p)
+	}
+}
+
+func TestTemplateWatcherApplyRules(t *testing.T) {
+	projectDir := "/work/app"
+	mem := fsys.Memory(projectDir, "/home/dev")
+	_, err := RunSession(Session{Env: mem}, func(s *Session) error {
+		pc := &config.ProjectConfig{
+			ProjectName:        "app",
+			ProjectDescription: "demo",
+			EnabledAgents:      []string{"copilot"},
+			InstalledRules:     []string{"go"},
+			Parameters:         map[string]string{"PRIMARY_LANGUAGE": "Go", "TEAM_NAME": "core"},
+		}
+		if err := config.SaveProjectConfig(s.Env, projectDir, pc); err != nil {
+			return err
+		}
+		if err := s.RunSyncWithOptions(projectDir, nil, false, false); err != nil {
+			return err
+		}
+		template := filepath.Join(projectDir, ".anyagent", "extra_rules", "go.md")
+		if err := mem.FS.MkdirAll(filepath.Dir(template), 0755); err != nil {
+			return err
+		}
+		if err := mem.FS.WriteFile(template, []byte("# Go (edited)\n"), 0644); err != nil {
+			return err
+		}
+		w := s.newTemplateWatcher(projectDir)
+		return w.apply(s, w.plan(s, []string{template}))
+	})
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	b, err := mem.FS.ReadFile(filepath.Join(projectDir, ".github", "instructions", "go.instructions.md"))
+	if err != nil || string(b) != "# Go (edited)\n" {
+		t.Errorf("go.instructions.md = %q, %v", b, err)
+	}
+}
+
+func TestTemplateWatcherApplyRemovedCommands(t *testing.T) {
+	projectDir := "/work/app"
+	mem := fsys.Memory(projectDir, "/home/dev")
+	command := filepath.Join(projectDir, ".claude", "commands", "review-code.md")
+	_, err := RunSession(Session{Env: mem}, func(s *Session) error {
+		pc := &config.ProjectConfig{
+			ProjectName:        "app",
+			ProjectDescription: "demo",
+			EnabledAgents:      []string{"claude"},
+			InstalledCommands:  []string{"review-code"},
+		}
+		if err := config.SaveProjectConfig(s.Env, projectDir, pc); err != nil {
+			return err
+		}
+		if err := s.RunSyncWithOptions(projectDir, nil, false, false); err != nil {
+			return err
+		}
+		if _, err := mem.FS.Stat(command); err != nil {
+			t.Fatalf("command not installed by sync: %v", err)
+		}
+		w := s.newTemplateWatcher(projectDir)
+		pc.InstalledCommands = nil
+		if err := config.SaveProjectConfig(s.Env, projectDir, pc); err != nil {
+			return err
+		}
+		return w.apply(s, w.plan(s, []string{config.GetProjectConfigPath(projectDir)}))
+	})
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if _, err := mem.FS.Stat(command); !os.IsNotExist(err) {
+		t.Errorf("the removed command's file should be deleted, got %v", err)
+	}
+}
+
+func TestTemplateWatcherRegenerateKeepsSession(t *testing.T) {
+	projectDir := "/work/app"
+	mem := fsys.Memory(projectDir, "/home/dev")
+	var out bytes.Buffer
+	logger := logging.New(&out, &out, logging.Options{NoEmoji: true})
+	s := &Session{Logger: logger, Interactive: true, Env: mem}
+	pc := &config.ProjectConfig{
+		ProjectName:        "app",
+		ProjectDescription: "demo",
+		EnabledAgents:      []string{"copilot"},
+		InstalledRules:     []string{"go"},
+		Parameters:         map[string]string{"PRIMARY_LANGUAGE": "Go", "TEAM_NAME": "core"},
+	}
+	if err := config.SaveProjectConfig(s.Env, projectDir, pc); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RunSyncWithOptions(projectDir, nil, false, false); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	template := filepath.Join(projectDir, ".anyagent", "extra_rules", "go.md")
+	if err := mem.FS.MkdirAll(filepath.Dir(template), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := mem.FS.WriteFile(template, []byte("# Go (edited)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := s.newTemplateWatcher(projectDir)
+	out.Reset()
+
+	w.regenerate(s, []string{template})
+	if s.Logger != logger || !s.Interactive || s.Env != mem {
+		t.Errorf("regenerate changed the session: %+v", s)
+	}
+	if got := out.String(); !strings.Contains(got, "go.instructions.md") || strings.Count(got, "\n") != 1 {
+		t.Errorf("expected one line naming the regenerated file:\n%s", got)
+	}
+}
@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/internal/fsys"
+)
+
+// Monorepo workspaces get a nested AGENTS.md rendered from the root templates, plus the
+// per-path scoping each agent understands:
+//   - Copilot: .github/instructions/workspace-<path>.instructions.md with applyTo: "<path>/**"
+//   - Claude Code: <path>/CLAUDE.md -> AGENTS.md
+//   - Gemini CLI and Codex read nested AGENTS.md files themselves
+//   - Q Developer rules are not path-scoped, so only the nested AGENTS.md is written
+// Commands for Copilot, Claude and Gemini are installed inside the workspace so they are
+// available when the package is opened on its own.
+
+// workspaceInstructionsPrefix marks the Copilot instruction files generated for workspaces
+const workspaceInstructionsPrefix = "workspace-"
+
+// workspaceAgentsMarker starts every nested AGENTS.md anyagent writes, so a hand-written one is
+// recognized and kept
+const workspaceAgentsMarker = "<!-- Generated by anyagent from the workspaces in .anyagent/config.yaml; 'anyagent sync' overwrites this file -->\n"
+
+// syncWorkspaces writes the nested AGENTS.md and agent files for every workspace. A nested
+// AGENTS.md that anyagent did not generate is kept unless force is set.
+func (s *Session) syncWorkspaces(projectDir string, pc *config.ProjectConfig, agents []AIAgent, dryRun, force bool) error {
+	workspaces, err := pc.ResolveWorkspaces(s.Env, projectDir)
+	if err != nil {
+		return err
+	}
+	if len(pc.Workspaces) > 0 && len(workspaces) == 0 {
+		s.warnf("No directories match the workspaces in .anyagent/config.yaml")
+	}
+
+	instructions := map[string]bool{}
+	for _, ws := range workspaces {
+		wsDir := filepath.Join(projectDir, filepath.FromSlash(ws.Dir))
+		s.Logger.Debugf("Workspace %s\n", ws.Dir)
+		content, err := ws.Config.RenderAgentsContent(s.Env, projectDir)
+		if err != nil {
+			return fmt.Errorf("failed to render AGENTS.md for workspace %s: %w", ws.Dir, err)
+		}
+		if err := s.writeWorkspaceAgentsFile(filepath.Join(wsDir, "AGENTS.md"), content, dryRun, force); err != nil {
+			return err
+		}
+		for _, agent := range agents {
+			switch agent.Name {
+			case "copilot":
+				path := workspaceInstructionsPath(projectDir, ws.Dir)
+				instructions[path] = true
+				if err := s.writeGeneratedFile(path, buildWorkspaceInstructions(ws.Dir, content), dryRun); err != nil {
+					return err
+				}
+			case "claude":
+				if err := s.createRelativeSymlink(filepath.Join(wsDir, "CLAUDE.md"), "AGENTS.md", dryRun); err != nil {
+					return err
+				}
+			}
+			if err := s.installLocalCommands(agent.Name, projectDir, wsDir, ws.Config.InstalledCommands, dryRun); err != nil {
+				return err
+			}
+		}
+	}
+	for _, agent := range agents {
+		if agent.Name == "qdev" && len(workspaces) > 0 {
+			s.Logger.Infof("ℹ️  Amazon Q Developer rules are not path-scoped; workspaces only get a nested AGENTS.md\n")
+		}
+	}
+
+	// Drop instruction files of workspaces that no longer exist
+	return s.removeWorkspaceInstructions(projectDir, instructions, dryRun)
+}
+
+// writeWorkspaceAgentsFile writes a nested AGENTS.md with the generated marker. An existing file
+// without the marker was written by hand and is only replaced with force; one that already has
+// the rendered content (written before the marker existed) is taken over.
+func (s *Session) writeWorkspaceAgentsFile(path, content string, dryRun, force bool) error {
+	if b, err := s.Env.FS.ReadFile(path); err == nil && !force {
+		existing := string(b)
+		if !strings.HasPrefix(existing, workspaceAgentsMarker) && existing != content {
+			s.warnf("Keeping %s: it was not generated by anyagent (use --force to overwrite it)", path)
+			return nil
+		}
+	}
+	return s.writeGeneratedFile(path, workspaceAgentsMarker+content, dryRun)
+}
+
+// workspaceInstructionsPath returns the Copilot instruction file scoped to the workspace
+func workspaceInstructionsPath(projectDir, wsDir string) string {
+	name := workspaceInstructionsPrefix + strings.ReplaceAll(wsDir, "/", "-")
+	return filepath.Join(projectDir, ".github", "instructions", name+".instructions.md")
+}
+
+// buildWorkspaceInstructions wraps the workspace AGENTS.md in applyTo frontmatter
+func buildWorkspaceInstructions(wsDir, content string) string {
+	return fmt.Sprintf("---\napplyTo: \"%s/**\"\n---\n\n%s", wsDir, content)
+}
+
+// removeWorkspaceInstructions removes generated workspace instruction files not listed in keep
+func (s *Session) removeWorkspaceInstructions(projectDir string, keep map[string]bool, dryRun bool) error {
+	matches, _ := fsys.Glob(s.Env.FS, filepath.Join(projectDir, ".github", "instructions", workspaceInstructionsPrefix+"*.instructions.md"))
+	for _, path := range matches {
+		if keep[path] {
+			continue
+		}
+		if err := s.removePath(path, "Copilot workspace instructions", dryRun); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeWorkspaceArtifacts removes the workspace files of a deselected agent
+func (s *Session) removeWorkspaceArtifacts(agentName, projectDir string, dryRun bool) error {
+	if agentName == "copilot" {
+		if err := s.removeWorkspaceInstructions(projectDir, nil, dryRun); err != nil {
+			return err
+		}
+	}
+	pc, err := config.LoadProjectConfig(s.Env, config.GetProjectConfigPath(projectDir))
+	if err != nil {
+		return nil
+	}
+	workspaces, err := pc.ResolveWorkspaces(s.Env, projectDir)
+	if err != nil {
+		return nil
+	}
+	for _, ws := range workspaces {
+		wsDir := filepath.Join(projectDir, filepath.FromSlash(ws.Dir))
+		s.Logger.Debugf("Workspace %s\n", ws.Dir)
+		if agentName == "claude" {
+			if err := s.removePath(filepath.Join(wsDir, "CLAUDE.md"), "Claude Code workspace symlink", dryRun); err != nil {
+				return err
+			}
+		}
+		for _, c := range ws.Config.InstalledCommands {
+			if path, ok := localCommandPath(agentName, wsDir, c); ok {
+				if err := s.removePath(path, fmt.Sprintf("%s command '%s' in %s", agentDisplayName(agentName), c, ws.Dir), dryRun); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// writeGeneratedFile writes a generated file, creating its directory
+func (s *Session) writeGeneratedFile(path, content string, dryRun bool) error {
+	if dryRun {
+		s.dryRunf("write", path, "Would write %s", path)
+		return nil
+	}
+	if err := s.Env.FS.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	s.Logger.Infof("📄 Writing %s\n", path)
+	return s.Env.FS.WriteFile(path, []byte(content), 0644)
+}
+
+// createRelativeSymlink (re)creates path as a symlink to target, relative to path's directory
+func (s *Session) createRelativeSymlink(path, target string, dryRun bool) error {
+	if dryRun {
+		s.dryRunf("symlink", path, "Would create symlink: %s -> %s", path, target)
+		return nil
+	}
+	if existing, err := s.Env.FS.Readlink(path); err == nil && existing == target {
+		return nil
+	}
+	if info, err := s.Env.FS.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSymlink == 0 {
+			s.warnf("Keeping %s: it is a regular file, not a symlink to %s", path, target)
+			return nil
+		}
+		if err := s.Env.FS.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove existing symlink %s: %w", path, err)
+		}
+	}
+	s.Logger.Infof("🔗 Creating symlink: %s -> %s\n", path, target)
+	if err := s.Env.FS.Symlink(target, path); err != nil {
+		return fmt.Errorf("failed to create symlink %s: %w", path, err)
+	}
+	return nil
+}
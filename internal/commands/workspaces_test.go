@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+func TestSyncWorkspaces(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	for _, d := range []string{"services/api", "web/app"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pc := &config.ProjectConfig{
+		ProjectName:        "mono",
+		ProjectDescription: "monorepo",
+		EnabledAgents:      []string{"claude"},
+		Parameters: map[string]string{
+			"PROJECT_NAME": "mono", "PROJECT_DESCRIPTION": "monorepo", "PRIMARY_LANGUAGE": "Go", "TEAM_NAME": "core",
+		},
+		Workspaces: []config.Workspace{
+			{Path: "services/*", Rules: []string{"go"}},
+			{Path: "web/*", Rules: []string{"typescript"}, Commands: []string{"coding"}},
+		},
+	}
+	if err := config.SaveProjectConfig(s.Env, dir, pc); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RunSyncWithOptions(dir, nil, false, false); err != nil {
+		t.Fatalf("RunSyncWithOptions failed: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "web", "app", "AGENTS.md"))
+	if err != nil {
+		t.Fatalf("nested AGENTS.md not written: %v", err)
+	}
+	if !strings.Contains(string(b), "TypeScript") {
+		t.Errorf("web AGENTS.md should contain the typescript rules:\n%s", b)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "AGENTS.md")); strings.Contains(string(b), "TypeScript Specific") {
+		t.Errorf("root AGENTS.md should not contain workspace rules")
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "services", "api", "CLAUDE.md")); err != nil || target != "AGENTS.md" {
+		t.Errorf("nested CLAUDE.md symlink = %q, %v", target, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "web", "app", ".claude", "commands", "coding.md")); err != nil {
+		t.Errorf("workspace command not installed: %v", err)
+	}
+
+	// Switching to Copilot replaces the nested CLAUDE.md links with applyTo instructions
+	if err := s.RunSwitch(dir, "copilot", false); err != nil {
+		t.Fatalf("RunSwitch failed: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "services", "api", "CLAUDE.md")); !os.IsNotExist(err) {
+		t.Errorf("nested CLAUDE.md should be removed after switching away from Claude")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "web", "app", ".claude", "commands", "coding.md")); !os.IsNotExist(err) {
+		t.Errorf("workspace Claude command should be removed after switching away from Claude")
+	}
+	b, err = os.ReadFile(filepath.Join(dir, ".github", "instructions", "workspace-web-app.instructions.md"))
+	if err != nil {
+		t.Fatalf("Copilot workspace instructions not written: %v", err)
+	}
+	if !strings.HasPrefix(string(b), "---\napplyTo: \"web/app/**\"\n---\n") {
+		t.Errorf("unexpected instructions frontmatter:\n%s", b)
+	}
+
+	// Instructions of a workspace that no longer exists are dropped on the next sync
+	if err := os.RemoveAll(filepath.Join(dir, "web")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RunSyncWithOptions(dir, nil, false, false); err != nil {
+		t.Fatalf("RunSyncWithOptions failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".github", "instructions", "workspace-web-app.instructions.md")); !os.IsNotExist(err) {
+		t.Errorf("stale workspace instructions should be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".github", "instructions", "workspace-services-api.instructions.md")); err != nil {
+		t.Errorf("services/api instructions missing: %v", err)
+	}
+}
+
+func TestSyncWorkspacesKeepsHandWrittenAgents(t *testing.T) {
+	s := newTestSession()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	handWritten := "# API team notes\n"
+	writeTestFile(t, filepath.Join(dir, "services", "api", "AGENTS.md"), handWritten)
+	if err := os.MkdirAll(filepath.Join(dir, "services", "worker"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pc := &config.ProjectConfig{
+		ProjectName:   "mono",
+		EnabledAgents: []string{"claude"},
+		Parameters:    map[string]string{"PROJECT_NAME": "mono", "PROJECT_DESCRIPTION": "monorepo", "PRIMARY_LANGUAGE": "Go", "TEAM_NAME": "core"},
+		Workspaces:    []config.Workspace{{Path: "services/*", Rules: []string{"go"}}},
+	}
+	if err := config.SaveProjectConfig(s.Env, dir, pc); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RunSyncWithOptions(dir, nil, false, false); err != nil {
+		t.Fatalf("RunSyncWithOptions failed: %v", err)
+	}
+	if got := mustReadFile(t, filepath.Join(dir, "services", "api", "AGENTS.md")); got != handWritten {
+		t.Errorf("hand-written nested AGENTS.md was overwritten:\n%s", got)
+	}
+	generated := mustReadFile(t, filepath.Join(dir, "services", "worker", "AGENTS.md"))
+	if !strings.HasPrefix(generated, workspaceAgentsMarker) {
+		t.Errorf("generated nested AGENTS.md lacks the marker:\n%s", generated)
+	}
+
+	// Generated files are regenerated, and --force replaces the hand-written one
+	if err := s.RunSyncWithOptions(dir, nil, false, true); err != nil {
+		t.Fatalf("RunSyncWithOptions --force failed: %v", err)
+	}
+	if got := mustReadFile(t, filepath.Join(dir, "services", "api", "AGENTS.md")); !strings.HasPrefix(got, workspaceAgentsMarker) {
+		t.Errorf("--force should replace the hand-written nested AGENTS.md:\n%s", got)
+	}
+}
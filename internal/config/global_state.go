@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/shibukawa/anyagent/internal/fsys"
+)
+
+// GlobalState is the user-level registry of artifacts anyagent installs outside projects
+// (~/.codex/prompts, ~/.aws/amazonq/prompts, ~/.codex/config.toml servers). Every artifact
+// lists the projects that use it, so it is only removed once no project references it.
+type GlobalState struct {
+	Artifacts map[string][]string `json:"artifacts"` // artifact id -> absolute project directories
+}
+
+// GetGlobalStatePath returns <user config dir>/anyagent/global-state.json
+func GetGlobalStatePath(env *fsys.Env) (string, error) {
+	dir, err := GetUserConfigDir(env)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "global-state.json"), nil
+}
+
+// LoadGlobalState reads the registry; a missing file is an empty registry
+func LoadGlobalState(env *fsys.Env) (*GlobalState, error) {
+	state := &GlobalState{Artifacts: map[string][]string{}}
+	path, err := GetGlobalStatePath(env)
+	if err != nil {
+		return nil, err
+	}
+	data, err := env.FS.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read global state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse global state %s: %w", path, err)
+	}
+	if state.Artifacts == nil {
+		state.Artifacts = map[string][]string{}
+	}
+	return state, nil
+}
+
+// UpdateGlobalState loads the state, lets fn change it and saves it while holding the state
+// lock. Nothing is saved when fn fails. fn may also change the artifacts themselves, so a
+// project never deletes an artifact another project has just registered.
+func UpdateGlobalState(env *fsys.Env, fn func(s *GlobalState) error) error {
+	path, err := GetGlobalStatePath(env)
+	if err != nil {
+		return err
+	}
+	return withFileLock(env, path, func() error {
+		state, err := LoadGlobalState(env)
+		if err != nil {
+			return err
+		}
+		if err := fn(state); err != nil {
+			return err
+		}
+		return state.Save(env)
+	})
+}
+
+// Save writes the registry atomically. Use UpdateGlobalState to change the state other
+// processes may be changing too.
+func (s *GlobalState) Save(env *fsys.Env) error {
+	path, err := GetGlobalStatePath(env)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal global state: %w", err)
+	}
+	if err := env.FS.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return fsys.WriteFileAtomic(env.FS, path, append(data, '\n'), 0644)
+}
+
+// AddReference records that the project uses the artifact
+func (s *GlobalState) AddReference(artifact, projectDir string) {
+	project := absProjectDir(projectDir)
+	refs := s.Artifacts[artifact]
+	if slices.Contains(refs, project) {
+		return
+	}
+	refs = append(refs, project)
+	sort.Strings(refs)
+	s.Artifacts[artifact] = refs
+}
+
+// RemoveReference drops the project's reference and returns the projects still using the artifact
+func (s *GlobalState) RemoveReference(artifact, projectDir string) []string {
+	project := absProjectDir(projectDir)
+	refs := slices.DeleteFunc(s.Artifacts[artifact], func(p string) bool { return p == project })
+	if len(refs) == 0 {
+		delete(s.Artifacts, artifact)
+		return nil
+	}
+	s.Artifacts[artifact] = refs
+	return refs
+}
+
+// IsReferencedBy reports whether the project uses the artifact
+func (s *GlobalState) IsReferencedBy(artifact, projectDir string) bool {
+	return slices.Contains(s.Artifacts[artifact], absProjectDir(projectDir))
+}
+
+// References returns the projects using the artifact
+func (s *GlobalState) References(artifact string) []string {
+	return s.Artifacts[artifact]
+}
+
+// absProjectDir returns the cleaned absolute form of a project directory, the registry key
+func absProjectDir(projectDir string) string {
+	if abs, err := filepath.Abs(projectDir); err == nil {
+		return abs
+	}
+	return filepath.Clean(projectDir)
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/shibukawa/anyagent/internal/fsys"
+)
+
+func TestGlobalStateReferences(t *testing.T) {
+	env := fsys.Default()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	projectA := t.TempDir()
+	projectB := t.TempDir()
+	artifact := filepath.Join(t.TempDir(), "prompts", "review.md")
+
+	state, err := LoadGlobalState(env)
+	if err != nil {
+		t.Fatalf("LoadGlobalState failed: %v", err)
+	}
+	if len(state.Artifacts) != 0 {
+		t.Fatalf("expected empty state, got %v", state.Artifacts)
+	}
+	state.AddReference(artifact, projectA)
+	state.AddReference(artifact, projectB)
+	state.AddReference(artifact, projectA) // duplicate
+	if err := state.Save(env); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	state, err = LoadGlobalState(env)
+	if err != nil {
+		t.Fatalf("LoadGlobalState failed: %v", err)
+	}
+	if refs := state.References(artifact); len(refs) != 2 || !slices.IsSorted(refs) {
+		t.Fatalf("expected two sorted references, got %v", refs)
+	}
+	if !state.IsReferencedBy(artifact, projectA) {
+		t.Errorf("expected %s to reference the artifact", projectA)
+	}
+
+	if others := state.RemoveReference(artifact, projectA); len(others) != 1 || others[0] != projectB {
+		t.Errorf("expected only %s to remain, got %v", projectB, others)
+	}
+	if others := state.RemoveReference(artifact, projectB); len(others) != 0 {
+		t.Errorf("expected no references, got %v", others)
+	}
+	if _, ok := state.Artifacts[artifact]; ok {
+		t.Errorf("unreferenced artifact should be dropped from the state")
+	}
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MCP server transports
+const (
+	MCPTransportStdio = "stdio"
+	MCPTransportHTTP  = "http"
+	MCPTransportSSE   = "sse"
+)
+
+// MCPServer is an MCP server definition stored under mcp_servers in .anyagent/config.yaml.
+// Local servers are launched with Command/Args (stdio); remote servers are reached at URL.
+type MCPServer struct {
+	Command   string            `yaml:"command,omitempty"`
+	Args      []string          `yaml:"args,omitempty"`
+	Env       map[string]string `yaml:"env,omitempty"`
+	Cwd       string            `yaml:"cwd,omitempty"`
+	Transport string            `yaml:"transport,omitempty"` // stdio (default), http or sse
+	URL       string            `yaml:"url,omitempty"`
+	Headers   map[string]string `yaml:"headers,omitempty"`
+	Disabled  bool              `yaml:"disabled,omitempty"`
+
+	// Agents limits the server to these agents; ExcludeAgents leaves it out of these agents
+	Agents        []string `yaml:"agents,omitempty"`
+	ExcludeAgents []string `yaml:"exclude_agents,omitempty"`
+
+	// Timeouts in seconds; written for agents that support them (Codex, Gemini)
+	StartupTimeout int `yaml:"startup_timeout_sec,omitempty"`
+	ToolTimeout    int `yaml:"tool_timeout_sec,omitempty"`
+}
+
+// UnmarshalYAML accepts both the structured form and the legacy single command line
+// ("npx -y some-server"), which is migrated to Command/Args.
+func (s *MCPServer) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		fields, err := SplitCommandLine(node.Value)
+		if err != nil {
+			// Keep loading old configs that relied on plain whitespace splitting
+			fields = strings.Fields(node.Value)
+		}
+		*s = MCPServer{}
+		if len(fields) > 0 {
+			s.Command = fields[0]
+			s.Args = fields[1:]
+		}
+		if len(s.Args) == 0 {
+			s.Args = nil
+		}
+		return nil
+	}
+	type plain MCPServer
+	var p plain
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	*s = MCPServer(p)
+	return nil
+}
+
+// TransportType returns the effective transport; stdio unless set or a URL is given
+func (s MCPServer) TransportType() string {
+	if s.Transport != "" {
+		return s.Transport
+	}
+	if s.URL != "" {
+		return MCPTransportHTTP
+	}
+	return MCPTransportStdio
+}
+
+// IsRemote reports whether the server is reached over HTTP/SSE rather than launched locally
+func (s MCPServer) IsRemote() bool {
+	return s.TransportType() != MCPTransportStdio
+}
+
+// EnabledFor reports whether the server is written to the agent's MCP config
+func (s MCPServer) EnabledFor(agent string) bool {
+	if s.Disabled {
+		return false
+	}
+	for _, a := range s.ExcludeAgents {
+		if a == agent {
+			return false
+		}
+	}
+	if len(s.Agents) == 0 {
+		return true
+	}
+	for _, a := range s.Agents {
+		if a == agent {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize returns the definition in its canonical stored form: the transport is omitted when it
+// is implied by command/url, and empty lists and maps are nil. Equal servers normalize identically.
+func (s MCPServer) Normalize() MCPServer {
+	if s.Transport == MCPTransportStdio || (s.Transport == MCPTransportHTTP && s.URL != "") {
+		s.Transport = ""
+	}
+	if len(s.Args) == 0 {
+		s.Args = nil
+	}
+	if len(s.Env) == 0 {
+		s.Env = nil
+	}
+	if len(s.Headers) == 0 {
+		s.Headers = nil
+	}
+	if len(s.Agents) == 0 {
+		s.Agents = nil
+	}
+	if len(s.ExcludeAgents) == 0 {
+		s.ExcludeAgents = nil
+	}
+	return s
+}
+
+// Validate checks that the definition is consistent with its transport
+func (s MCPServer) Validate() error {
+	switch s.TransportType() {
+	case MCPTransportStdio:
+		if s.Command == "" {
+			return fmt.Errorf("stdio MCP server requires a command")
+		}
+		if s.URL != "" {
+			return fmt.Errorf("stdio MCP server cannot have a url")
+		}
+	case MCPTransportHTTP, MCPTransportSSE:
+		if s.URL == "" {
+			return fmt.Errorf("%s MCP server requires a url", s.TransportType())
+		}
+		if s.Command != "" {
+			return fmt.Errorf("%s MCP server cannot have a command", s.TransportType())
+		}
+	default:
+		return fmt.Errorf("unknown MCP transport: %s (expected stdio, http or sse)", s.Transport)
+	}
+	if s.StartupTimeout < 0 || s.ToolTimeout < 0 {
+		return fmt.Errorf("MCP server timeouts cannot be negative")
+	}
+	return nil
+}
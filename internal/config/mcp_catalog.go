@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/shibukawa/anyagent/internal/fsys"
+)
+
+// MCPPresetEnv describes an environment variable a catalog preset needs
+type MCPPresetEnv struct {
+	Description string `yaml:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+	Secret      bool   `yaml:"secret,omitempty"` // referenced as ${env:NAME} rather than stored
+	Default     string `yaml:"default,omitempty"`
+}
+
+// UnmarshalYAML also accepts a plain value (NAME: value), as used by the older servers: map
+func (e *MCPPresetEnv) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*e = MCPPresetEnv{Default: node.Value}
+		return nil
+	}
+	type plain MCPPresetEnv
+	var p plain
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	*e = MCPPresetEnv(p)
+	return nil
+}
+
+// MCPPreset is an entry of the MCP server catalog (templates/mcp.yaml)
+type MCPPreset struct {
+	ID          string                  `yaml:"-"`
+	Source      string                  `yaml:"-"` // template layer that defined the preset
+	Description string                  `yaml:"description,omitempty"`
+	Command     string                  `yaml:"command,omitempty"`
+	Args        []string                `yaml:"args,omitempty"`
+	Cwd         string                  `yaml:"cwd,omitempty"`
+	Transport   string                  `yaml:"transport,omitempty"`
+	URL         string                  `yaml:"url,omitempty"`
+	Headers     map[string]string       `yaml:"headers,omitempty"`
+	Env         map[string]MCPPresetEnv `yaml:"env,omitempty"`
+	Agents      []string                `yaml:"agents,omitempty"` // recommended agents; empty means all
+}
+
+// EnvNames returns the preset's environment variable names, sorted
+func (p MCPPreset) EnvNames() []string {
+	names := make([]string, 0, len(p.Env))
+	for name := range p.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RecommendedFor reports whether the preset is recommended for the agent
+func (p MCPPreset) RecommendedFor(agent string) bool {
+	if len(p.Agents) == 0 {
+		return true
+	}
+	for _, a := range p.Agents {
+		if a == agent {
+			return true
+		}
+	}
+	return false
+}
+
+// Server returns the server definition with the given environment values
+func (p MCPPreset) Server(env map[string]string) MCPServer {
+	s := MCPServer{
+		Command:   p.Command,
+		Args:      append([]string(nil), p.Args...),
+		Cwd:       p.Cwd,
+		Transport: p.Transport,
+		URL:       p.URL,
+	}
+	if len(p.Headers) > 0 {
+		s.Headers = map[string]string{}
+		for k, v := range p.Headers {
+			s.Headers[k] = v
+		}
+	}
+	if len(env) > 0 {
+		s.Env = map[string]string{}
+		for k, v := range env {
+			s.Env[k] = v
+		}
+	}
+	return s
+}
+
+// mcpCatalogFile is the layout of templates/mcp.yaml. Older files list servers under servers:,
+// which are read as presets too.
+type mcpCatalogFile struct {
+	Presets map[string]MCPPreset `yaml:"presets"`
+	Servers map[string]MCPPreset `yaml:"servers"`
+}
+
+// LoadMCPCatalog returns the MCP presets available to a project, merged by id across the
+// embedded, user and project (.anyagent/mcp.yaml) catalogs and sorted by id
+func LoadMCPCatalog(env *fsys.Env, projectDir string) ([]MCPPreset, error) {
+	presets := map[string]MCPPreset{}
+	merge := func(data []byte, source, path string) error {
+		var file mcpCatalogFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("failed to parse MCP catalog %s: %w", path, err)
+		}
+		// Older catalogs were copied from an example servers: map with placeholder paths, so
+		// those entries only add ids and never replace a preset
+		for id, p := range file.Servers {
+			if _, exists := presets[id]; !exists {
+				p.ID, p.Source = id, source
+				presets[id] = p
+			}
+		}
+		for id, p := range file.Presets {
+			p.ID, p.Source = id, source
+			presets[id] = p
+		}
+		return nil
+	}
+
+	// Lowest precedence first so higher layers replace presets with the same id
+	data, err := templatesFS.ReadFile("configsrc/templates/mcp.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded MCP catalog: %w", err)
+	}
+	if err := merge(data, TemplateSourceEmbedded, "(embedded)"); err != nil {
+		return nil, err
+	}
+	if userDir, err := GetUserConfigDir(env); err == nil {
+		path := filepath.Join(userDir, "templates", "mcp.yaml")
+		if data, err := env.FS.ReadFile(path); err == nil {
+			if err := merge(data, TemplateSourceUser, path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if projectDir != "" {
+		path := filepath.Join(projectDir, ".anyagent", "mcp.yaml")
+		if data, err := env.FS.ReadFile(path); err == nil {
+			if err := merge(data, TemplateSourceProject, path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	out := make([]MCPPreset, 0, len(presets))
+	for _, p := range presets {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// GetMCPPreset returns a catalog preset by id
+func GetMCPPreset(env *fsys.Env, projectDir, id string) (MCPPreset, error) {
+	presets, err := LoadMCPCatalog(env, projectDir)
+	if err != nil {
+		return MCPPreset{}, err
+	}
+	var ids []string
+	for _, p := range presets {
+		if p.ID == id {
+			return p, nil
+		}
+		ids = append(ids, p.ID)
+	}
+	return MCPPreset{}, fmt.Errorf("MCP preset '%s' not found. Available presets: %s", id, strings.Join(ids, ", "))
+}
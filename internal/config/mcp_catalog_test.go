@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shibukawa/anyagent/internal/fsys"
+)
+
+func TestLoadMCPCatalog_Embedded(t *testing.T) {
+	env := fsys.Default()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	presets, err := LoadMCPCatalog(env, t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadMCPCatalog failed: %v", err)
+	}
+	byID := map[string]MCPPreset{}
+	for _, p := range presets {
+		byID[p.ID] = p
+	}
+	c7, ok := byID["context7"]
+	if !ok || c7.Source != TemplateSourceEmbedded || c7.Command != "npx" {
+		t.Fatalf("unexpected context7 preset: %+v", c7)
+	}
+	gh := byID["github"]
+	if env := gh.Env["GITHUB_PERSONAL_ACCESS_TOKEN"]; !env.Required || !env.Secret {
+		t.Errorf("github token should be a required secret: %+v", env)
+	}
+	for _, p := range presets {
+		if err := p.Server(nil).Validate(); err != nil {
+			t.Errorf("preset %s is invalid: %v", p.ID, err)
+		}
+	}
+}
+
+func TestLoadMCPCatalog_Overrides(t *testing.T) {
+	env := fsys.Default()
+	userDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	projectDir := t.TempDir()
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The example servers: map older versions copied into user templates
+	write(filepath.Join(userDir, "anyagent", "templates", "mcp.yaml"), `servers:
+  filesystem:
+    command: npx
+    args: ["@modelcontextprotocol/server-filesystem", "/path/to/allowed/directory"]
+  legacy:
+    command: legacy-server
+    env:
+      MODE: production
+presets:
+  context7:
+    description: Team mirror
+    command: c7-mirror
+`)
+	write(filepath.Join(projectDir, ".anyagent", "mcp.yaml"), `presets:
+  internal:
+    url: https://mcp.internal.example.com/mcp
+    agents: [claude]
+`)
+
+	presets, err := LoadMCPCatalog(env, projectDir)
+	if err != nil {
+		t.Fatalf("LoadMCPCatalog failed: %v", err)
+	}
+	byID := map[string]MCPPreset{}
+	for _, p := range presets {
+		byID[p.ID] = p
+	}
+	if p := byID["filesystem"]; p.Source != TemplateSourceEmbedded {
+		t.Errorf("legacy servers: entries must not replace presets: %+v", p)
+	}
+	if p := byID["legacy"]; p.Source != TemplateSourceUser || p.Env["MODE"].Default != "production" {
+		t.Errorf("unexpected legacy entry: %+v", p)
+	}
+	if p := byID["context7"]; p.Source != TemplateSourceUser || p.Command != "c7-mirror" {
+		t.Errorf("user preset should override the embedded one: %+v", p)
+	}
+	p := byID["internal"]
+	if p.Source != TemplateSourceProject || p.RecommendedFor("copilot") || !p.RecommendedFor("claude") {
+		t.Errorf("unexpected project preset: %+v", p)
+	}
+	if _, err := GetMCPPreset(env, projectDir, "nope"); err == nil {
+		t.Error("expected an error for an unknown preset")
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+import "testing"
+
+func TestMCPServerEnabledFor(t *testing.T) {
+	tests := []struct {
+		server MCPServer
+		agent  string
+		want   bool
+	}{
+		{MCPServer{Command: "x"}, "claude", true},
+		{MCPServer{Command: "x", Disabled: true}, "claude", false},
+		{MCPServer{Command: "x", Agents: []string{"copilot", "gemini"}}, "claude", false},
+		{MCPServer{Command: "x", Agents: []string{"copilot", "gemini"}}, "gemini", true},
+		{MCPServer{Command: "x", ExcludeAgents: []string{"claude"}}, "claude", false},
+		{MCPServer{Command: "x", ExcludeAgents: []string{"claude"}}, "qdev", true},
+		{MCPServer{Command: "x", Agents: []string{"claude"}, ExcludeAgents: []string{"claude"}}, "claude", false},
+	}
+	for _, tt := range tests {
+		if got := tt.server.EnabledFor(tt.agent); got != tt.want {
+			t.Errorf("%+v.EnabledFor(%s) = %v, want %v", tt.server, tt.agent, got, tt.want)
+		}
+	}
+}
@@ -7,39 +7,44 @@ import (
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/shibukawa/anyagent/internal/fsys"
 )
 
 // ProjectConfig represents the project-specific configuration stored in .anyagent.yaml
 type ProjectConfig struct {
-	ProjectName        string            `yaml:"project_name"`
-	ProjectDescription string            `yaml:"project_description"`
-	InstalledRules     []string          `yaml:"installed_rules"`
-	InstalledCommands  []string          `yaml:"installed_commands"`
-	EnabledAgents      []string          `yaml:"enabled_agents"`
-	Parameters         map[string]string `yaml:"parameters"`
-	MCPServers         map[string]string `yaml:"mcp_servers"`
+	ProjectName        string               `yaml:"project_name"`
+	ProjectDescription string               `yaml:"project_description"`
+	InstalledRules     []string             `yaml:"installed_rules"`
+	InstalledCommands  []string             `yaml:"installed_commands"`
+	InstalledPersonas  []string             `yaml:"installed_personas"`
+	InstalledHooks     []string             `yaml:"installed_hooks"`
+	EnabledAgents      []string             `yaml:"enabled_agents"`
+	Parameters         map[string]string    `yaml:"parameters"`
+	MCPServers         map[string]MCPServer `yaml:"mcp_servers"`
+	Workspaces         []Workspace          `yaml:"workspaces,omitempty"`
 }
 
 // LoadProjectConfig loads the project configuration from .anyagent.yaml
-func LoadProjectConfig(configPath string) (*ProjectConfig, error) {
+func LoadProjectConfig(env *fsys.Env, configPath string) (*ProjectConfig, error) {
 	// Primary: read from provided path
-	data, err := os.ReadFile(configPath)
+	data, err := env.FS.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			// Back-compat: if provided path is .anyagent/config.yaml, try legacy .anyagent.yaml
 			if filepath.Base(configPath) == "config.yaml" {
 				legacy := filepath.Join(filepath.Dir(filepath.Dir(configPath)), ".anyagent.yaml")
-				if b, e := os.ReadFile(legacy); e == nil {
+				if b, e := env.FS.ReadFile(legacy); e == nil {
 					data = b
 				} else if os.IsNotExist(e) {
 					// Return default config if neither exists
-					return &ProjectConfig{InstalledRules: []string{}}, nil
+					return defaultProjectConfig(), nil
 				} else {
 					return nil, fmt.Errorf("failed to read legacy project config: %w", e)
 				}
 			} else {
 				// Return default config if file doesn't exist
-				return &ProjectConfig{InstalledRules: []string{}}, nil
+				return defaultProjectConfig(), nil
 			}
 		} else {
 			return nil, fmt.Errorf("failed to read project config: %w", err)
@@ -58,6 +63,12 @@ func LoadProjectConfig(configPath string) (*ProjectConfig, error) {
 	if config.InstalledCommands == nil {
 		config.InstalledCommands = []string{}
 	}
+	if config.InstalledPersonas == nil {
+		config.InstalledPersonas = []string{}
+	}
+	if config.InstalledHooks == nil {
+		config.InstalledHooks = []string{}
+	}
 	if config.EnabledAgents == nil {
 		config.EnabledAgents = []string{}
 	}
@@ -65,30 +76,40 @@ func LoadProjectConfig(configPath string) (*ProjectConfig, error) {
 		config.Parameters = map[string]string{}
 	}
 	if config.MCPServers == nil {
-		config.MCPServers = map[string]string{}
+		config.MCPServers = map[string]MCPServer{}
 	}
 
 	return &config, nil
 }
 
+// defaultProjectConfig is the config of a project without .anyagent/config.yaml, with the
+// maps initialized like a loaded config so callers can fill them in
+func defaultProjectConfig() *ProjectConfig {
+	return &ProjectConfig{
+		InstalledRules: []string{},
+		Parameters:     map[string]string{},
+		MCPServers:     map[string]MCPServer{},
+	}
+}
+
 // Save saves the project configuration to the specified file
-func (c *ProjectConfig) Save(configPath string) error {
+func (c *ProjectConfig) Save(env *fsys.Env, configPath string) error {
 	data, err := yaml.Marshal(c)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
 	// Ensure parent dir exists
-	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+	if err := env.FS.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
-	return os.WriteFile(configPath, data, 0644)
+	return env.FS.WriteFile(configPath, data, 0644)
 }
 
 // SaveProjectConfig saves the project configuration to .anyagent.yaml
-func SaveProjectConfig(projectDir string, config *ProjectConfig) error {
+func SaveProjectConfig(env *fsys.Env, projectDir string, config *ProjectConfig) error {
 	configPath := GetProjectConfigPath(projectDir)
-	return config.Save(configPath)
+	return config.Save(env, configPath)
 }
 
 // GetProjectConfigPath returns the path to .anyagent.yaml for the given project directory
@@ -96,10 +117,38 @@ func GetProjectConfigPath(projectDir string) string {
 	return filepath.Join(projectDir, ".anyagent", "config.yaml")
 }
 
-// RegenerateAgentsFile regenerates AGENTS.md with current project configuration
-func (c *ProjectConfig) RegenerateAgentsFile() error {
+// RegenerateAgentsFile regenerates AGENTS.md in the environment's project directory
+func (c *ProjectConfig) RegenerateAgentsFile(env *fsys.Env) error {
+	return c.RegenerateAgentsFileAt(env, "")
+}
+
+// RegenerateAgentsFileAt regenerates AGENTS.md at the specified project directory (the
+// environment's project directory when empty)
+func (c *ProjectConfig) RegenerateAgentsFileAt(env *fsys.Env, projectDir string) error {
+	if projectDir == "" {
+		var err error
+		if projectDir, err = env.Getwd(); err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+	content, err := c.RenderAgentsContent(env, projectDir)
+	if err != nil {
+		return err
+	}
+	return env.FS.WriteFile(filepath.Join(projectDir, "AGENTS.md"), []byte(content), 0644)
+}
+
+// RenderAgentsContent renders AGENTS.md with projectDir's template layers (the environment's
+// project directory when empty)
+func (c *ProjectConfig) RenderAgentsContent(env *fsys.Env, projectDir string) (string, error) {
+	return RenderAgents(c, LayersFor(env, projectDir))
+}
+
+// RenderAgents renders AGENTS.md from the config and the template layers. It only reads
+// templates; nothing depends on the working directory.
+func RenderAgents(c *ProjectConfig, layers TemplateLayers) (string, error) {
 	// Get the template
-	agentsTemplate, _ := ResolveTemplateContent("", "AGENTS.md.tmpl", func() (string, error) {
+	agentsTemplate, _ := layers.Resolve("AGENTS.md.tmpl", func() (string, error) {
 		return GetAGENTSTemplate(), nil
 	})
 
@@ -121,31 +170,20 @@ func (c *ProjectConfig) RegenerateAgentsFile() error {
 	// Collect and inject extra rule content
 	var extraRules []string
 	for _, rule := range c.InstalledRules {
-		contentRule, err := getRuleTemplateContent("", rule)
+		contentRule, err := getRuleTemplateContent(layers, rule)
 		if err != nil {
-			return fmt.Errorf("failed to get content for rule %s: %w", rule, err)
+			return "", fmt.Errorf("failed to get content for rule %s: %w", rule, err)
 		}
 		extraRules = append(extraRules, contentRule)
 	}
 	extraRulesContent := strings.Join(extraRules, "\n\n")
-	content = strings.Replace(content, "{{EXTRA_RULES}}", extraRulesContent, 1)
-
-	// Write to AGENTS.md in current working directory
-	return os.WriteFile("AGENTS.md", []byte(content), 0644)
+	return strings.Replace(content, "{{EXTRA_RULES}}", extraRulesContent, 1), nil
 }
 
-// RegenerateAgentsFileAt regenerates AGENTS.md at the specified project directory
-func (c *ProjectConfig) RegenerateAgentsFileAt(projectDir string) error {
-	cwd, _ := os.Getwd()
-	defer func() { _ = os.Chdir(cwd) }()
-	if projectDir != "" {
-		_ = os.Chdir(projectDir)
-	}
-	return c.RegenerateAgentsFile()
-}
-
-// getRuleTemplateContent gets the content for a specific rule
-func getRuleTemplateContent(projectDir string, rule string) (string, error) {
+// getRuleTemplateContent gets the content for a specific rule. Built-in rules have an
+// embedded fallback; any other name is looked up as extra_rules/<name>.md in the project and
+// user templates (e.g. rules created by 'anyagent adopt').
+func getRuleTemplateContent(layers TemplateLayers, rule string) (string, error) {
 	// Map rule to filename
 	filename := ""
 	switch rule {
@@ -160,10 +198,13 @@ func getRuleTemplateContent(projectDir string, rule string) (string, error) {
 	case "react":
 		filename = "react.md"
 	default:
-		return "", fmt.Errorf("unknown rule: %s", rule)
+		if rule == "" || strings.ContainsAny(rule, "/\\") || strings.HasPrefix(rule, ".") {
+			return "", fmt.Errorf("unknown rule: %s", rule)
+		}
+		filename = rule + ".md"
 	}
-	// Resolve using precedence via ResolveTemplateContent
-	content, err := ResolveTemplateContent(projectDir, filepath.Join("extra_rules", filename), func() (string, error) {
+	// Resolve using the layer precedence
+	content, err := layers.Resolve(filepath.Join("extra_rules", filename), func() (string, error) {
 		switch rule {
 		case "go":
 			return GetGoExtraRuleTemplate(), nil
@@ -5,9 +5,12 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/shibukawa/anyagent/internal/fsys"
 )
 
 func TestRegenerateAgentsFilePrefersProjectTemplates(t *testing.T) {
+	env := fsys.Default()
 	dir := t.TempDir()
 	// Prepare .anyagent template
 	anyagentDir := filepath.Join(dir, ".anyagent")
@@ -31,7 +34,7 @@ func TestRegenerateAgentsFilePrefersProjectTemplates(t *testing.T) {
 		InstalledRules: []string{"go"},
 		Parameters:     map[string]string{"PROJECT_NAME": "X"},
 	}
-	if err := cfg.RegenerateAgentsFileAt(dir); err != nil {
+	if err := cfg.RegenerateAgentsFileAt(env, dir); err != nil {
 		t.Fatalf("regenerate: %v", err)
 	}
 	// Verify AGENTS.md contains local rule header
@@ -44,3 +47,120 @@ func TestRegenerateAgentsFilePrefersProjectTemplates(t *testing.T) {
 		t.Fatalf("AGENTS.md missing local rule: %s", want)
 	}
 }
+
+func TestLoadProjectConfigMigratesLegacyMCPServers(t *testing.T) {
+	env := fsys.Default()
+	dir := t.TempDir()
+	path := GetProjectConfigPath(dir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	legacy := "mcp_servers:\n  context7: \"npx -y @upstash/context7-mcp@latest\"\n  db:\n    command: pg-mcp\n    env:\n      PGHOST: localhost\n"
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	cfg, err := LoadProjectConfig(env, path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	c7 := cfg.MCPServers["context7"]
+	if c7.Command != "npx" || strings.Join(c7.Args, " ") != "-y @upstash/context7-mcp@latest" {
+		t.Errorf("legacy server not migrated: %+v", c7)
+	}
+	if db := cfg.MCPServers["db"]; db.Command != "pg-mcp" || db.Env["PGHOST"] != "localhost" {
+		t.Errorf("structured server not loaded: %+v", db)
+	}
+
+	// Saving writes the structured form
+	if err := cfg.Save(env, path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	b, _ := os.ReadFile(path)
+	if !strings.Contains(string(b), "command: npx") {
+		t.Errorf("expected structured form after save:\n%s", b)
+	}
+}
+
+func TestRenderAgentsContentWithProjectRule(t *testing.T) {
+	env := fsys.Default()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	extraDir := filepath.Join(dir, ".anyagent", "extra_rules")
+	if err := os.MkdirAll(extraDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".anyagent", "AGENTS.md.tmpl"), []byte("# Rules\n\n{{EXTRA_RULES}}\n"), 0644); err != nil {
+		t.Fatalf("write tmpl: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(extraDir, "cursorrules.md"), []byte("Use tabs."), 0644); err != nil {
+		t.Fatalf("write rule: %v", err)
+	}
+
+	cfg := &ProjectConfig{InstalledRules: []string{"cursorrules"}}
+	content, err := cfg.RenderAgentsContent(env, dir)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if content != "# Rules\n\nUse tabs.\n" {
+		t.Errorf("content = %q", content)
+	}
+
+	cfg.InstalledRules = []string{"../secret"}
+	if _, err := cfg.RenderAgentsContent(env, dir); err == nil {
+		t.Error("rule names with path separators should be rejected")
+	}
+}
+
+func TestRenderAgentsUsesOnlyTheGivenLayers(t *testing.T) {
+	env := fsys.Default()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The layers live in their own file system; nothing is read from the disk
+	mem := fsys.NewMemFS()
+	project, user := "/work/api/.anyagent", "/home/dev/.config/anyagent/templates"
+	if err := mem.MkdirAll(filepath.Join(user, "extra_rules"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := mem.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := mem.WriteFile(filepath.Join(project, "AGENTS.md.tmpl"), []byte("# {{PROJECT_NAME}}\n{{EXTRA_RULES}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := mem.WriteFile(filepath.Join(user, "extra_rules", "go.md"), []byte("user go rule"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &ProjectConfig{ProjectName: "api", InstalledRules: []string{"go"}}
+	content, err := RenderAgents(cfg, TemplateLayers{FS: mem, Project: project, User: user})
+	if err != nil {
+		t.Fatalf("RenderAgents failed: %v", err)
+	}
+	if content != "# api\nuser go rule" {
+		t.Errorf("content = %q", content)
+	}
+
+	// Without layers only the embedded templates are used
+	content, err = RenderAgents(cfg, TemplateLayers{})
+	if err != nil {
+		t.Fatalf("RenderAgents failed: %v", err)
+	}
+	if !strings.Contains(content, GetGoExtraRuleTemplate()) {
+		t.Error("expected the embedded go rule")
+	}
+
+	// Regenerating writes to the project directory without changing the working directory
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := cfg.RegenerateAgentsFileAt(env, dir); err != nil {
+		t.Fatalf("RegenerateAgentsFileAt failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "AGENTS.md")); err != nil {
+		t.Errorf("AGENTS.md was not written: %v", err)
+	}
+	if now, _ := os.Getwd(); now != wd {
+		t.Errorf("working directory changed to %s", now)
+	}
+}
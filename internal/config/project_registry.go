@@ -0,0 +1,152 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/shibukawa/anyagent/internal/fsys"
+)
+
+// ProjectRegistry is the user-level list of projects that have been synced on this machine,
+// so 'sync --all' can bring all of them up to date after the user templates change
+type ProjectRegistry struct {
+	Projects []RegisteredProject `json:"projects"`
+}
+
+// RegisteredProject is a single synced project
+type RegisteredProject struct {
+	Path     string    `json:"path"`
+	Name     string    `json:"name,omitempty"`
+	Agents   []string  `json:"agents,omitempty"`
+	LastSync time.Time `json:"last_sync"`
+}
+
+// Exists reports whether the project directory is still present
+func (p RegisteredProject) Exists(env *fsys.Env) bool {
+	info, err := env.FS.Stat(p.Path)
+	return err == nil && info.IsDir()
+}
+
+// GetProjectRegistryPath returns <user config dir>/anyagent/projects.json
+func GetProjectRegistryPath(env *fsys.Env) (string, error) {
+	dir, err := GetUserConfigDir(env)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "projects.json"), nil
+}
+
+// LoadProjectRegistry reads the registry; a missing file is an empty registry
+func LoadProjectRegistry(env *fsys.Env) (*ProjectRegistry, error) {
+	registry := &ProjectRegistry{}
+	path, err := GetProjectRegistryPath(env)
+	if err != nil {
+		return nil, err
+	}
+	data, err := env.FS.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+		return nil, fmt.Errorf("failed to read project registry: %w", err)
+	}
+	if err := json.Unmarshal(data, registry); err != nil {
+		return nil, fmt.Errorf("failed to parse project registry %s: %w", path, err)
+	}
+	return registry, nil
+}
+
+// UpdateProjectRegistry loads the registry, lets fn change it and saves it while holding the
+// registry lock, so concurrent syncs of different projects don't drop each other's entries.
+// Nothing is saved when fn fails.
+func UpdateProjectRegistry(env *fsys.Env, fn func(r *ProjectRegistry) error) error {
+	path, err := GetProjectRegistryPath(env)
+	if err != nil {
+		return err
+	}
+	return withFileLock(env, path, func() error {
+		registry, err := LoadProjectRegistry(env)
+		if err != nil {
+			return err
+		}
+		if err := fn(registry); err != nil {
+			return err
+		}
+		return registry.Save(env)
+	})
+}
+
+// Save writes the registry atomically. Use UpdateProjectRegistry to change the registry
+// other processes may be changing too.
+func (r *ProjectRegistry) Save(env *fsys.Env) error {
+	path, err := GetProjectRegistryPath(env)
+	if err != nil {
+		return err
+	}
+	if r.Projects == nil {
+		r.Projects = []RegisteredProject{}
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal project registry: %w", err)
+	}
+	if err := env.FS.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return fsys.WriteFileAtomic(env.FS, path, append(data, '\n'), 0644)
+}
+
+// withFileLock runs fn while holding <path>.lock
+func withFileLock(env *fsys.Env, path string, fn func() error) error {
+	if err := env.FS.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	unlock, err := fsys.Lock(env.FS, path+".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fn()
+}
+
+// Register adds the project or refreshes its entry, keeping the list sorted by path
+func (r *ProjectRegistry) Register(project RegisteredProject) {
+	project.Path = absProjectDir(project.Path)
+	for i, p := range r.Projects {
+		if p.Path == project.Path {
+			r.Projects[i] = project
+			return
+		}
+	}
+	r.Projects = append(r.Projects, project)
+	sort.Slice(r.Projects, func(i, j int) bool { return r.Projects[i].Path < r.Projects[j].Path })
+}
+
+// Find returns the registry entry of the project directory
+func (r *ProjectRegistry) Find(projectDir string) (RegisteredProject, bool) {
+	path := absProjectDir(projectDir)
+	for _, p := range r.Projects {
+		if p.Path == path {
+			return p, true
+		}
+	}
+	return RegisteredProject{}, false
+}
+
+// Prune drops the projects whose directory no longer exists and returns them
+func (r *ProjectRegistry) Prune(env *fsys.Env) []RegisteredProject {
+	var kept, pruned []RegisteredProject
+	for _, p := range r.Projects {
+		if p.Exists(env) {
+			kept = append(kept, p)
+		} else {
+			pruned = append(pruned, p)
+		}
+	}
+	r.Projects = kept
+	return pruned
+}
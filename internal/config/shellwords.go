@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitCommandLine splits a command line into words using POSIX shell quoting rules:
+// whitespace separates words, single quotes keep text literally, double quotes allow
+// backslash escapes of $ ` " \ and newline, and a backslash outside quotes escapes the
+// next character. Nothing is expanded; $VAR and ${...} are kept as written.
+// Pipelines, redirections, command lists and command substitution are rejected because
+// MCP clients launch the command directly, without a shell.
+func SplitCommandLine(s string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		case r == '\\':
+			inWord = true
+			if i+1 < len(runes) {
+				i++
+				if runes[i] != '\n' { // backslash-newline is a line continuation
+					cur.WriteRune(runes[i])
+				}
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'':
+			inWord = true
+			end := indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote in command: %s", s)
+			}
+			cur.WriteString(string(runes[i+1 : end]))
+			i = end
+		case r == '"':
+			inWord = true
+			closed := false
+			for i++; i < len(runes); i++ {
+				c := runes[i]
+				if c == '"' {
+					closed = true
+					break
+				}
+				if c == '`' || (c == '$' && i+1 < len(runes) && runes[i+1] == '(') {
+					return nil, fmt.Errorf("command substitution is not supported in command: %s", s)
+				}
+				if c == '\\' && i+1 < len(runes) && strings.ContainsRune("$`\"\\\n", runes[i+1]) {
+					i++
+					if runes[i] != '\n' {
+						cur.WriteRune(runes[i])
+					}
+					continue
+				}
+				cur.WriteRune(c)
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated double quote in command: %s", s)
+			}
+		case r == '`' || (r == '$' && i+1 < len(runes) && runes[i+1] == '('):
+			return nil, fmt.Errorf("command substitution is not supported in command: %s", s)
+		case strings.ContainsRune("|&;<>()", r):
+			return nil, fmt.Errorf("shell operator %q is not supported in command (the server is launched without a shell): %s", r, s)
+		default:
+			inWord = true
+			cur.WriteRune(r)
+		}
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
+
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"npx -y @upstash/context7-mcp@latest", []string{"npx", "-y", "@upstash/context7-mcp@latest"}},
+		{"node server.js --root '/path with spaces'", []string{"node", "server.js", "--root", "/path with spaces"}},
+		{`node "a \"quoted\" arg" b\ c`, []string{"node", `a "quoted" arg`, "b c"}},
+		{`srv --token '${env:TOKEN}' $HOME`, []string{"srv", "--token", "${env:TOKEN}", "$HOME"}},
+		{`srv ''`, []string{"srv", ""}},
+		{`srv "it's"`, []string{"srv", "it's"}},
+		{"  srv   a\tb  ", []string{"srv", "a", "b"}},
+	}
+	for _, tt := range tests {
+		got, err := SplitCommandLine(tt.in)
+		if err != nil {
+			t.Errorf("SplitCommandLine(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitCommandLine(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSplitCommandLineRejects(t *testing.T) {
+	for _, in := range []string{
+		"srv | tee log",
+		"srv > out",
+		"srv; rm -rf x",
+		"srv && other",
+		"srv $(cat token)",
+		`srv "$(cat token)"`,
+		"srv `cat token`",
+		"srv 'unterminated",
+		`srv "unterminated`,
+	} {
+		if _, err := SplitCommandLine(in); err == nil {
+			t.Errorf("SplitCommandLine(%q) should fail", in)
+		}
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"embed"
 	"fmt"
 	"io/fs"
-	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/shibukawa/anyagent/internal/fsys"
 )
 
 //go:embed configsrc/templates/commands/*
@@ -22,58 +24,185 @@ func GetCommandTemplate(command string) (string, error) {
 	return string(content), nil
 }
 
-// ResolveTemplateContent reads a template with precedence:
-// 1) projectDir/.anyagent/<relPath>
-// 2) <userConfigDir>/templates/<relPath>
-// 3) embeddedFallback()
-func ResolveTemplateContent(projectDir string, relPath string, embeddedFallback func() (string, error)) (string, error) {
-	// Determine project base
-	base := projectDir
-	if base == "" {
-		if wd, err := os.Getwd(); err == nil {
-			base = wd
-		}
+// TemplateLayers are the directories templates are read from before the embedded defaults
+type TemplateLayers struct {
+	FS      fsys.FS // file system the layers are read from; nil skips both layers
+	Project string  // <project>/.anyagent; empty skips the layer
+	User    string  // <user config dir>/anyagent/templates; empty skips the layer
+}
+
+// LayersFor returns the template layers of projectDir (the environment's project directory
+// when empty)
+func LayersFor(env *fsys.Env, projectDir string) TemplateLayers {
+	layers := TemplateLayers{FS: env.FS}
+	if projectDir == "" {
+		projectDir, _ = env.Getwd()
 	}
-	// Project override
-	if base != "" {
-		if b, err := os.ReadFile(filepath.Join(base, ".anyagent", relPath)); err == nil {
-			return string(b), nil
-		}
+	if projectDir != "" {
+		layers.Project = filepath.Join(projectDir, ".anyagent")
 	}
-	// User override
-	if userDir, err := GetUserConfigDir(); err == nil {
-		if b, err := os.ReadFile(filepath.Join(userDir, "templates", relPath)); err == nil {
+	if userDir, err := GetUserConfigDir(env); err == nil {
+		layers.User = filepath.Join(userDir, "templates")
+	}
+	return layers
+}
+
+// Resolve reads relPath from the project layer, then the user layer, then embeddedFallback()
+func (l TemplateLayers) Resolve(relPath string, embeddedFallback func() (string, error)) (string, error) {
+	for _, dir := range []string{l.Project, l.User} {
+		if dir == "" || l.FS == nil {
+			continue
+		}
+		if b, err := l.FS.ReadFile(filepath.Join(dir, relPath)); err == nil {
 			return string(b), nil
 		}
 	}
-	// Embedded fallback
 	return embeddedFallback()
 }
 
+// ResolveTemplateContent reads a template with precedence:
+// 1) projectDir/.anyagent/<relPath>
+// 2) <userConfigDir>/templates/<relPath>
+// 3) embeddedFallback()
+func ResolveTemplateContent(env *fsys.Env, projectDir string, relPath string, embeddedFallback func() (string, error)) (string, error) {
+	return LayersFor(env, projectDir).Resolve(relPath, embeddedFallback)
+}
+
 // GetCommandTemplateResolved resolves a command template using standard precedence.
-func GetCommandTemplateResolved(projectDir, command string) (string, error) {
+func GetCommandTemplateResolved(env *fsys.Env, projectDir, command string) (string, error) {
 	rel := filepath.Join("commands", fmt.Sprintf("%s.md", command))
-	return ResolveTemplateContent(projectDir, rel, func() (string, error) {
+	return ResolveTemplateContent(env, projectDir, rel, func() (string, error) {
 		return GetCommandTemplate(command)
 	})
 }
 
-// GetAvailableCommands returns a list of available command templates
-func GetAvailableCommands() ([]string, error) {
+// Template layer names, in order of precedence.
+const (
+	TemplateSourceProject  = "project"
+	TemplateSourceUser     = "user"
+	TemplateSourceEmbedded = "embedded"
+)
+
+// TemplateInfo describes a discoverable template and the layer it resolves from.
+type TemplateInfo struct {
+	Name   string
+	Source string
+}
+
+// ListCommandTemplates returns the command templates available to a project, merged across
+// project (.anyagent/commands), user (<userConfigDir>/templates/commands) and embedded layers.
+// Each name is reported once with the highest-precedence layer that provides it.
+func ListCommandTemplates(env *fsys.Env, projectDir string) ([]TemplateInfo, error) {
+	return listTemplates(env, projectDir, "commands", ".md", commandsFS, "configsrc/templates/commands")
+}
+
+// GetPersonaTemplate retrieves the embedded template content for the specified persona
+func GetPersonaTemplate(persona string) (string, error) {
+	content, err := templatesFS.ReadFile(fmt.Sprintf("configsrc/templates/agents/%s.md", persona))
+	if err != nil {
+		return "", fmt.Errorf("persona template not found: %s", persona)
+	}
+	return string(content), nil
+}
+
+// GetPersonaTemplateResolved resolves a persona template using standard precedence.
+func GetPersonaTemplateResolved(env *fsys.Env, projectDir, persona string) (string, error) {
+	rel := filepath.Join("agents", fmt.Sprintf("%s.md", persona))
+	return ResolveTemplateContent(env, projectDir, rel, func() (string, error) {
+		return GetPersonaTemplate(persona)
+	})
+}
+
+// ListPersonaTemplates returns the persona (agents/) templates available to a project,
+// merged across project, user and embedded layers.
+func ListPersonaTemplates(env *fsys.Env, projectDir string) ([]TemplateInfo, error) {
+	return listTemplates(env, projectDir, "agents", ".md", templatesFS, "configsrc/templates/agents")
+}
+
+// GetHookTemplate retrieves the embedded template content for the specified hook
+func GetHookTemplate(hook string) (string, error) {
+	content, err := templatesFS.ReadFile(fmt.Sprintf("configsrc/templates/hooks/%s.yaml", hook))
+	if err != nil {
+		return "", fmt.Errorf("hook template not found: %s", hook)
+	}
+	return string(content), nil
+}
+
+// GetHookTemplateResolved resolves a hook template using standard precedence.
+func GetHookTemplateResolved(env *fsys.Env, projectDir, hook string) (string, error) {
+	rel := filepath.Join("hooks", fmt.Sprintf("%s.yaml", hook))
+	return ResolveTemplateContent(env, projectDir, rel, func() (string, error) {
+		return GetHookTemplate(hook)
+	})
+}
+
+// ListHookTemplates returns the hook templates available to a project,
+// merged across project, user and embedded layers.
+func ListHookTemplates(env *fsys.Env, projectDir string) ([]TemplateInfo, error) {
+	return listTemplates(env, projectDir, "hooks", ".yaml", templatesFS, "configsrc/templates/hooks")
+}
+
+// GetAvailableCommands returns the names of command templates available to a project
+func GetAvailableCommands(env *fsys.Env, projectDir string) ([]string, error) {
+	infos, err := ListCommandTemplates(env, projectDir)
+	if err != nil {
+		return nil, err
+	}
 	var commands []string
+	for _, info := range infos {
+		commands = append(commands, info.Name)
+	}
+	return commands, nil
+}
+
+// listTemplates merges template names found under relDir in every layer, sorted by name.
+func listTemplates(env *fsys.Env, projectDir, relDir, ext string, embedded fs.FS, embeddedDir string) ([]TemplateInfo, error) {
+	found := map[string]string{}
 
-	entries, err := fs.ReadDir(commandsFS, "configsrc/templates/commands")
+	// Lowest precedence first so higher layers overwrite the source
+	entries, err := fs.ReadDir(embedded, embeddedDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read commands directory: %w", err)
+		return nil, fmt.Errorf("failed to read %s directory: %w", relDir, err)
+	}
+	for _, name := range templateNames(entries, ext) {
+		found[name] = TemplateSourceEmbedded
+	}
+	if userDir, err := GetUserConfigDir(env); err == nil {
+		if entries, err := env.FS.ReadDir(filepath.Join(userDir, "templates", relDir)); err == nil {
+			for _, name := range templateNames(entries, ext) {
+				found[name] = TemplateSourceUser
+			}
+		}
+	}
+	base := projectDir
+	if base == "" {
+		if wd, err := env.Getwd(); err == nil {
+			base = wd
+		}
+	}
+	if base != "" {
+		if entries, err := env.FS.ReadDir(filepath.Join(base, ".anyagent", relDir)); err == nil {
+			for _, name := range templateNames(entries, ext) {
+				found[name] = TemplateSourceProject
+			}
+		}
 	}
 
+	var infos []TemplateInfo
+	for name, source := range found {
+		infos = append(infos, TemplateInfo{Name: name, Source: source})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// templateNames returns file names with the given extension, extension removed
+func templateNames(entries []fs.DirEntry, ext string) []string {
+	var names []string
 	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
-			// Remove .md extension to get command name
-			command := strings.TrimSuffix(entry.Name(), ".md")
-			commands = append(commands, command)
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ext) {
+			names = append(names, strings.TrimSuffix(entry.Name(), ext))
 		}
 	}
-
-	return commands, nil
+	return names
 }
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/shibukawa/anyagent/internal/fsys"
 )
 
 func TestGetCommandTemplate(t *testing.T) {
@@ -53,7 +57,8 @@ func TestGetCommandTemplate(t *testing.T) {
 }
 
 func TestGetAvailableCommands(t *testing.T) {
-	commands, err := GetAvailableCommands()
+	env := fsys.Default()
+	commands, err := GetAvailableCommands(env, "")
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -77,3 +82,54 @@ func TestGetAvailableCommands(t *testing.T) {
 		}
 	}
 }
+
+func TestListCommandTemplatesMergesLayers(t *testing.T) {
+	env := fsys.Default()
+	projectDir := t.TempDir()
+	userConfigHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userConfigHome)
+
+	userCommands := filepath.Join(userConfigHome, "anyagent", "templates", "commands")
+	projectCommands := filepath.Join(projectDir, ".anyagent", "commands")
+	for _, dir := range []string{userCommands, projectCommands} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(userCommands, "lint.md"):             "# Lint",
+		filepath.Join(userCommands, "create-readme.md"):    "# README (user)",
+		filepath.Join(projectCommands, "deploy.md"):        "# Deploy",
+		filepath.Join(projectCommands, "lint.md"):          "# Lint (project)",
+		filepath.Join(projectCommands, "notes.txt"):        "ignored",
+		filepath.Join(userCommands, "editorconfig.md.bak"): "ignored",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+
+	infos, err := ListCommandTemplates(env, projectDir)
+	if err != nil {
+		t.Fatalf("ListCommandTemplates: %v", err)
+	}
+	got := map[string]string{}
+	for _, info := range infos {
+		got[info.Name] = info.Source
+	}
+	want := map[string]string{
+		"deploy":        TemplateSourceProject,
+		"lint":          TemplateSourceProject,
+		"create-readme": TemplateSourceUser,
+		"editorconfig":  TemplateSourceEmbedded,
+	}
+	for name, source := range want {
+		if got[name] != source {
+			t.Errorf("command %s: source = %q, want %q", name, got[name], source)
+		}
+	}
+	if _, ok := got["notes"]; ok {
+		t.Errorf("non-markdown file should not be listed: %v", got)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/shibukawa/anyagent/internal/fsys"
 )
 
 // Embedded template files root (deploy layout)
@@ -17,8 +19,8 @@ var templatesFS embed.FS
 // (individual file embeds removed; templatesFS now holds the entire tree)
 
 // GetUserConfigDir returns the user configuration directory for anyagent
-func GetUserConfigDir() (string, error) {
-	userConfigDir, err := os.UserConfigDir()
+func GetUserConfigDir(env *fsys.Env) (string, error) {
+	userConfigDir, err := env.UserConfigDir()
 	if err != nil {
 		return "", err
 	}
@@ -26,29 +28,39 @@ func GetUserConfigDir() (string, error) {
 }
 
 // CreateUserConfigDir creates the user configuration directory
-func CreateUserConfigDir(dir string) error {
-	return os.MkdirAll(dir, 0755)
+func CreateUserConfigDir(env *fsys.Env, dir string) error {
+	return env.FS.MkdirAll(dir, 0755)
 }
 
 // CreateTemplateStructure creates the template directory structure
-func CreateTemplateStructure(baseDir string) error {
+func CreateTemplateStructure(env *fsys.Env, baseDir string) error {
 	templatesDir := filepath.Join(baseDir, "templates")
 	commandsDir := filepath.Join(templatesDir, "commands")
 	extraRulesDir := filepath.Join(templatesDir, "extra_rules")
+	agentsDir := filepath.Join(templatesDir, "agents")
+	hooksDir := filepath.Join(templatesDir, "hooks")
+
+	if err := env.FS.MkdirAll(templatesDir, 0755); err != nil {
+		return err
+	}
+
+	if err := env.FS.MkdirAll(commandsDir, 0755); err != nil {
+		return err
+	}
 
-	if err := os.MkdirAll(templatesDir, 0755); err != nil {
+	if err := env.FS.MkdirAll(agentsDir, 0755); err != nil {
 		return err
 	}
 
-	if err := os.MkdirAll(commandsDir, 0755); err != nil {
+	if err := env.FS.MkdirAll(hooksDir, 0755); err != nil {
 		return err
 	}
 
-	return os.MkdirAll(extraRulesDir, 0755)
+	return env.FS.MkdirAll(extraRulesDir, 0755)
 }
 
 // CreateTemplateFiles creates the default template files
-func CreateTemplateFiles(baseDir string) error {
+func CreateTemplateFiles(env *fsys.Env, baseDir string) error {
 	return fs.WalkDir(templatesFS, "configsrc", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -59,7 +71,7 @@ func CreateTemplateFiles(baseDir string) error {
 				return nil
 			}
 			rel := strings.TrimPrefix(path, "configsrc/")
-			return os.MkdirAll(filepath.Join(baseDir, rel), 0755)
+			return env.FS.MkdirAll(filepath.Join(baseDir, rel), 0755)
 		}
 		b, err := templatesFS.ReadFile(path)
 		if err != nil {
@@ -67,16 +79,16 @@ func CreateTemplateFiles(baseDir string) error {
 		}
 		rel := path[len("configsrc/"):]
 		outPath := filepath.Join(baseDir, rel)
-		if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		if err := env.FS.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
 			return err
 		}
-		return os.WriteFile(outPath, b, 0644)
+		return env.FS.WriteFile(outPath, b, 0644)
 	})
 }
 
 // CreateTemplateFilesIfMissing creates default template files only when they don't exist.
 // Existing files are preserved and not overwritten.
-func CreateTemplateFilesIfMissing(baseDir string) error {
+func CreateTemplateFilesIfMissing(env *fsys.Env, baseDir string) error {
 	return fs.WalkDir(templatesFS, "configsrc", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -86,15 +98,15 @@ func CreateTemplateFilesIfMissing(baseDir string) error {
 		}
 		rel := path[len("configsrc/"):]
 		outPath := filepath.Join(baseDir, rel)
-		if _, err := os.Stat(outPath); os.IsNotExist(err) {
-			if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		if _, err := env.FS.Stat(outPath); os.IsNotExist(err) {
+			if err := env.FS.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
 				return err
 			}
 			b, err := templatesFS.ReadFile(path)
 			if err != nil {
 				return err
 			}
-			if err := os.WriteFile(outPath, b, 0644); err != nil {
+			if err := env.FS.WriteFile(outPath, b, 0644); err != nil {
 				return err
 			}
 		}
@@ -103,16 +115,16 @@ func CreateTemplateFilesIfMissing(baseDir string) error {
 }
 
 // CreateAnyagentProject creates the anyagent configuration project
-func CreateAnyagentProject(baseDir string) error {
+func CreateAnyagentProject(env *fsys.Env, baseDir string) error {
 	// Ensure the base directory exists
-	if err := os.MkdirAll(baseDir, 0755); err != nil {
+	if err := env.FS.MkdirAll(baseDir, 0755); err != nil {
 		return err
 	}
 
 	// Create AGENTS.md for anyagent configuration project
 	agentsContent := getAnyagentAGENTSContent()
 	agentsPath := filepath.Join(baseDir, "AGENTS.md")
-	if err := os.WriteFile(agentsPath, []byte(agentsContent), 0644); err != nil {
+	if err := env.FS.WriteFile(agentsPath, []byte(agentsContent), 0644); err != nil {
 		return err
 	}
 
@@ -131,11 +143,11 @@ func CreateAnyagentProject(baseDir string) error {
 		if agentDir == ".amazonq" {
 			// Create rules subdirectory for Amazon Q
 			rulesDir := filepath.Join(dirPath, "rules")
-			if err := os.MkdirAll(rulesDir, 0755); err != nil {
+			if err := env.FS.MkdirAll(rulesDir, 0755); err != nil {
 				return err
 			}
 		} else {
-			if err := os.MkdirAll(dirPath, 0755); err != nil {
+			if err := env.FS.MkdirAll(dirPath, 0755); err != nil {
 				return err
 			}
 		}
@@ -148,18 +160,18 @@ func CreateAnyagentProject(baseDir string) error {
 		}
 
 		// Remove existing file/link if it exists
-		_ = os.Remove(symlinkPath) // Ignore error if file doesn't exist
+		_ = env.FS.Remove(symlinkPath) // Ignore error if file doesn't exist
 
 		// Create symbolic link
-		if err := os.Symlink(agentsRelativePath, symlinkPath); err != nil {
+		if err := env.FS.Symlink(agentsRelativePath, symlinkPath); err != nil {
 			return fmt.Errorf("failed to create symbolic link %s: %w", symlinkPath, err)
 		}
 	}
 
 	// Create CLAUDE.md symbolic link at project root for Claude
 	claudeSymlinkPath := filepath.Join(baseDir, "CLAUDE.md")
-	_ = os.Remove(claudeSymlinkPath) // Remove existing file/link if it exists
-	if err := os.Symlink("AGENTS.md", claudeSymlinkPath); err != nil {
+	_ = env.FS.Remove(claudeSymlinkPath) // Remove existing file/link if it exists
+	if err := env.FS.Symlink("AGENTS.md", claudeSymlinkPath); err != nil {
 		return fmt.Errorf("failed to create CLAUDE.md symbolic link: %w", err)
 	}
 
@@ -167,8 +179,8 @@ func CreateAnyagentProject(baseDir string) error {
 }
 
 // CheckUserConfigExists checks if the user configuration directory exists
-func CheckUserConfigExists(dir string) bool {
-	_, err := os.Stat(dir)
+func CheckUserConfigExists(env *fsys.Env, dir string) bool {
+	_, err := env.FS.Stat(dir)
 	return err == nil
 }
 
@@ -4,10 +4,13 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/shibukawa/anyagent/internal/fsys"
 )
 
 // TestUserConfigDir tests the user configuration directory functionality
 func TestUserConfigDir(t *testing.T) {
+	env := fsys.Default()
 	tests := []struct {
 		name    string
 		wantErr bool
@@ -20,7 +23,7 @@ func TestUserConfigDir(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := GetUserConfigDir()
+			got, err := GetUserConfigDir(env)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetUserConfigDir() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -34,6 +37,7 @@ func TestUserConfigDir(t *testing.T) {
 
 // TestCreateUserConfigDir tests creating the user configuration directory
 func TestCreateUserConfigDir(t *testing.T) {
+	env := fsys.Default()
 	// Use a temporary directory for testing
 	tempDir := t.TempDir()
 	testConfigDir := filepath.Join(tempDir, "anyagent")
@@ -57,7 +61,7 @@ func TestCreateUserConfigDir(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := CreateUserConfigDir(tt.dir)
+			err := CreateUserConfigDir(env, tt.dir)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CreateUserConfigDir() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -75,6 +79,7 @@ func TestCreateUserConfigDir(t *testing.T) {
 
 // TestCreateTemplateStructure tests creating the template directory structure
 func TestCreateTemplateStructure(t *testing.T) {
+	env := fsys.Default()
 	tempDir := t.TempDir()
 	configDir := filepath.Join(tempDir, "anyagent")
 
@@ -92,7 +97,7 @@ func TestCreateTemplateStructure(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := CreateTemplateStructure(tt.baseDir)
+			err := CreateTemplateStructure(env, tt.baseDir)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CreateTemplateStructure() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -117,11 +122,12 @@ func TestCreateTemplateStructure(t *testing.T) {
 
 // TestCreateTemplateFiles tests creating template files
 func TestCreateTemplateFiles(t *testing.T) {
+	env := fsys.Default()
 	tempDir := t.TempDir()
 	configDir := filepath.Join(tempDir, "anyagent")
 
 	// First create the directory structure
-	err := CreateTemplateStructure(configDir)
+	err := CreateTemplateStructure(env, configDir)
 	if err != nil {
 		t.Fatalf("Failed to create template structure: %v", err)
 	}
@@ -140,7 +146,7 @@ func TestCreateTemplateFiles(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := CreateTemplateFiles(tt.baseDir)
+			err := CreateTemplateFiles(env, tt.baseDir)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CreateTemplateFiles() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -169,6 +175,7 @@ func TestCreateTemplateFiles(t *testing.T) {
 
 // TestCreateAnyagentProject tests creating the anyagent configuration project
 func TestCreateAnyagentProject(t *testing.T) {
+	env := fsys.Default()
 	tempDir := t.TempDir()
 	configDir := filepath.Join(tempDir, "anyagent")
 
@@ -186,7 +193,7 @@ func TestCreateAnyagentProject(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := CreateAnyagentProject(tt.baseDir)
+			err := CreateAnyagentProject(env, tt.baseDir)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CreateAnyagentProject() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -220,6 +227,7 @@ func TestCreateAnyagentProject(t *testing.T) {
 
 // TestCheckUserConfigExists tests checking if user config directory exists
 func TestCheckUserConfigExists(t *testing.T) {
+	env := fsys.Default()
 	tempDir := t.TempDir()
 	existingDir := filepath.Join(tempDir, "existing")
 	nonExistingDir := filepath.Join(tempDir, "nonexisting")
@@ -249,7 +257,7 @@ func TestCheckUserConfigExists(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := CheckUserConfigExists(tt.dir)
+			got := CheckUserConfigExists(env, tt.dir)
 			if got != tt.want {
 				t.Errorf("CheckUserConfigExists() = %v, want %v", got, tt.want)
 			}
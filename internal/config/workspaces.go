@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/shibukawa/anyagent/internal/fsys"
+)
+
+// Workspace declares monorepo packages that get their own nested AGENTS.md:
+//
+//	workspaces:
+//	  - path: services/*
+//	    rules: [go, docker]
+//	    parameters:
+//	      PRIMARY_LANGUAGE: Go
+//	  - path: web/*
+//	    rules: [typescript, react]
+//	    commands: [storybook]
+//
+// Rules and commands are added to the root ones, and parameters override the root values.
+type Workspace struct {
+	Path       string            `yaml:"path"` // glob relative to the project root
+	Rules      []string          `yaml:"rules,omitempty"`
+	Commands   []string          `yaml:"commands,omitempty"`
+	Parameters map[string]string `yaml:"parameters,omitempty"`
+}
+
+// ResolvedWorkspace is a directory matched by a workspace glob with its effective configuration
+type ResolvedWorkspace struct {
+	Dir    string         // slash-separated path relative to the project root
+	Config *ProjectConfig // root configuration merged with the workspace settings
+}
+
+// ResolveWorkspaces expands the workspace globs into directories, sorted by path. A directory
+// matched by several entries uses the first one.
+func (c *ProjectConfig) ResolveWorkspaces(env *fsys.Env, projectDir string) ([]ResolvedWorkspace, error) {
+	var resolved []ResolvedWorkspace
+	seen := map[string]bool{}
+	for _, ws := range c.Workspaces {
+		pattern := filepath.Clean(filepath.FromSlash(ws.Path))
+		if ws.Path == "" || pattern == "." || !filepath.IsLocal(pattern) {
+			return nil, fmt.Errorf("invalid workspace path %q: must be a glob inside the project", ws.Path)
+		}
+		matches, err := fsys.Glob(env.FS, filepath.Join(projectDir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace path %q: %w", ws.Path, err)
+		}
+		for _, match := range matches {
+			if info, err := env.FS.Stat(match); err != nil || !info.IsDir() {
+				continue
+			}
+			rel, err := filepath.Rel(projectDir, match)
+			if err != nil {
+				continue
+			}
+			dir := filepath.ToSlash(rel)
+			if seen[dir] {
+				continue
+			}
+			seen[dir] = true
+			resolved = append(resolved, ResolvedWorkspace{Dir: dir, Config: c.workspaceConfig(ws)})
+		}
+	}
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].Dir < resolved[j].Dir })
+	return resolved, nil
+}
+
+// workspaceConfig returns the root configuration with the workspace's rules, commands and
+// parameters applied
+func (c *ProjectConfig) workspaceConfig(ws Workspace) *ProjectConfig {
+	merged := *c
+	merged.Workspaces = nil
+	merged.InstalledRules = appendMissing(slices.Clone(c.InstalledRules), ws.Rules)
+	merged.InstalledCommands = appendMissing(slices.Clone(c.InstalledCommands), ws.Commands)
+	merged.Parameters = map[string]string{}
+	for k, v := range c.Parameters {
+		merged.Parameters[k] = v
+	}
+	for k, v := range ws.Parameters {
+		merged.Parameters[k] = v
+	}
+	if v, ok := ws.Parameters["PROJECT_NAME"]; ok {
+		merged.ProjectName = v
+	}
+	if v, ok := ws.Parameters["PROJECT_DESCRIPTION"]; ok {
+		merged.ProjectDescription = v
+	}
+	return &merged
+}
+
+func appendMissing(list, items []string) []string {
+	for _, item := range items {
+		if !slices.Contains(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
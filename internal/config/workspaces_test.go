@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shibukawa/anyagent/internal/fsys"
+)
+
+func TestResolveWorkspaces(t *testing.T) {
+	env := fsys.Default()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	for _, d := range []string{"services/api", "services/worker", "web/app"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Files matched by a glob are not workspaces
+	if err := os.WriteFile(filepath.Join(dir, "services", "README.md"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ProjectConfig{
+		ProjectName:       "mono",
+		InstalledRules:    []string{"docker"},
+		InstalledCommands: []string{"general"},
+		Parameters:        map[string]string{"PROJECT_NAME": "mono", "PRIMARY_LANGUAGE": "Go"},
+		Workspaces: []Workspace{
+			{Path: "web/*", Rules: []string{"typescript", "docker"}, Parameters: map[string]string{"PRIMARY_LANGUAGE": "TypeScript"}},
+			{Path: "services/*", Rules: []string{"go"}, Commands: []string{"coding"}},
+			{Path: "services/api", Rules: []string{"python"}}, // already matched above
+		},
+	}
+
+	got, err := cfg.ResolveWorkspaces(env, dir)
+	if err != nil {
+		t.Fatalf("ResolveWorkspaces failed: %v", err)
+	}
+	var dirs []string
+	for _, ws := range got {
+		dirs = append(dirs, ws.Dir)
+	}
+	if want := []string{"services/api", "services/worker", "web/app"}; !slices.Equal(dirs, want) {
+		t.Fatalf("dirs = %v, want %v", dirs, want)
+	}
+
+	api := got[0].Config
+	if want := []string{"docker", "go"}; !slices.Equal(api.InstalledRules, want) {
+		t.Errorf("api rules = %v, want %v", api.InstalledRules, want)
+	}
+	if want := []string{"general", "coding"}; !slices.Equal(api.InstalledCommands, want) {
+		t.Errorf("api commands = %v, want %v", api.InstalledCommands, want)
+	}
+	web := got[2].Config
+	if want := []string{"docker", "typescript"}; !slices.Equal(web.InstalledRules, want) {
+		t.Errorf("web rules = %v, want %v", web.InstalledRules, want)
+	}
+	if web.Parameters["PRIMARY_LANGUAGE"] != "TypeScript" || web.Parameters["PROJECT_NAME"] != "mono" {
+		t.Errorf("web parameters = %v", web.Parameters)
+	}
+	// The root configuration is left untouched
+	if cfg.Parameters["PRIMARY_LANGUAGE"] != "Go" || len(cfg.InstalledRules) != 1 {
+		t.Errorf("root config modified: %+v", cfg)
+	}
+
+	content, err := web.RenderAgentsContent(env, dir)
+	if err != nil {
+		t.Fatalf("RenderAgentsContent failed: %v", err)
+	}
+	if !strings.Contains(content, "TypeScript") {
+		t.Errorf("workspace AGENTS.md should use the workspace parameters:\n%s", content)
+	}
+
+	for _, bad := range []string{"../other", "/abs/*", ""} {
+		cfg := &ProjectConfig{Workspaces: []Workspace{{Path: bad}}}
+		if _, err := cfg.ResolveWorkspaces(env, dir); err == nil {
+			t.Errorf("workspace path %q should be rejected", bad)
+		}
+	}
+}
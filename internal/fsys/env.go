@@ -0,0 +1,98 @@
+package fsys
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Env is the environment commands run in. Empty directories, a nil Stdin and nil Vars fall
+// back to the process (working directory, $HOME, os.UserConfigDir, os.Stdin, os.Environ), so
+// the zero value plus OS behaves like the CLI.
+type Env struct {
+	FS         FS
+	ProjectDir string    // project used when a command gets no directory
+	HomeDir    string    // where agents keep user-global files (~/.codex, ~/.aws/amazonq)
+	ConfigDir  string    // user config directory; anyagent keeps its templates in ConfigDir/anyagent
+	Stdin      io.Reader // answers to prompts
+	Vars       []string  // environment variables as KEY=value, for ${env:NAME} and launched servers
+}
+
+// Default returns the environment of the current process on the real file system
+func Default() *Env {
+	return &Env{FS: OS}
+}
+
+// Memory returns an environment on an empty in-memory file system with projectDir and home
+// created. The config directory is home/.config.
+func Memory(projectDir, home string) *Env {
+	m := NewMemFS()
+	_ = m.MkdirAll(projectDir, 0755)
+	_ = m.MkdirAll(home, 0755)
+	return &Env{FS: m, ProjectDir: projectDir, HomeDir: home, ConfigDir: filepath.Join(home, ".config")}
+}
+
+// Getwd returns the project directory, or the working directory when it is not set
+func (e *Env) Getwd() (string, error) {
+	if e.ProjectDir != "" {
+		return e.ProjectDir, nil
+	}
+	return os.Getwd()
+}
+
+// UserHomeDir returns the home directory, or the user's when it is not set
+func (e *Env) UserHomeDir() (string, error) {
+	if e.HomeDir != "" {
+		return e.HomeDir, nil
+	}
+	return os.UserHomeDir()
+}
+
+// UserConfigDir returns the user config directory, or the user's when it is not set
+func (e *Env) UserConfigDir() (string, error) {
+	if e.ConfigDir != "" {
+		return e.ConfigDir, nil
+	}
+	return os.UserConfigDir()
+}
+
+// Input returns the reader prompts read answers from, or os.Stdin when Stdin is not set
+func (e *Env) Input() io.Reader {
+	if e.Stdin != nil {
+		return e.Stdin
+	}
+	return os.Stdin
+}
+
+// InputIsTerminal reports whether prompts can be answered: a given Stdin always can, the
+// process stdin only when it is a terminal
+func (e *Env) InputIsTerminal() bool {
+	if e.Stdin != nil {
+		return true
+	}
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Environ returns the environment variables, or the process environment when Vars is not set
+func (e *Env) Environ() []string {
+	if e.Vars != nil {
+		return e.Vars
+	}
+	return os.Environ()
+}
+
+// LookupEnv returns the value of an environment variable from Environ
+func (e *Env) LookupEnv(name string) (string, bool) {
+	if e.Vars == nil {
+		return os.LookupEnv(name)
+	}
+	// Later entries win, as with duplicate keys passed to exec.Cmd
+	for i := len(e.Vars) - 1; i >= 0; i-- {
+		if k, v, ok := strings.Cut(e.Vars[i], "="); ok && k == name {
+			return v, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,25 @@
+package fsys
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnvFallsBackToProcess(t *testing.T) {
+	t.Setenv("ANYAGENT_FSYS_TEST", "process")
+	e := &Env{}
+	if v, ok := e.LookupEnv("ANYAGENT_FSYS_TEST"); !ok || v != "process" {
+		t.Errorf("expected the process environment, got %q %v", v, ok)
+	}
+	e.Vars = []string{"ANYAGENT_FSYS_TEST=a", "OTHER=b", "ANYAGENT_FSYS_TEST=c=d"}
+	if v, ok := e.LookupEnv("ANYAGENT_FSYS_TEST"); !ok || v != "c=d" {
+		t.Errorf("expected the last given value, got %q %v", v, ok)
+	}
+	if _, ok := e.LookupEnv("HOME"); ok {
+		t.Error("given Vars must not fall back to the process environment")
+	}
+	e.Stdin = strings.NewReader("")
+	if !e.InputIsTerminal() || e.Input() != e.Stdin {
+		t.Error("a given Stdin should be used for prompts")
+	}
+}
@@ -0,0 +1,192 @@
+// Package fsys abstracts the file system and the process environment (working directory,
+// home and user config directories) so commands can run against an in-memory tree.
+package fsys
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FS is the file access commands need. Paths are OS paths; errors are *fs.PathError values
+// that os.IsNotExist and errors.Is(err, fs.ErrNotExist) understand.
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	// WriteNewFile is WriteFile for a file that must not exist yet; it fails with fs.ErrExist
+	// otherwise, atomically, so it can take a lock file
+	WriteNewFile(name string, data []byte, perm fs.FileMode) error
+	Rename(oldpath, newpath string) error
+	MkdirAll(path string, perm fs.FileMode) error
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Remove(name string) error
+	RemoveAll(path string) error
+	Symlink(oldname, newname string) error
+	Readlink(name string) (string, error)
+}
+
+// OS is the FS of the real file system
+var OS FS = osFS{}
+
+type osFS struct{}
+
+func (osFS) ReadFile(name string) ([]byte, error) { return os.ReadFile(name) }
+
+func (osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (osFS) WriteNewFile(name string, data []byte, perm fs.FileMode) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (osFS) Rename(oldpath, newpath string) error { return os.Rename(oldpath, newpath) }
+
+func (osFS) MkdirAll(path string, perm fs.FileMode) error { return os.MkdirAll(path, perm) }
+
+func (osFS) Stat(name string) (fs.FileInfo, error) { return os.Stat(name) }
+
+func (osFS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+
+func (osFS) Remove(name string) error { return os.Remove(name) }
+
+func (osFS) RemoveAll(path string) error { return os.RemoveAll(path) }
+
+func (osFS) Symlink(oldname, newname string) error { return os.Symlink(oldname, newname) }
+
+func (osFS) Readlink(name string) (string, error) { return os.Readlink(name) }
+
+// Walk is filepath.Walk on fsys: fn is called for root and everything below it in lexical
+// order, without following symbolic links
+func Walk(fsys FS, root string, fn filepath.WalkFunc) error {
+	info, err := fsys.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walk(fsys, root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func walk(fsys FS, path string, info fs.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := fsys.ReadDir(path)
+	err1 := fn(path, info, err)
+	if err != nil || err1 != nil {
+		return err1
+	}
+	for _, entry := range entries {
+		name := filepath.Join(path, entry.Name())
+		info, err := fsys.Lstat(name)
+		if err != nil {
+			if err := fn(name, info, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := walk(fsys, name, info, fn); err != nil {
+			if !info.IsDir() || err != filepath.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WalkDir is filepath.WalkDir on fsys
+func WalkDir(fsys FS, root string, fn fs.WalkDirFunc) error {
+	return Walk(fsys, root, func(path string, info fs.FileInfo, err error) error {
+		var d fs.DirEntry
+		if info != nil {
+			d = fs.FileInfoToDirEntry(info)
+		}
+		return fn(path, d, err)
+	})
+}
+
+// Glob is filepath.Glob on fsys
+func Glob(fsys FS, pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	if !hasMeta(pattern) {
+		if _, err := fsys.Lstat(pattern); err != nil {
+			return nil, nil
+		}
+		return []string{pattern}, nil
+	}
+	dir, file := filepath.Split(pattern)
+	dir = cleanGlobPath(dir)
+	if !hasMeta(dir) {
+		return glob(fsys, dir, file, nil)
+	}
+	dirs, err := Glob(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, d := range dirs {
+		if matches, err = glob(fsys, d, file, matches); err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+func glob(fsys FS, dir, pattern string, matches []string) ([]string, error) {
+	info, err := fsys.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return matches, nil
+	}
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return matches, nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ok, err := filepath.Match(pattern, name); err != nil {
+			return matches, err
+		} else if ok {
+			matches = append(matches, filepath.Join(dir, name))
+		}
+	}
+	return matches, nil
+}
+
+func cleanGlobPath(path string) string {
+	switch path {
+	case "":
+		return "."
+	case string(filepath.Separator):
+		return path
+	default:
+		return path[:len(path)-1]
+	}
+}
+
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[\`)
+}
@@ -0,0 +1,61 @@
+package fsys
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+const (
+	// lockTimeout is how long Lock waits for another holder
+	lockTimeout = 30 * time.Second
+	// staleLockAge is the age after which a lock file is taken to be left by a crashed process
+	staleLockAge = 2 * time.Minute
+)
+
+// WriteFileAtomic writes data to a temporary file next to name and renames it over name, so
+// readers never see a partly written file
+func WriteFileAtomic(fsys FS, name string, data []byte, perm fs.FileMode) error {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	tmp := name + ".tmp-" + hex.EncodeToString(suffix)
+	if err := fsys.WriteNewFile(tmp, data, perm); err != nil {
+		return err
+	}
+	if err := fsys.Rename(tmp, name); err != nil {
+		_ = fsys.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Lock creates the lock file name, waiting while another process or goroutine holds it. A lock
+// file older than staleLockAge is removed first. unlock removes the lock file.
+func Lock(fsys FS, name string) (unlock func(), err error) {
+	deadline := time.Now().Add(lockTimeout)
+	wait := 5 * time.Millisecond
+	for {
+		err := fsys.WriteNewFile(name, fmt.Appendf(nil, "%d\n", os.Getpid()), 0644)
+		if err == nil {
+			return func() { _ = fsys.Remove(name) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+		if info, err := fsys.Stat(name); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = fsys.Remove(name)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s; remove it if no anyagent command is running", name)
+		}
+		time.Sleep(wait)
+		wait = min(wait*2, 100*time.Millisecond)
+	}
+}
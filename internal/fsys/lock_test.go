@@ -0,0 +1,99 @@
+package fsys
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockAndWriteFileAtomic(t *testing.T) {
+	for name, fsys := range map[string]FS{"mem": NewMemFS(), "os": OS} {
+		t.Run(name, func(t *testing.T) {
+			root := "/config"
+			if fsys == OS {
+				root = t.TempDir()
+			}
+			_ = fsys.MkdirAll(root, 0755)
+			counter := filepath.Join(root, "counter")
+			lock := counter + ".lock"
+
+			if err := fsys.WriteNewFile(lock, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := fsys.WriteNewFile(lock, nil, 0644); !errors.Is(err, fs.ErrExist) {
+				t.Errorf("expected fs.ErrExist, got %v", err)
+			}
+			_ = fsys.Remove(lock)
+
+			// Every increment survives when they run under the lock
+			var wg sync.WaitGroup
+			for range 20 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					unlock, err := Lock(fsys, lock)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					defer unlock()
+					b, _ := fsys.ReadFile(counter)
+					n, _ := strconv.Atoi(string(b))
+					if err := WriteFileAtomic(fsys, counter, []byte(strconv.Itoa(n+1)), 0644); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+			if b, _ := fsys.ReadFile(counter); string(b) != "20" {
+				t.Errorf("counter = %q, want 20", b)
+			}
+			if entries, _ := fsys.ReadDir(root); len(entries) != 1 {
+				t.Errorf("expected only the counter file to be left, got %d entries", len(entries))
+			}
+		})
+	}
+}
+
+func TestLockBreaksStaleLock(t *testing.T) {
+	m := NewMemFS()
+	_ = m.MkdirAll("/config", 0755)
+	_ = m.WriteNewFile("/config/projects.json.lock", nil, 0644)
+	m.nodes["/config/projects.json.lock"].modTime = time.Now().Add(-2 * staleLockAge)
+
+	unlock, err := Lock(m, "/config/projects.json.lock")
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	unlock()
+	if _, err := m.Stat("/config/projects.json.lock"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unlock left the lock file: %v", err)
+	}
+}
+
+func TestMemRename(t *testing.T) {
+	m := NewMemFS()
+	_ = m.MkdirAll("/p/dir", 0755)
+	_ = m.WriteFile("/p/dir/a", []byte("a"), 0644)
+	_ = m.WriteFile("/p/b", []byte("b"), 0644)
+
+	if err := m.Rename("/p/b", "/p/dir/a"); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := m.ReadFile("/p/dir/a"); string(b) != "b" {
+		t.Errorf("rename did not replace the target: %q", b)
+	}
+	if err := m.Rename("/p/dir", "/p/moved"); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := m.ReadFile("/p/moved/a"); string(b) != "b" {
+		t.Errorf("directory contents not moved: %q", b)
+	}
+	if err := m.Rename("/p/missing", "/p/x"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,377 @@
+package fsys
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errNotDir   = errors.New("not a directory")
+	errIsDir    = errors.New("is a directory")
+	errNotEmpty = errors.New("directory not empty")
+	errLoop     = errors.New("too many levels of symbolic links")
+)
+
+// maxSymlinks bounds symbolic link resolution like the OS does
+const maxSymlinks = 40
+
+// MemFS is an in-memory FS with directories, files and symbolic links. It is safe for
+// concurrent use. Relative paths are resolved against the root.
+type MemFS struct {
+	mu    sync.RWMutex
+	nodes map[string]*memNode
+}
+
+type memNode struct {
+	mode    fs.FileMode
+	data    []byte
+	target  string // symbolic link target
+	modTime time.Time
+}
+
+// NewMemFS returns an empty MemFS with only the root directory
+func NewMemFS() *MemFS {
+	root := string(filepath.Separator)
+	return &MemFS{nodes: map[string]*memNode{root: {mode: fs.ModeDir | 0755, modTime: time.Now()}}}
+}
+
+// ReadFile reads a file, following symbolic links
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, n, err := m.resolve(name, true)
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+	if n == nil {
+		return nil, pathError("open", name, fs.ErrNotExist)
+	}
+	if n.mode.IsDir() {
+		return nil, pathError("read", p, errIsDir)
+	}
+	return append([]byte{}, n.data...), nil
+}
+
+// WriteFile creates or truncates a file; its directory must exist
+func (m *MemFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.resolve(name, true)
+	if err != nil {
+		return pathError("open", name, err)
+	}
+	if n != nil && n.mode.IsDir() {
+		return pathError("open", name, errIsDir)
+	}
+	if n == nil {
+		n = &memNode{mode: perm.Perm()}
+		m.nodes[p] = n
+	}
+	n.data = append([]byte{}, data...)
+	n.modTime = time.Now()
+	return nil
+}
+
+// WriteNewFile creates a file that must not exist yet, not even as a symbolic link
+func (m *MemFS) WriteNewFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.resolve(name, false)
+	if err != nil {
+		return pathError("open", name, err)
+	}
+	if n != nil {
+		return pathError("open", name, fs.ErrExist)
+	}
+	m.nodes[p] = &memNode{mode: perm.Perm(), data: append([]byte{}, data...), modTime: time.Now()}
+	return nil
+}
+
+// Rename moves a file, link or directory, replacing newpath unless it is a directory
+func (m *MemFS) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	from, n, err := m.resolve(oldpath, false)
+	if err == nil && n == nil {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	to, target, err := m.resolve(newpath, false)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	if target != nil && target.mode.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errIsDir}
+	}
+	if from == to {
+		return nil
+	}
+	prefix := from + string(filepath.Separator)
+	for name, child := range m.nodes {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			delete(m.nodes, name)
+			m.nodes[filepath.Join(to, rest)] = child
+		}
+	}
+	delete(m.nodes, from)
+	m.nodes[to] = n
+	return nil
+}
+
+// MkdirAll creates a directory and its missing parents
+func (m *MemFS) MkdirAll(path string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.mkdirAll(clean(path), perm); err != nil {
+		return pathError("mkdir", path, err)
+	}
+	return nil
+}
+
+func (m *MemFS) mkdirAll(p string, perm fs.FileMode) error {
+	resolved, n, err := m.resolve(p, true)
+	switch {
+	case err == nil && n == nil:
+		m.nodes[resolved] = &memNode{mode: fs.ModeDir | perm.Perm(), modTime: time.Now()}
+		return nil
+	case err == nil && !n.mode.IsDir():
+		return errNotDir
+	case err == nil:
+		return nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+	parent := filepath.Dir(p)
+	if parent == p {
+		return err
+	}
+	if err := m.mkdirAll(parent, perm); err != nil {
+		return err
+	}
+	return m.mkdirAll(p, perm)
+}
+
+// Stat describes a file, following symbolic links
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, n, err := m.resolve(name, true)
+	if err == nil && n == nil {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		return nil, pathError("stat", name, err)
+	}
+	return memInfo{name: filepath.Base(p), node: n}, nil
+}
+
+// Lstat describes a file without following a symbolic link
+func (m *MemFS) Lstat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, n, err := m.resolve(name, false)
+	if err == nil && n == nil {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		return nil, pathError("lstat", name, err)
+	}
+	return memInfo{name: filepath.Base(p), node: n}, nil
+}
+
+// ReadDir lists a directory sorted by name
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, n, err := m.resolve(name, true)
+	if err == nil && n == nil {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+	if !n.mode.IsDir() {
+		return nil, pathError("readdirent", name, errNotDir)
+	}
+	var entries []fs.DirEntry
+	for _, child := range m.children(p) {
+		entries = append(entries, fs.FileInfoToDirEntry(memInfo{name: filepath.Base(child), node: m.nodes[child]}))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// Remove removes a file, a symbolic link or an empty directory
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.resolve(name, false)
+	if err == nil && n == nil {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		return pathError("remove", name, err)
+	}
+	if n.mode.IsDir() && len(m.children(p)) > 0 {
+		return pathError("remove", name, errNotEmpty)
+	}
+	delete(m.nodes, p)
+	return nil
+}
+
+// RemoveAll removes path and everything below it; a missing path is not an error
+func (m *MemFS) RemoveAll(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.resolve(path, false)
+	if err != nil || n == nil {
+		return nil
+	}
+	prefix := p + string(filepath.Separator)
+	for name := range m.nodes {
+		if strings.HasPrefix(name, prefix) {
+			delete(m.nodes, name)
+		}
+	}
+	if filepath.Dir(p) != p {
+		delete(m.nodes, p)
+	}
+	return nil
+}
+
+// Symlink creates newname as a symbolic link to oldname
+func (m *MemFS) Symlink(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.resolve(newname, false)
+	if err != nil {
+		return &fs.PathError{Op: "symlink", Path: newname, Err: err}
+	}
+	if n != nil {
+		return &fs.PathError{Op: "symlink", Path: newname, Err: fs.ErrExist}
+	}
+	m.nodes[p] = &memNode{mode: fs.ModeSymlink | 0777, target: oldname, modTime: time.Now()}
+	return nil
+}
+
+// Readlink returns the target of a symbolic link
+func (m *MemFS) Readlink(name string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, n, err := m.resolve(name, false)
+	if err == nil && n == nil {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		return "", pathError("readlink", name, err)
+	}
+	if n.mode&fs.ModeSymlink == 0 {
+		return "", pathError("readlink", name, fs.ErrInvalid)
+	}
+	return n.target, nil
+}
+
+// resolve follows the symbolic links in name's directories (and in name itself with
+// followLast). It returns the resolved path and its node, which is nil when only the last
+// element is missing; a missing directory is fs.ErrNotExist.
+func (m *MemFS) resolve(name string, followLast bool) (string, *memNode, error) {
+	p := clean(name)
+	for hops := 0; ; {
+		resolved, n, link, err := m.walkPath(p, followLast)
+		if err != nil || link == "" {
+			return resolved, n, err
+		}
+		if hops++; hops > maxSymlinks {
+			return "", nil, errLoop
+		}
+		p = link
+	}
+}
+
+// walkPath checks p element by element. When an element is a symbolic link to follow it
+// returns the path with the link substituted.
+func (m *MemFS) walkPath(p string, followLast bool) (string, *memNode, string, error) {
+	root := string(filepath.Separator)
+	parts := strings.Split(strings.TrimPrefix(p, root), string(filepath.Separator))
+	cur := root
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		next := filepath.Join(cur, part)
+		n := m.nodes[next]
+		last := i == len(parts)-1
+		if n == nil {
+			if last {
+				return next, nil, "", nil
+			}
+			return "", nil, "", fs.ErrNotExist
+		}
+		if n.mode&fs.ModeSymlink != 0 && (!last || followLast) {
+			target := n.target
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(cur, target)
+			}
+			rest := filepath.Join(parts[i+1:]...)
+			return "", nil, clean(filepath.Join(target, rest)), nil
+		}
+		if !last && !n.mode.IsDir() {
+			return "", nil, "", errNotDir
+		}
+		cur = next
+	}
+	return cur, m.nodes[cur], "", nil
+}
+
+// children returns the paths directly below dir
+func (m *MemFS) children(dir string) []string {
+	prefix := dir
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	var out []string
+	for name := range m.nodes {
+		if rest, ok := strings.CutPrefix(name, prefix); ok && rest != "" && !strings.Contains(rest, string(filepath.Separator)) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// clean makes name absolute against the root
+func clean(name string) string {
+	if !filepath.IsAbs(name) {
+		name = string(filepath.Separator) + name
+	}
+	return filepath.Clean(name)
+}
+
+func pathError(op, path string, err error) error {
+	return &fs.PathError{Op: op, Path: path, Err: err}
+}
+
+// memInfo is the fs.FileInfo of a MemFS node
+type memInfo struct {
+	name string
+	node *memNode
+}
+
+func (i memInfo) Name() string { return i.name }
+
+func (i memInfo) Size() int64 { return int64(len(i.node.data)) }
+
+func (i memInfo) Mode() fs.FileMode { return i.node.mode }
+
+func (i memInfo) ModTime() time.Time { return i.node.modTime }
+
+func (i memInfo) IsDir() bool { return i.node.mode.IsDir() }
+
+func (i memInfo) Sys() any { return nil }
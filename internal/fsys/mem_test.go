@@ -0,0 +1,139 @@
+package fsys
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMemFSFiles(t *testing.T) {
+	m := NewMemFS()
+	if err := m.WriteFile("/p/a.txt", []byte("x"), 0644); !os.IsNotExist(err) {
+		t.Fatalf("expected a missing directory error, got %v", err)
+	}
+	if err := m.MkdirAll("/p/sub", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.WriteFile("/p/a.txt", []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := m.ReadFile("/p/a.txt"); err != nil || string(b) != "hello" {
+		t.Fatalf("ReadFile = %q, %v", b, err)
+	}
+	if _, err := m.Stat("/p/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	if err := m.MkdirAll("/p/a.txt/x", 0755); err == nil {
+		t.Error("expected MkdirAll below a file to fail")
+	}
+
+	entries, err := m.ReadDir("/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if !slices.Equal(names, []string{"a.txt", "sub"}) || !entries[1].IsDir() {
+		t.Errorf("unexpected entries: %v", names)
+	}
+
+	if err := m.Remove("/p"); err == nil {
+		t.Error("expected removing a non-empty directory to fail")
+	}
+	if err := m.RemoveAll("/p"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Stat("/p/sub"); !os.IsNotExist(err) {
+		t.Errorf("expected /p/sub to be removed, got %v", err)
+	}
+}
+
+func TestMemFSSymlinks(t *testing.T) {
+	m := NewMemFS()
+	_ = m.MkdirAll("/p/.github", 0755)
+	_ = m.WriteFile("/p/AGENTS.md", []byte("agents"), 0644)
+	if err := m.Symlink("../AGENTS.md", "/p/.github/copilot-instructions.md"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Symlink("AGENTS.md", "/p/.github/copilot-instructions.md"); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected fs.ErrExist, got %v", err)
+	}
+
+	if b, err := m.ReadFile("/p/.github/copilot-instructions.md"); err != nil || string(b) != "agents" {
+		t.Fatalf("ReadFile through link = %q, %v", b, err)
+	}
+	info, err := m.Lstat("/p/.github/copilot-instructions.md")
+	if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		t.Fatalf("expected a symlink, got %v, %v", info, err)
+	}
+	if target, err := m.Readlink("/p/.github/copilot-instructions.md"); err != nil || target != "../AGENTS.md" {
+		t.Errorf("Readlink = %q, %v", target, err)
+	}
+
+	// Directory links are followed in the middle of a path
+	_ = m.Symlink("/p/.github", "/p/gh")
+	if _, err := m.Stat("/p/gh/copilot-instructions.md"); err != nil {
+		t.Errorf("Stat through a directory link failed: %v", err)
+	}
+
+	if err := m.Remove("/p/.github/copilot-instructions.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Stat("/p/AGENTS.md"); err != nil {
+		t.Errorf("removing the link removed its target: %v", err)
+	}
+}
+
+func TestWalkAndGlob(t *testing.T) {
+	for name, fsys := range map[string]FS{"mem": NewMemFS(), "os": OS} {
+		t.Run(name, func(t *testing.T) {
+			root := "/repo"
+			if fsys == OS {
+				root = t.TempDir()
+			}
+			for _, dir := range []string{"packages/api", "packages/web", "docs"} {
+				if err := fsys.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_ = fsys.WriteFile(filepath.Join(root, "packages/api/go.mod"), nil, 0644)
+
+			matches, err := Glob(fsys, filepath.Join(root, "packages", "*"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []string{filepath.Join(root, "packages/api"), filepath.Join(root, "packages/web")}
+			if !slices.Equal(matches, want) {
+				t.Errorf("Glob = %v, want %v", matches, want)
+			}
+			if matches, _ := Glob(fsys, filepath.Join(root, "*", "*", "go.mod")); len(matches) != 1 {
+				t.Errorf("expected one go.mod, got %v", matches)
+			}
+
+			var walked []string
+			err = Walk(fsys, root, func(path string, info fs.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				rel, _ := filepath.Rel(root, path)
+				if rel == "docs" {
+					return filepath.SkipDir
+				}
+				walked = append(walked, rel)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantWalk := []string{".", "packages", "packages/api", "packages/api/go.mod", "packages/web"}
+			if !slices.Equal(walked, wantWalk) {
+				t.Errorf("Walk = %v, want %v", walked, wantWalk)
+			}
+		})
+	}
+}
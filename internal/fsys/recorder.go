@@ -0,0 +1,109 @@
+package fsys
+
+import (
+	"bytes"
+	"io/fs"
+	"sort"
+	"sync"
+)
+
+// Recorder is an FS that remembers the paths written, linked or removed through it, so a
+// caller can report which files a step really changed
+type Recorder struct {
+	FS
+	mu      sync.Mutex
+	initial map[string]pathState
+}
+
+// pathState is what a path held before the first change
+type pathState struct {
+	exists bool
+	dir    bool
+	link   string
+	data   []byte
+}
+
+// NewRecorder returns a Recorder writing through to fsys
+func NewRecorder(fsys FS) *Recorder {
+	return &Recorder{FS: fsys, initial: map[string]pathState{}}
+}
+
+// WriteFile writes through and remembers the path
+func (r *Recorder) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	r.touch(name)
+	return r.FS.WriteFile(name, data, perm)
+}
+
+// WriteNewFile creates through and remembers the path
+func (r *Recorder) WriteNewFile(name string, data []byte, perm fs.FileMode) error {
+	r.touch(name)
+	return r.FS.WriteNewFile(name, data, perm)
+}
+
+// Rename moves through and remembers both paths
+func (r *Recorder) Rename(oldpath, newpath string) error {
+	r.touch(oldpath)
+	r.touch(newpath)
+	return r.FS.Rename(oldpath, newpath)
+}
+
+// Remove removes through and remembers the path
+func (r *Recorder) Remove(name string) error {
+	r.touch(name)
+	return r.FS.Remove(name)
+}
+
+// RemoveAll removes through and remembers the path
+func (r *Recorder) RemoveAll(path string) error {
+	r.touch(path)
+	return r.FS.RemoveAll(path)
+}
+
+// Symlink links through and remembers newname
+func (r *Recorder) Symlink(oldname, newname string) error {
+	r.touch(newname)
+	return r.FS.Symlink(oldname, newname)
+}
+
+// Changed returns the paths whose content, link target or existence differs from before
+// they were first touched, sorted. Rewriting a file with the same content or recreating
+// the same link is not a change.
+func (r *Recorder) Changed() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var changed []string
+	for path, before := range r.initial {
+		if !before.equal(r.state(path)) {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func (r *Recorder) touch(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.initial[path]; !ok {
+		r.initial[path] = r.state(path)
+	}
+}
+
+func (r *Recorder) state(path string) pathState {
+	info, err := r.FS.Lstat(path)
+	if err != nil {
+		return pathState{}
+	}
+	st := pathState{exists: true, dir: info.IsDir()}
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		st.link, _ = r.FS.Readlink(path)
+	case !st.dir:
+		st.data, _ = r.FS.ReadFile(path)
+	}
+	return st
+}
+
+func (s pathState) equal(o pathState) bool {
+	return s.exists == o.exists && s.dir == o.dir && s.link == o.link && bytes.Equal(s.data, o.data)
+}
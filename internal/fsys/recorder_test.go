@@ -0,0 +1,27 @@
+package fsys
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRecorderChanged(t *testing.T) {
+	m := NewMemFS()
+	_ = m.MkdirAll("/p/.claude", 0755)
+	_ = m.WriteFile("/p/AGENTS.md", []byte("same"), 0644)
+	_ = m.WriteFile("/p/old.md", []byte("old"), 0644)
+	_ = m.Symlink("AGENTS.md", "/p/CLAUDE.md")
+
+	r := NewRecorder(m)
+	_ = r.WriteFile("/p/AGENTS.md", []byte("same"), 0644)
+	_ = r.Remove("/p/CLAUDE.md")
+	_ = r.Symlink("AGENTS.md", "/p/CLAUDE.md")
+	_ = r.WriteFile("/p/.claude/review.md", []byte("new"), 0644)
+	_ = r.Remove("/p/old.md")
+	_ = r.RemoveAll("/p/missing")
+
+	want := []string{"/p/.claude/review.md", "/p/old.md"}
+	if got := r.Changed(); !slices.Equal(got, want) {
+		t.Errorf("Changed() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,204 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Level selects which messages a Logger prints
+type Level int
+
+const (
+	// LevelQuiet prints only results, warnings and errors
+	LevelQuiet Level = iota - 1
+	// LevelNormal also prints progress messages (the default)
+	LevelNormal
+	// LevelVerbose also prints details such as resolved paths and skipped files
+	LevelVerbose
+)
+
+// Options configures a Logger
+type Options struct {
+	Level   Level
+	NoEmoji bool // strip the emoji at the start of messages
+	NoColor bool // never color output, even on a terminal
+}
+
+// Logger prints command output. Results (tables, lists, dry-run plans) and prompts go to the
+// output writer at every level; progress is hidden by LevelQuiet and details need
+// LevelVerbose. Warnings always go to the error writer.
+type Logger struct {
+	out      io.Writer
+	err      io.Writer
+	opts     Options
+	outColor bool
+	errColor bool
+}
+
+// New returns a Logger writing to out and err
+func New(out, err io.Writer, opts Options) *Logger {
+	return &Logger{
+		out:      out,
+		err:      err,
+		opts:     opts,
+		outColor: useColor(out, opts),
+		errColor: useColor(err, opts),
+	}
+}
+
+// Default returns a Logger for stdout and stderr with the default options
+func Default() *Logger {
+	return New(os.Stdout, os.Stderr, Options{})
+}
+
+// Discard returns a Logger that prints nothing
+func Discard() *Logger {
+	return New(io.Discard, io.Discard, Options{Level: LevelQuiet})
+}
+
+// WithOutput returns a copy of the Logger that prints results and progress to out
+func (l *Logger) WithOutput(out io.Writer) *Logger {
+	return New(out, l.err, l.opts)
+}
+
+// WithLevel returns a copy of the Logger that prints at level
+func (l *Logger) WithLevel(level Level) *Logger {
+	opts := l.opts
+	opts.Level = level
+	return New(l.out, l.err, opts)
+}
+
+// Level returns the Logger's level
+func (l *Logger) Level() Level {
+	return l.opts.Level
+}
+
+// Writer returns the output writer, e.g. for a tabwriter
+func (l *Logger) Writer() io.Writer {
+	return l.out
+}
+
+// ErrWriter returns the error writer warnings are printed to
+func (l *Logger) ErrWriter() io.Writer {
+	return l.err
+}
+
+// Printf prints a result line at every level
+func (l *Logger) Printf(format string, args ...any) {
+	l.write(l.out, l.outColor, fmt.Sprintf(format, args...))
+}
+
+// Println prints a result line at every level
+func (l *Logger) Println(args ...any) {
+	l.write(l.out, l.outColor, fmt.Sprintln(args...))
+}
+
+// Infof prints a progress message unless the Logger is quiet
+func (l *Logger) Infof(format string, args ...any) {
+	if l.opts.Level < LevelNormal {
+		return
+	}
+	l.write(l.out, l.outColor, fmt.Sprintf(format, args...))
+}
+
+// Debugf prints a detail message when the Logger is verbose
+func (l *Logger) Debugf(format string, args ...any) {
+	if l.opts.Level < LevelVerbose {
+		return
+	}
+	l.write(l.out, l.outColor, fmt.Sprintf(format, args...))
+}
+
+// Warnf prints a warning to the error writer at every level
+func (l *Logger) Warnf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if l.opts.NoEmoji {
+		msg = "warning: " + strings.TrimPrefix(msg, "Warning: ")
+	} else {
+		msg = "⚠️  " + msg
+	}
+	l.write(l.err, l.errColor, msg+"\n")
+}
+
+func (l *Logger) write(w io.Writer, color bool, msg string) {
+	if l.opts.NoEmoji {
+		msg = stripEmoji(msg)
+	}
+	if color {
+		msg = colorize(msg)
+	}
+	_, _ = io.WriteString(w, msg)
+}
+
+// ANSI colors for the status symbols messages start with
+const (
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiReset  = "\x1b[0m"
+)
+
+// colorize colors each line by its leading status symbol (✅, ❌, ⚠️)
+func colorize(msg string) string {
+	lines := strings.SplitAfter(msg, "\n")
+	for i, line := range lines {
+		body := strings.TrimRight(line, "\n")
+		code := ""
+		switch trimmed := strings.TrimLeft(body, " "); {
+		case strings.HasPrefix(trimmed, "✅"):
+			code = ansiGreen
+		case strings.HasPrefix(trimmed, "❌"):
+			code = ansiRed
+		case strings.HasPrefix(trimmed, "⚠️"), strings.HasPrefix(trimmed, "warning:"):
+			code = ansiYellow
+		}
+		if code != "" {
+			lines[i] = code + body + ansiReset + line[len(body):]
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// stripEmoji removes the emoji (and the spaces after it) at the start of each line, keeping
+// the indentation. Bullets become dashes.
+func stripEmoji(msg string) string {
+	lines := strings.SplitAfter(msg, "\n")
+	for i, line := range lines {
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		rest := line[indent:]
+		if strings.HasPrefix(rest, "•") {
+			lines[i] = line[:indent] + "-" + rest[len("•"):]
+			continue
+		}
+		n := 0
+		for n < len(rest) {
+			r, size := utf8.DecodeRuneInString(rest[n:])
+			if r < 0x2000 || (unicode.IsLetter(r) && r != 'ℹ') {
+				break
+			}
+			n += size
+		}
+		if n == 0 {
+			continue
+		}
+		lines[i] = line[:indent] + strings.TrimLeft(rest[n:], " ")
+	}
+	return strings.Join(lines, "")
+}
+
+// useColor reports whether w is a terminal and colors are not disabled (--no-color, NO_COLOR)
+func useColor(w io.Writer, opts Options) bool {
+	if opts.NoColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLoggerLevels(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   Level
+		wantOut string
+	}{
+		{"quiet", LevelQuiet, "result\n"},
+		{"normal", LevelNormal, "result\n📄 progress\n"},
+		{"verbose", LevelVerbose, "result\n📄 progress\ndetail\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			l := New(&out, &errOut, Options{Level: tt.level})
+			l.Printf("result\n")
+			l.Infof("📄 progress\n")
+			l.Debugf("detail\n")
+			l.Warnf("Warning: careful")
+			if out.String() != tt.wantOut {
+				t.Errorf("out = %q, want %q", out.String(), tt.wantOut)
+			}
+			if errOut.String() != "⚠️  Warning: careful\n" {
+				t.Errorf("warnings should go to the error writer, got %q", errOut.String())
+			}
+		})
+	}
+}
+
+func TestLoggerNoEmoji(t *testing.T) {
+	var out, errOut bytes.Buffer
+	l := New(&out, &errOut, Options{NoEmoji: true})
+	l.Infof("🗑️  Removing file: a.md\n")
+	l.Printf("Available rules:\n  ✅ go (installed)\n  • docker\n")
+	l.Infof("ℹ️  Codex selected\n")
+	l.Printf("[DRY RUN] Would write AGENTS.md\n")
+	l.Warnf("Warning: careful")
+
+	want := "Removing file: a.md\nAvailable rules:\n  go (installed)\n  - docker\nCodex selected\n[DRY RUN] Would write AGENTS.md\n"
+	if out.String() != want {
+		t.Errorf("out = %q, want %q", out.String(), want)
+	}
+	if errOut.String() != "warning: careful\n" {
+		t.Errorf("err = %q", errOut.String())
+	}
+}
+
+func TestColorize(t *testing.T) {
+	got := colorize("  ✅ done\nplain\n❌ failed")
+	want := "\x1b[32m  ✅ done\x1b[0m\nplain\n\x1b[31m❌ failed\x1b[0m"
+	if got != want {
+		t.Errorf("colorize = %q, want %q", got, want)
+	}
+	// Buffers are never terminals
+	if useColor(&bytes.Buffer{}, Options{}) {
+		t.Error("colors should only be used on terminals")
+	}
+}
@@ -0,0 +1,466 @@
+// Package anyagent lets Go programs manage anyagent projects the way the anyagent CLI does:
+// sync AGENTS.md and the agent files, add rules, commands and MCP servers, switch agents and
+// inspect a project's status.
+//
+// Functions never print and never read stdin unless Options asks for it; what a command
+// changed (or would change with DryRun) is returned as structured results. Failures are
+// *Error values wrapping one of the Err* sentinels where applicable:
+//
+//	p, err := anyagent.Load(ctx, dir, anyagent.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	res, err := p.Sync(ctx, anyagent.SyncOptions{Agents: []string{"claude"}})
+//	if errors.Is(err, anyagent.ErrInputRequired) {
+//		// a new project needs an agent
+//	}
+//
+// Options.FS and the directory options make a Project hermetic: with NewMemFS nothing touches
+// the disk or the user's home.
+//
+// Calls may run concurrently from several goroutines and processes: the user-level project
+// registry and global state are updated under a lock file. Calls that write the same project
+// should not overlap.
+//
+// Every call returns ctx.Err() if ctx is done before it starts. Once started, only Watch and
+// CheckMCP (which stops the MCP server it launched) return early when ctx is done; the other
+// calls only touch files and run to completion.
+package anyagent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/shibukawa/anyagent/internal/commands"
+	"github.com/shibukawa/anyagent/internal/config"
+	"github.com/shibukawa/anyagent/internal/fsys"
+	"github.com/shibukawa/anyagent/internal/logging"
+)
+
+// Options configures how a Project runs commands. The zero value prints nothing and never
+// prompts.
+type Options struct {
+	// Stdout receives the results and progress messages the CLI prints; nil discards them
+	Stdout io.Writer
+	// Stderr receives warnings; nil discards them
+	Stderr io.Writer
+	// Quiet, Verbose, NoEmoji and NoColor shape the messages like the CLI flags of the same name
+	Quiet, Verbose, NoEmoji, NoColor bool
+	// Interactive lets commands prompt on Stdin for missing input (agent, project name,
+	// template parameters) instead of failing with ErrInputRequired
+	Interactive bool
+	// Stdin answers the prompts of interactive commands; nil is the process stdin, which is
+	// only prompted when it is a terminal
+	Stdin io.Reader
+	// Environ holds the environment variables (KEY=value) that ${env:NAME} placeholders are
+	// resolved from and that checked MCP servers are launched with; nil is the process environment
+	Environ []string
+	// FS is the file system projects, templates and agent configs are read from and written
+	// to; nil is the real file system
+	FS FS
+	// HomeDir and ConfigDir replace the user's home directory (user-global agent files such as
+	// ~/.codex) and config directory (user templates, the project registry); empty uses the user's
+	HomeDir, ConfigDir string
+}
+
+// FS is the file access a Project needs; see NewMemFS
+type FS = fsys.FS
+
+// MemFS is an in-memory FS with directories, files and symbolic links
+type MemFS = fsys.MemFS
+
+// NewMemFS returns an empty in-memory FS. Use absolute paths with it.
+func NewMemFS() *MemFS {
+	return fsys.NewMemFS()
+}
+
+// Project is an anyagent project directory
+type Project struct {
+	Dir  string
+	opts Options
+	env  *fsys.Env
+}
+
+// Load opens the project in dir (the current directory when empty). The project does not
+// need to be initialized; Sync initializes it. With Options.FS, dir must be an absolute path
+// in that FS: the process working directory means nothing there.
+func Load(ctx context.Context, dir string, opts Options) (*Project, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &Error{Op: "load", Dir: dir, Err: err}
+	}
+	if opts.FS != nil && !filepath.IsAbs(dir) {
+		return nil, &Error{Op: "load", Dir: dir, Err: fmt.Errorf("%w: Options.FS needs an absolute project directory", ErrProjectNotFound)}
+	}
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, &Error{Op: "load", Err: fmt.Errorf("failed to get current directory: %w", err)}
+		}
+		dir = wd
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, &Error{Op: "load", Dir: dir, Err: err}
+	}
+	env := newEnv(opts, abs)
+	if st, err := env.FS.Stat(abs); err != nil || !st.IsDir() {
+		return nil, &Error{Op: "load", Dir: abs, Err: fmt.Errorf("%w: %s", ErrProjectNotFound, abs)}
+	}
+	return &Project{Dir: abs, opts: opts, env: env}, nil
+}
+
+// Initialized reports whether the project has been synced (it has AGENTS.md)
+func (p *Project) Initialized() bool {
+	_, err := p.env.FS.Stat(filepath.Join(p.Dir, "AGENTS.md"))
+	return err == nil
+}
+
+// Sync initializes the project or regenerates AGENTS.md and the agent files from the
+// templates and .anyagent/config.yaml
+func (p *Project) Sync(ctx context.Context, opts SyncOptions) (*SyncResult, error) {
+	report, err := p.run(ctx, "sync", func(s *commands.Session) error {
+		if p.isNew() {
+			name := opts.ProjectName
+			if name == "" && !p.opts.Interactive {
+				name = filepath.Base(p.Dir)
+			}
+			return s.RunFirstSyncWithOptions(commands.FirstSyncOptions{
+				ProjectDir:         p.Dir,
+				Agents:             opts.Agents,
+				ProjectName:        name,
+				ProjectDescription: opts.ProjectDescription,
+				Parameters:         opts.Parameters,
+				DryRun:             opts.DryRun,
+			})
+		}
+		if len(opts.Parameters) > 0 && !opts.DryRun {
+			if err := p.setParameters(opts.Parameters); err != nil {
+				return err
+			}
+		}
+		return s.RunSyncWithOptions(p.Dir, opts.Agents, opts.DryRun, opts.Force)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := &SyncResult{Result: newResult(report), Dir: p.Dir, DryRun: opts.DryRun, Agents: []string{}, RemovedAgents: []string{}, Installed: newInstalled(commands.InstalledItems{})}
+	if data, ok := report.Data.(*commands.SyncResult); ok {
+		result.FirstSync = data.FirstSync
+		result.Agents = data.Agents
+		result.RemovedAgents = data.RemovedAgents
+		result.Installed = newInstalled(data.Installed)
+	}
+	return result, nil
+}
+
+// Plan returns what Sync would do without changing any file
+func (p *Project) Plan(ctx context.Context, opts SyncOptions) (*SyncResult, error) {
+	opts.DryRun = true
+	return p.Sync(ctx, opts)
+}
+
+// AddRule installs a language rule (go, typescript, docker, python, react or a custom rule)
+func (p *Project) AddRule(ctx context.Context, rule string, opts AddOptions) (*Result, error) {
+	return p.change(ctx, "add rule", func(s *commands.Session) error {
+		return s.RunAddRule(rule, p.Dir, opts.DryRun)
+	})
+}
+
+// AddCommand installs a command template for the enabled agent
+func (p *Project) AddCommand(ctx context.Context, command string, opts AddCommandOptions) (*Result, error) {
+	return p.change(ctx, "add command", func(s *commands.Session) error {
+		return s.RunAddCommand(command, p.Dir, opts.DryRun, opts.Global)
+	})
+}
+
+// AddMCP records an MCP server in .anyagent/config.yaml and writes it to the agents' MCP configs
+func (p *Project) AddMCP(ctx context.Context, server MCPServer, opts AddMCPOptions) (*Result, error) {
+	return p.change(ctx, "add mcp", func(s *commands.Session) error {
+		return s.RunAddMCP(commands.AddMCPParams{
+			Name:           server.Name,
+			Preset:         server.Preset,
+			Cmd:            server.Command,
+			Args:           server.Args,
+			Env:            keyValues(server.Env),
+			URL:            server.URL,
+			Headers:        keyValues(server.Headers),
+			Transport:      server.Transport,
+			StartupTimeout: server.StartupTimeout,
+			ToolTimeout:    server.ToolTimeout,
+			Agents:         server.Agents,
+			ExcludeAgents:  server.ExcludeAgents,
+			Disabled:       server.Disabled,
+			ProjectDir:     p.Dir,
+			DryRun:         opts.DryRun,
+			Global:         opts.Global,
+			Force:          opts.Force,
+		})
+	})
+}
+
+// Switch replaces the enabled agent, removing the previous agent's files
+func (p *Project) Switch(ctx context.Context, agent string, opts SwitchOptions) (*Result, error) {
+	return p.change(ctx, "switch", func(s *commands.Session) error {
+		return s.RunSwitch(p.Dir, agent, opts.DryRun)
+	})
+}
+
+// Status returns the project's agents, installed items, workspaces and last sync
+func (p *Project) Status(ctx context.Context) (*Status, error) {
+	report, err := p.run(ctx, "status", func(s *commands.Session) error {
+		return s.RunStatus(p.Dir)
+	})
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{Result: newResult(report), Dir: p.Dir, Agents: []string{}, Workspaces: []string{}, Installed: newInstalled(commands.InstalledItems{})}
+	if data, ok := report.Data.(*commands.StatusReport); ok {
+		status.Initialized = data.Initialized
+		status.Name = data.ProjectName
+		status.Agents = data.Agents
+		status.Installed = newInstalled(data.Installed)
+		status.Workspaces = data.Workspaces
+		status.Registered = data.Registered
+		status.LastSync = data.LastSync
+	}
+	return status, nil
+}
+
+// AddPersona installs a persona (Claude subagent, Copilot chat mode) for the enabled agent
+func (p *Project) AddPersona(ctx context.Context, persona string, opts AddOptions) (*Result, error) {
+	return p.change(ctx, "add persona", func(s *commands.Session) error {
+		return s.RunAddPersona(persona, p.Dir, opts.DryRun)
+	})
+}
+
+// AddHook installs a lifecycle hook in the enabled agent's settings
+func (p *Project) AddHook(ctx context.Context, hook string, opts AddOptions) (*Result, error) {
+	return p.change(ctx, "add hook", func(s *commands.Session) error {
+		return s.RunAddHook(hook, p.Dir, opts.DryRun)
+	})
+}
+
+// RemoveRule removes an installed rule
+func (p *Project) RemoveRule(ctx context.Context, rule string, opts RemoveOptions) (*Result, error) {
+	return p.change(ctx, "remove rule", func(s *commands.Session) error {
+		return s.RunRemoveRule(rule, p.Dir, opts.DryRun)
+	})
+}
+
+// RemoveCommand removes an installed command
+func (p *Project) RemoveCommand(ctx context.Context, command string, opts RemoveOptions) (*Result, error) {
+	return p.change(ctx, "remove command", func(s *commands.Session) error {
+		return s.RunRemoveCommand(command, p.Dir, opts.DryRun)
+	})
+}
+
+// RemovePersona removes an installed persona
+func (p *Project) RemovePersona(ctx context.Context, persona string, opts RemoveOptions) (*Result, error) {
+	return p.change(ctx, "remove persona", func(s *commands.Session) error {
+		return s.RunRemovePersona(persona, p.Dir, opts.DryRun)
+	})
+}
+
+// RemoveHook removes an installed hook
+func (p *Project) RemoveHook(ctx context.Context, hook string, opts RemoveOptions) (*Result, error) {
+	return p.change(ctx, "remove hook", func(s *commands.Session) error {
+		return s.RunRemoveHook(hook, p.Dir, opts.DryRun)
+	})
+}
+
+// RemoveMCP removes an MCP server from .anyagent/config.yaml and the agents' MCP configs
+func (p *Project) RemoveMCP(ctx context.Context, name string, opts RemoveOptions) (*Result, error) {
+	return p.change(ctx, "remove mcp", func(s *commands.Session) error {
+		return s.RunRemoveMCP(name, p.Dir, opts.DryRun)
+	})
+}
+
+// ListRules reports which rules are installed; Data is a *RuleList
+func (p *Project) ListRules(ctx context.Context) (*Result, error) {
+	return p.change(ctx, "list rule", func(s *commands.Session) error {
+		return s.RunListRules(p.Dir)
+	})
+}
+
+// ListCommands reports which commands are installed; Data is a *CommandList
+func (p *Project) ListCommands(ctx context.Context) (*Result, error) {
+	return p.change(ctx, "list command", func(s *commands.Session) error {
+		return s.RunListCommands(p.Dir)
+	})
+}
+
+// ListPersonas reports which personas are installed; Data is a *PersonaList
+func (p *Project) ListPersonas(ctx context.Context) (*Result, error) {
+	return p.change(ctx, "list persona", func(s *commands.Session) error {
+		return s.RunListPersonas(p.Dir)
+	})
+}
+
+// ListHooks reports which hooks are installed; Data is a *HookList
+func (p *Project) ListHooks(ctx context.Context) (*Result, error) {
+	return p.change(ctx, "list hook", func(s *commands.Session) error {
+		return s.RunListHooks(p.Dir)
+	})
+}
+
+// ListMCP reports the recorded MCP servers and where they are installed; Data is a *MCPList
+func (p *Project) ListMCP(ctx context.Context) (*Result, error) {
+	return p.change(ctx, "list mcp", func(s *commands.Session) error {
+		return s.RunListMCP(p.Dir)
+	})
+}
+
+// AvailableCommands reports the command templates of every template layer; Data is a []Template
+func (p *Project) AvailableCommands(ctx context.Context) (*Result, error) {
+	return p.change(ctx, "add command", func(s *commands.Session) error {
+		return s.ListAvailableCommands(p.Dir)
+	})
+}
+
+// AvailablePersonas reports the persona templates of every template layer; Data is a []Template
+func (p *Project) AvailablePersonas(ctx context.Context) (*Result, error) {
+	return p.change(ctx, "add persona", func(s *commands.Session) error {
+		return s.ListAvailablePersonas(p.Dir)
+	})
+}
+
+// AvailableHooks reports the hook templates of every template layer; Data is a []Template
+func (p *Project) AvailableHooks(ctx context.Context) (*Result, error) {
+	return p.change(ctx, "add hook", func(s *commands.Session) error {
+		return s.ListAvailableHooks(p.Dir)
+	})
+}
+
+// MCPPresets reports the MCP server catalog AddMCP accepts as MCPServer.Preset; Data is a
+// []MCPPreset
+func (p *Project) MCPPresets(ctx context.Context) (*Result, error) {
+	return p.change(ctx, "add mcp", func(s *commands.Session) error {
+		return s.ListMCPPresets(p.Dir)
+	})
+}
+
+// CheckMCP launches the stdio MCP servers and verifies their initialize handshake and tools/list
+func (p *Project) CheckMCP(ctx context.Context, opts CheckMCPOptions) (*Result, error) {
+	return p.change(ctx, "mcp check", func(s *commands.Session) error {
+		return s.RunMCPCheck(ctx, p.Dir, opts.Names, opts.Timeout)
+	})
+}
+
+// ImportMCP records the MCP servers of existing agent configs in .anyagent/config.yaml
+func (p *Project) ImportMCP(ctx context.Context, opts ImportMCPOptions) (*Result, error) {
+	return p.change(ctx, "import mcp", func(s *commands.Session) error {
+		return s.RunImportMCP(p.Dir, opts.From, opts.DryRun, opts.Force)
+	})
+}
+
+// Adopt creates .anyagent from the project's existing agent files (CLAUDE.md, Copilot
+// instructions, .cursorrules and commands)
+func (p *Project) Adopt(ctx context.Context, opts AdoptOptions) (*Result, error) {
+	return p.change(ctx, "adopt", func(s *commands.Session) error {
+		return s.RunAdopt(p.Dir, opts.Agent, opts.DryRun, opts.Force)
+	})
+}
+
+// Watch syncs the project, then regenerates the affected files whenever .anyagent/, the user
+// templates or config.yaml change. It returns when ctx is done.
+func (p *Project) Watch(ctx context.Context, opts WatchOptions) error {
+	_, err := p.run(ctx, "watch", func(s *commands.Session) error {
+		return s.RunSyncWatch(ctx, p.Dir, opts.Agents, opts.Force, commands.WatchOptions{})
+	})
+	return err
+}
+
+// change runs a command that only reports operations and warnings
+func (p *Project) change(ctx context.Context, op string, fn func(s *commands.Session) error) (*Result, error) {
+	report, err := p.run(ctx, op, fn)
+	if err != nil {
+		return nil, err
+	}
+	result := newResult(report)
+	return &result, nil
+}
+
+// run runs a command in the project
+func (p *Project) run(ctx context.Context, op string, fn func(s *commands.Session) error) (commands.Report, error) {
+	return runSession(ctx, op, p.Dir, p.opts, p.env, fn)
+}
+
+// runSession runs a command silently (or with the configured writers) and wraps its error
+// with what it reported before failing
+func runSession(ctx context.Context, op, dir string, opts Options, env *fsys.Env, fn func(s *commands.Session) error) (commands.Report, error) {
+	if err := ctx.Err(); err != nil {
+		return commands.Report{}, &Error{Op: op, Dir: dir, Err: err, Result: newResult(commands.Report{})}
+	}
+	report, err := commands.RunSession(commands.Session{Logger: newLogger(opts), Interactive: opts.Interactive, Env: env}, fn)
+	if err != nil {
+		return report, &Error{Op: op, Dir: dir, Err: err, Result: newResult(report)}
+	}
+	return report, nil
+}
+
+// newEnv returns the environment opts describe, with dir as the project directory
+func newEnv(opts Options, dir string) *fsys.Env {
+	env := &fsys.Env{FS: opts.FS, ProjectDir: dir, HomeDir: opts.HomeDir, ConfigDir: opts.ConfigDir, Stdin: opts.Stdin, Vars: opts.Environ}
+	if env.FS == nil {
+		env.FS = fsys.OS
+	}
+	return env
+}
+
+// newLogger builds the logger commands print with
+func newLogger(opts Options) *logging.Logger {
+	out, errOut := opts.Stdout, opts.Stderr
+	if out == nil {
+		out = io.Discard
+	}
+	if errOut == nil {
+		errOut = io.Discard
+	}
+	logOpts := logging.Options{NoEmoji: opts.NoEmoji, NoColor: opts.NoColor}
+	switch {
+	case opts.Quiet:
+		logOpts.Level = logging.LevelQuiet
+	case opts.Verbose:
+		logOpts.Level = logging.LevelVerbose
+	}
+	return logging.New(out, errOut, logOpts)
+}
+
+// isNew reports whether Sync initializes the project (as 'anyagent sync' decides)
+func (p *Project) isNew() bool {
+	if p.Initialized() {
+		return false
+	}
+	pc, err := config.LoadProjectConfig(p.env, config.GetProjectConfigPath(p.Dir))
+	return err == nil && len(pc.Parameters) == 0 && pc.ProjectName == ""
+}
+
+// setParameters stores template parameters in .anyagent/config.yaml
+func (p *Project) setParameters(params map[string]string) error {
+	configPath := config.GetProjectConfigPath(p.Dir)
+	pc, err := config.LoadProjectConfig(p.env, configPath)
+	if err != nil {
+		return err
+	}
+	if pc.Parameters == nil {
+		pc.Parameters = map[string]string{}
+	}
+	for k, v := range params {
+		pc.Parameters[k] = v
+	}
+	return pc.Save(p.env, configPath)
+}
+
+// keyValues turns a map into sorted KEY=VALUE strings
+func keyValues(m map[string]string) []string {
+	var out []string
+	for k, v := range m {
+		out = append(out, k+"="+v)
+	}
+	sort.Strings(out)
+	return out
+}
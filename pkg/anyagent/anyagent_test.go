@@ -0,0 +1,381 @@
+package anyagent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// setupTestHome isolates the user config directory and the project registry
+func setupTestHome(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+}
+
+// newTestProject initializes a claude project without prompts
+func newTestProject(t *testing.T) *Project {
+	t.Helper()
+	ctx := context.Background()
+	p, err := Load(ctx, t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	res, err := p.Sync(ctx, SyncOptions{
+		Agents:      []string{"claude"},
+		ProjectName: "demo",
+		Parameters:  map[string]string{"PRIMARY_LANGUAGE": "Go", "TEAM_NAME": "core"},
+	})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if !res.FirstSync || !slices.Equal(res.Agents, []string{"claude"}) {
+		t.Fatalf("unexpected first sync result: %+v", res)
+	}
+	return p
+}
+
+func TestLoad(t *testing.T) {
+	setupTestHome(t)
+
+	_, err := Load(context.Background(), filepath.Join(t.TempDir(), "missing"), Options{})
+	if !errors.Is(err, ErrProjectNotFound) {
+		t.Fatalf("expected ErrProjectNotFound, got %v", err)
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Op != "load" {
+		t.Fatalf("expected *Error for load, got %#v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Load(ctx, t.TempDir(), Options{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSyncAndStatus(t *testing.T) {
+	setupTestHome(t)
+	p := newTestProject(t)
+	ctx := context.Background()
+
+	if !p.Initialized() {
+		t.Fatal("expected project to be initialized")
+	}
+	data, err := os.ReadFile(filepath.Join(p.Dir, "AGENTS.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "demo") {
+		t.Errorf("AGENTS.md does not contain the project name:\n%s", data)
+	}
+
+	if _, err := p.AddRule(ctx, "go", AddOptions{}); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+	status, err := p.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if !status.Initialized || status.Name != "demo" || !status.Registered {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if !slices.Equal(status.Installed.Rules, []string{"go"}) {
+		t.Errorf("expected the go rule to be installed, got %v", status.Installed.Rules)
+	}
+}
+
+func TestPlanDoesNotWrite(t *testing.T) {
+	setupTestHome(t)
+	p := newTestProject(t)
+
+	res, err := p.Plan(context.Background(), SyncOptions{Agents: []string{"gemini"}})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(res.Operations) == 0 {
+		t.Fatal("expected planned operations")
+	}
+	if !slices.Equal(res.RemovedAgents, []string{"claude"}) {
+		t.Errorf("expected claude to be removed, got %v", res.RemovedAgents)
+	}
+	if _, err := os.Lstat(filepath.Join(p.Dir, "CLAUDE.md")); err != nil {
+		t.Errorf("Plan removed CLAUDE.md: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(p.Dir, "GEMINI.md")); !os.IsNotExist(err) {
+		t.Errorf("Plan created GEMINI.md: %v", err)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	setupTestHome(t)
+	ctx := context.Background()
+
+	fresh, err := Load(ctx, t.TempDir(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fresh.AddRule(ctx, "go", AddOptions{}); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+	if _, err := fresh.Sync(ctx, SyncOptions{ProjectName: "demo"}); !errors.Is(err, ErrInputRequired) {
+		t.Errorf("expected ErrInputRequired without an agent, got %v", err)
+	}
+
+	p := newTestProject(t)
+	if _, err := p.AddRule(ctx, "cobol", AddOptions{}); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("expected ErrUnknownTemplate, got %v", err)
+	}
+	if _, err := p.Switch(ctx, "vim", SwitchOptions{}); !errors.Is(err, ErrUnsupportedAgent) {
+		t.Errorf("expected ErrUnsupportedAgent, got %v", err)
+	}
+}
+
+func TestOptionsWriters(t *testing.T) {
+	setupTestHome(t)
+	p := newTestProject(t)
+	var out bytes.Buffer
+	p.opts = Options{Stdout: &out, NoEmoji: true}
+
+	if _, err := p.Plan(context.Background(), SyncOptions{Agents: []string{"gemini"}}); err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "[DRY RUN]") {
+		t.Errorf("expected dry-run messages on Stdout, got %q", out.String())
+	}
+	if strings.Contains(out.String(), "✅") {
+		t.Errorf("expected no emoji with NoEmoji, got %q", out.String())
+	}
+}
+
+func TestMemFS(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemFS()
+	opts := Options{FS: mem, HomeDir: "/home/dev", ConfigDir: "/home/dev/.config"}
+
+	if _, err := Load(ctx, "/work/app", opts); !errors.Is(err, ErrProjectNotFound) {
+		t.Fatalf("expected ErrProjectNotFound, got %v", err)
+	}
+	// There is no working directory in a memory FS
+	for _, dir := range []string{"", "work/app"} {
+		if _, err := Load(ctx, dir, opts); !errors.Is(err, ErrProjectNotFound) {
+			t.Errorf("Load(%q): expected ErrProjectNotFound, got %v", dir, err)
+		}
+	}
+	if err := mem.MkdirAll("/work/app", 0755); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Load(ctx, "/work/app", opts)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, err := p.AddCommand(ctx, "editorconfig", AddCommandOptions{}); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+	status, err := p.Status(ctx)
+	if err != nil || status.Initialized {
+		t.Errorf("unexpected status: %+v, %v", status, err)
+	}
+}
+
+func TestMemFSSync(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemFS()
+	_ = mem.MkdirAll("/work/api", 0755)
+	_ = mem.MkdirAll("/work/web", 0755)
+	opts := Options{FS: mem, HomeDir: "/home/dev", ConfigDir: "/home/dev/.config"}
+
+	for _, name := range []string{"api", "web"} {
+		p, err := Load(ctx, "/work/"+name, opts)
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if _, err := p.Sync(ctx, SyncOptions{Agents: []string{"claude"}, ProjectName: name}); err != nil {
+			t.Fatalf("Sync %s failed: %v", name, err)
+		}
+		if _, err := p.AddRule(ctx, "go", AddOptions{}); err != nil {
+			t.Fatalf("AddRule %s failed: %v", name, err)
+		}
+		b, err := mem.ReadFile("/work/" + name + "/CLAUDE.md")
+		if err != nil {
+			t.Fatalf("CLAUDE.md was not written: %v", err)
+		}
+		if !strings.Contains(string(b), name) {
+			t.Errorf("AGENTS.md of %s does not name the project:\n%s", name, b)
+		}
+	}
+	if _, err := mem.Stat("/AGENTS.md"); err == nil {
+		t.Error("AGENTS.md was written outside the project")
+	}
+	if _, err := os.Stat("/work/api"); err == nil {
+		t.Error("the in-memory project was written to disk")
+	}
+}
+
+func TestConcurrentSync(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemFS()
+	opts := Options{FS: mem, HomeDir: "/home/dev", ConfigDir: "/home/dev/.config"}
+	const projects = 30
+	errs := make(chan error, projects)
+	for i := range projects {
+		go func() {
+			dir := fmt.Sprintf("/work/project-%02d", i)
+			_ = mem.MkdirAll(dir, 0755)
+			p, err := Load(ctx, dir, opts)
+			if err == nil {
+				_, err = p.Sync(ctx, SyncOptions{Agents: []string{"codex"}})
+			}
+			errs <- err
+		}()
+	}
+	for range projects {
+		if err := <-errs; err != nil {
+			t.Errorf("Sync failed: %v", err)
+		}
+	}
+	res, err := ListProjects(ctx, opts)
+	if err != nil {
+		t.Fatalf("ListProjects failed: %v", err)
+	}
+	if registered, _ := res.Data.([]RegisteredProject); len(registered) != projects {
+		t.Errorf("expected %d registered projects, got %+v", projects, res.Data)
+	}
+}
+
+func TestSyncParametersWithoutProjectConfig(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemFS()
+	_ = mem.MkdirAll("/work/app", 0755)
+	_ = mem.WriteFile("/work/app/AGENTS.md", []byte("# app\n"), 0644)
+	p, err := Load(ctx, "/work/app", Options{FS: mem, HomeDir: "/home/dev", ConfigDir: "/home/dev/.config"})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, err := p.Sync(ctx, SyncOptions{Agents: []string{"claude"}, Parameters: map[string]string{"owner": "platform"}}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	b, err := mem.ReadFile("/work/app/.anyagent/config.yaml")
+	if err != nil {
+		t.Fatalf("config.yaml was not written: %v", err)
+	}
+	if !strings.Contains(string(b), "owner: platform") {
+		t.Errorf("parameter not stored:\n%s", b)
+	}
+}
+
+func TestListAndRemove(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemFS()
+	_ = mem.MkdirAll("/work/app", 0755)
+	opts := Options{FS: mem, HomeDir: "/home/dev", ConfigDir: "/home/dev/.config"}
+	p, err := Load(ctx, "/work/app", opts)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, err := p.Sync(ctx, SyncOptions{Agents: []string{"claude"}}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, err := p.AddRule(ctx, "go", AddOptions{}); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+
+	res, err := p.ListRules(ctx)
+	if err != nil {
+		t.Fatalf("ListRules failed: %v", err)
+	}
+	list, ok := res.Data.(*RuleList)
+	if !ok || !slices.ContainsFunc(list.Rules, func(r RuleStatus) bool { return r.Name == "go" && r.Installed }) {
+		t.Fatalf("expected the go rule to be listed as installed: %+v", res.Data)
+	}
+
+	if _, err := p.RemoveRule(ctx, "go", RemoveOptions{}); err != nil {
+		t.Fatalf("RemoveRule failed: %v", err)
+	}
+	status, err := p.Status(ctx)
+	if err != nil || len(status.Installed.Rules) != 0 {
+		t.Errorf("expected no rules after RemoveRule: %+v, %v", status, err)
+	}
+
+	res, err = SyncAll(ctx, opts, SyncAllOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+	rows, ok := res.Data.([]SyncAllResult)
+	if !ok || len(rows) != 1 || rows[0].Dir != "/work/app" || rows[0].Status != "dry-run" {
+		t.Errorf("unexpected SyncAll result: %+v", res.Data)
+	}
+}
+
+func TestErrorKeepsResult(t *testing.T) {
+	setupTestHome(t)
+	p := newTestProject(t)
+
+	_, err := p.RemoveMCP(context.Background(), "missing", RemoveOptions{})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Op != "remove mcp" {
+		t.Fatalf("expected *Error for remove mcp, got %#v", err)
+	}
+	if apiErr.Result.Operations == nil || apiErr.Result.Warnings == nil {
+		t.Errorf("expected the failed command's (empty) report, got %+v", apiErr.Result)
+	}
+}
+
+func TestListCallsReturnData(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemFS()
+	_ = mem.MkdirAll("/work/app", 0755)
+	opts := Options{FS: mem, HomeDir: "/home/dev", ConfigDir: "/home/dev/.config"}
+	p, err := Load(ctx, "/work/app", opts)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, err := p.Sync(ctx, SyncOptions{Agents: []string{"claude"}}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, err := p.AddPersona(ctx, "reviewer", AddOptions{}); err != nil {
+		t.Fatalf("AddPersona failed: %v", err)
+	}
+
+	res, err := p.ListPersonas(ctx)
+	if err != nil {
+		t.Fatalf("ListPersonas failed: %v", err)
+	}
+	personas, ok := res.Data.(*PersonaList)
+	if !ok || !slices.ContainsFunc(personas.Personas, func(s TemplateStatus) bool { return s.Name == "reviewer" && s.Installed }) {
+		t.Errorf("expected reviewer to be listed as installed: %+v", res.Data)
+	}
+	if res, err = p.ListHooks(ctx); err != nil {
+		t.Fatalf("ListHooks failed: %v", err)
+	}
+	if hooks, ok := res.Data.(*HookList); !ok || !hooks.Initialized || len(hooks.Hooks) == 0 {
+		t.Errorf("unexpected hook list: %+v", res.Data)
+	}
+	if res, err = p.AvailableCommands(ctx); err != nil {
+		t.Fatalf("AvailableCommands failed: %v", err)
+	}
+	if templates, ok := res.Data.([]Template); !ok || len(templates) == 0 {
+		t.Errorf("unexpected available commands: %+v", res.Data)
+	}
+	if res, err = p.MCPPresets(ctx); err != nil {
+		t.Fatalf("MCPPresets failed: %v", err)
+	}
+	presets, _ := res.Data.([]MCPPreset)
+	if !slices.ContainsFunc(presets, func(p MCPPreset) bool { return p.ID == "github" && len(p.Env) > 0 }) {
+		t.Errorf("expected the github preset: %+v", res.Data)
+	}
+
+	res, err = EditTemplates(ctx, opts, EditTemplatesOptions{})
+	if err != nil {
+		t.Fatalf("EditTemplates failed: %v", err)
+	}
+	if templates, ok := res.Data.(*Templates); !ok || templates.Dir != "/home/dev/.config/anyagent" || len(res.Operations) != 0 {
+		t.Errorf("unexpected EditTemplates result: %+v", res)
+	}
+}
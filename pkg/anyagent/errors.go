@@ -0,0 +1,42 @@
+package anyagent
+
+import (
+	"fmt"
+
+	"github.com/shibukawa/anyagent/internal/commands"
+)
+
+// Errors an *Error wraps; test for them with errors.Is
+var (
+	// ErrProjectNotFound means the project directory does not exist
+	ErrProjectNotFound = commands.ErrProjectNotFound
+	// ErrNotInitialized means the command needs a synced project
+	ErrNotInitialized = commands.ErrNotInitialized
+	// ErrUnsupportedAgent means an agent name is not copilot, qdev, claude, gemini or codex
+	ErrUnsupportedAgent = commands.ErrUnsupportedAgent
+	// ErrUnknownTemplate means a rule, command or persona is neither embedded nor a custom template
+	ErrUnknownTemplate = commands.ErrUnknownTemplate
+	// ErrInputRequired means the command needs input it would prompt for without Options.Interactive
+	ErrInputRequired = commands.ErrInputRequired
+)
+
+// Error is returned by Load, the Project methods and the functions on the user's templates
+// and registry
+type Error struct {
+	Op  string // load, sync, add rule, remove mcp, sync all, ... (the command that failed)
+	Dir string // project directory; empty for the user-level functions
+	Err error
+	// Result holds what the command reported before it failed
+	Result Result
+}
+
+func (e *Error) Error() string {
+	if e.Dir == "" {
+		return fmt.Sprintf("%s: %v", e.Op, e.Err)
+	}
+	return fmt.Sprintf("%s %s: %v", e.Op, e.Dir, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,55 @@
+package anyagent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shibukawa/anyagent/internal/commands"
+	"github.com/shibukawa/anyagent/internal/config"
+)
+
+// SyncAll re-syncs every project in the user's project registry (the projects synced on this
+// machine). It never prompts; Data is a []SyncAllResult with one row per project, and the
+// error reports how many projects failed.
+func SyncAll(ctx context.Context, opts Options, syncOpts SyncAllOptions) (*Result, error) {
+	return runUser(ctx, "sync all", opts, func(s *commands.Session) error {
+		return s.RunSyncAll(syncOpts.DryRun, syncOpts.Force)
+	})
+}
+
+// ListProjects reports the projects in the user's project registry; Data is a []RegisteredProject
+func ListProjects(ctx context.Context, opts Options) (*Result, error) {
+	return runUser(ctx, "list projects", opts, func(s *commands.Session) error {
+		return s.RunListProjects()
+	})
+}
+
+// PruneProjects removes the projects whose directory no longer exists from the registry
+func PruneProjects(ctx context.Context, opts Options, pruneOpts PruneOptions) (*Result, error) {
+	return runUser(ctx, "prune projects", opts, func(s *commands.Session) error {
+		return s.RunPruneProjects(pruneOpts.DryRun)
+	})
+}
+
+// EditTemplates creates or updates the user templates in the user config directory; Data is
+// a *Templates with the directory, for the caller to open in an editor
+func EditTemplates(ctx context.Context, opts Options, editOpts EditTemplatesOptions) (*Result, error) {
+	return runUser(ctx, "edit templates", opts, func(s *commands.Session) error {
+		configDir, err := config.GetUserConfigDir(s.Env)
+		if err != nil {
+			return fmt.Errorf("failed to get user config directory: %w", err)
+		}
+		s.Logger.Infof("Using config directory: %s\n", configDir)
+		return s.RunEditTemplate(configDir, editOpts.Force)
+	})
+}
+
+// runUser runs a command that works on the user's templates or registry rather than a project
+func runUser(ctx context.Context, op string, opts Options, fn func(s *commands.Session) error) (*Result, error) {
+	report, err := runSession(ctx, op, "", opts, newEnv(opts, ""), fn)
+	if err != nil {
+		return nil, err
+	}
+	result := newResult(report)
+	return &result, nil
+}
@@ -0,0 +1,373 @@
+package anyagent
+
+import (
+	"time"
+
+	"github.com/shibukawa/anyagent/internal/commands"
+)
+
+// SyncOptions configures Sync and Plan
+type SyncOptions struct {
+	// Agents replaces the enabled agent (only one agent can be enabled); empty keeps the recorded one
+	Agents []string
+	// Force regenerates files even when they look up to date
+	Force bool
+	// DryRun reports the planned changes without writing anything
+	DryRun bool
+	// ProjectName and ProjectDescription are used when Sync initializes a new project; the
+	// name defaults to the directory name
+	ProjectName        string
+	ProjectDescription string
+	// Parameters sets template parameters ({{KEY}} in AGENTS.md) in .anyagent/config.yaml
+	Parameters map[string]string
+}
+
+// AddOptions configures AddRule, AddPersona and AddHook
+type AddOptions struct {
+	DryRun bool
+}
+
+// RemoveOptions configures the Remove methods
+type RemoveOptions struct {
+	DryRun bool
+}
+
+// AddCommandOptions configures AddCommand
+type AddCommandOptions struct {
+	DryRun bool
+	// Global installs the command into the agent's user directory instead of the project
+	Global bool
+}
+
+// AddMCPOptions configures AddMCP
+type AddMCPOptions struct {
+	DryRun bool
+	// Global writes the server to the agents' user MCP configs
+	Global bool
+	// Force stores literal values that look like secrets
+	Force bool
+}
+
+// SwitchOptions configures Switch
+type SwitchOptions struct {
+	DryRun bool
+}
+
+// CheckMCPOptions configures CheckMCP
+type CheckMCPOptions struct {
+	Names   []string      // servers to check; empty checks all
+	Timeout time.Duration // time allowed for each server to answer
+}
+
+// ImportMCPOptions configures ImportMCP
+type ImportMCPOptions struct {
+	// From imports only from this agent (copilot, vscode, claude, cursor, gemini, qdev, codex)
+	// or file; empty imports from every config found
+	From   string
+	DryRun bool
+	// Force replaces recorded servers whose definitions differ
+	Force bool
+}
+
+// AdoptOptions configures Adopt
+type AdoptOptions struct {
+	// Agent is the agent to enable; empty infers it from the files
+	Agent  string
+	DryRun bool
+	// Force adopts again even if .anyagent/config.yaml exists
+	Force bool
+}
+
+// WatchOptions configures Watch
+type WatchOptions struct {
+	// Agents and Force apply to the initial sync as in SyncOptions
+	Agents []string
+	Force  bool
+}
+
+// SyncAllOptions configures SyncAll
+type SyncAllOptions struct {
+	DryRun bool
+	// Force overwrites generated files edited by hand; a project's .anyagent is never reset
+	Force bool
+}
+
+// PruneOptions configures PruneProjects
+type PruneOptions struct {
+	DryRun bool
+}
+
+// EditTemplatesOptions configures EditTemplates
+type EditTemplatesOptions struct {
+	// Force resets every user template to the built-in version
+	Force bool
+}
+
+// MCPServer describes an MCP server for AddMCP, like the flags of 'anyagent add mcp'
+type MCPServer struct {
+	Name   string
+	Preset string // catalog preset id; Name defaults to it
+	// Command is the command line launching a stdio server (POSIX shell quoting); Args are
+	// appended verbatim
+	Command string
+	Args    []string
+	Env     map[string]string
+	// URL is the endpoint of a remote (http/sse) server
+	URL            string
+	Headers        map[string]string
+	Transport      string // stdio, http or sse (default: stdio, or http when URL is set)
+	StartupTimeout int    // seconds
+	ToolTimeout    int    // seconds
+	Agents         []string
+	ExcludeAgents  []string
+	Disabled       bool
+}
+
+// Operation is a change a dry run planned. Action is one of create, write, update, remove,
+// symlink, mkdir, copy, record, unregister and launch.
+type Operation struct {
+	Action  string `json:"action"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// Result is what a command reported besides its error
+type Result struct {
+	Operations []Operation `json:"operations"`
+	Warnings   []string    `json:"warnings"`
+	// Data is the command's own report, the data of the CLI's --output json document; the
+	// method documents its type. It is nil for commands that report nothing else.
+	Data any `json:"-"`
+}
+
+// RuleList is the Data of ListRules
+type RuleList struct {
+	Dir         string       `json:"project_dir"`
+	Initialized bool         `json:"initialized"`
+	Agent       string       `json:"agent,omitempty"`
+	Rules       []RuleStatus `json:"rules"`
+}
+
+// RuleStatus is a rule in a RuleList
+type RuleStatus struct {
+	Name      string `json:"name"`
+	Installed bool   `json:"installed"`
+}
+
+// CommandList is the Data of ListCommands
+type CommandList struct {
+	Dir         string           `json:"project_dir"`
+	Initialized bool             `json:"initialized"`
+	Commands    []TemplateStatus `json:"commands"`
+}
+
+// PersonaList is the Data of ListPersonas
+type PersonaList struct {
+	Dir         string           `json:"project_dir"`
+	Initialized bool             `json:"initialized"`
+	Personas    []TemplateStatus `json:"personas"`
+}
+
+// HookList is the Data of ListHooks
+type HookList struct {
+	Dir         string           `json:"project_dir"`
+	Initialized bool             `json:"initialized"`
+	Hooks       []TemplateStatus `json:"hooks"`
+}
+
+// TemplateStatus is a command, persona or hook template in a list and whether the project
+// has it installed
+type TemplateStatus struct {
+	Name      string `json:"name"`
+	Source    string `json:"source"` // template layer: project, user or builtin
+	Installed bool   `json:"installed"`
+}
+
+// Template is a command, persona or hook template in the Data of AvailableCommands,
+// AvailablePersonas and AvailableHooks
+type Template struct {
+	Name   string `json:"name"`
+	Source string `json:"source"` // template layer: project, user or builtin
+}
+
+// MCPList is the Data of ListMCP
+type MCPList struct {
+	Dir         string            `json:"project_dir"`
+	Initialized bool              `json:"initialized"`
+	Agents      []string          `json:"agents"`
+	Servers     []MCPServerStatus `json:"servers"`
+}
+
+// MCPServerStatus is a server in an MCPList
+type MCPServerStatus struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Disabled    bool             `json:"disabled"`
+	Agents      []MCPAgentStatus `json:"agents"`
+}
+
+// MCPAgentStatus is the install status of an MCPServerStatus for one agent: installed,
+// missing or excluded (by the server's agents settings)
+type MCPAgentStatus struct {
+	Agent  string `json:"agent"`
+	Status string `json:"status"`
+}
+
+// MCPPreset is a catalog entry in the Data of MCPPresets
+type MCPPreset struct {
+	ID          string         `json:"id"`
+	Source      string         `json:"source"` // template layer: project, user or builtin
+	Description string         `json:"description,omitempty"`
+	Server      string         `json:"server"` // command line, or transport and URL
+	Env         []MCPPresetEnv `json:"env"`
+	Agents      []string       `json:"agents"` // recommended agents; empty means all
+}
+
+// MCPPresetEnv is an environment variable an MCPPreset asks for
+type MCPPresetEnv struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required"`
+	Secret      bool   `json:"secret"`
+}
+
+// RegisteredProject is a project in the Data of ListProjects
+type RegisteredProject struct {
+	Dir      string    `json:"path"`
+	Name     string    `json:"name,omitempty"`
+	Agents   []string  `json:"agents"`
+	LastSync time.Time `json:"last_sync"`
+	Missing  bool      `json:"missing"` // the directory no longer exists
+}
+
+// SyncAllResult is a project in the Data of SyncAll
+type SyncAllResult struct {
+	Dir     string   `json:"path"`
+	Status  string   `json:"status"` // updated, unchanged, dry-run, missing or failed
+	Changed []string `json:"changed,omitempty"`
+	Err     error    `json:"-"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Templates is the Data of EditTemplates
+type Templates struct {
+	Dir string `json:"config_dir"` // user config directory holding the templates
+}
+
+// Installed lists the rules, commands, personas, hooks and MCP servers a project records
+type Installed struct {
+	Rules      []string `json:"rules"`
+	Commands   []string `json:"commands"`
+	Personas   []string `json:"personas"`
+	Hooks      []string `json:"hooks"`
+	MCPServers []string `json:"mcp_servers"`
+}
+
+// SyncResult is the result of Sync and Plan
+type SyncResult struct {
+	Result        `json:"-"`
+	Dir           string    `json:"project_dir"`
+	DryRun        bool      `json:"dry_run"`
+	FirstSync     bool      `json:"first_sync"`
+	Agents        []string  `json:"agents"`
+	RemovedAgents []string  `json:"removed_agents"`
+	Installed     Installed `json:"installed"`
+}
+
+// Status is the result of Status
+type Status struct {
+	Result      `json:"-"`
+	Dir         string     `json:"project_dir"`
+	Initialized bool       `json:"initialized"`
+	Name        string     `json:"project_name,omitempty"`
+	Agents      []string   `json:"agents"`
+	Installed   Installed  `json:"installed"`
+	Workspaces  []string   `json:"workspaces"`
+	Registered  bool       `json:"registered"`
+	LastSync    *time.Time `json:"last_sync,omitempty"`
+}
+
+func newResult(report commands.Report) Result {
+	result := Result{Operations: []Operation{}, Warnings: append([]string{}, report.Warnings...), Data: newData(report.Data)}
+	for _, op := range report.Operations {
+		result.Operations = append(result.Operations, Operation(op))
+	}
+	return result
+}
+
+// newData converts what a command reported to the public type its method documents. Sync and
+// Status report through their own result types, so their data is dropped here.
+func newData(data any) any {
+	switch data := data.(type) {
+	case *commands.RuleList:
+		list := &RuleList{Dir: data.ProjectDir, Initialized: data.Initialized, Agent: data.Agent, Rules: []RuleStatus{}}
+		for _, r := range data.Rules {
+			list.Rules = append(list.Rules, RuleStatus(r))
+		}
+		return list
+	case *commands.CommandList:
+		list := &CommandList{Dir: data.ProjectDir, Initialized: data.Initialized, Commands: []TemplateStatus{}}
+		for _, c := range data.Commands {
+			list.Commands = append(list.Commands, TemplateStatus(c))
+		}
+		return list
+	case *commands.PersonaList:
+		list := &PersonaList{Dir: data.ProjectDir, Initialized: data.Initialized, Personas: []TemplateStatus{}}
+		for _, p := range data.Personas {
+			list.Personas = append(list.Personas, TemplateStatus(p))
+		}
+		return list
+	case *commands.HookList:
+		list := &HookList{Dir: data.ProjectDir, Initialized: data.Initialized, Hooks: []TemplateStatus{}}
+		for _, h := range data.Hooks {
+			list.Hooks = append(list.Hooks, TemplateStatus(h))
+		}
+		return list
+	case []commands.AvailableTemplate:
+		list := []Template{}
+		for _, t := range data {
+			list = append(list, Template(t))
+		}
+		return list
+	case *commands.MCPList:
+		list := &MCPList{Dir: data.ProjectDir, Initialized: data.Initialized, Agents: append([]string{}, data.Agents...), Servers: []MCPServerStatus{}}
+		for _, server := range data.Servers {
+			status := MCPServerStatus{Name: server.Name, Description: server.Description, Disabled: server.Disabled, Agents: []MCPAgentStatus{}}
+			for _, a := range server.Agents {
+				status.Agents = append(status.Agents, MCPAgentStatus(a))
+			}
+			list.Servers = append(list.Servers, status)
+		}
+		return list
+	case []commands.MCPPresetInfo:
+		list := []MCPPreset{}
+		for _, p := range data {
+			preset := MCPPreset{ID: p.ID, Source: p.Source, Description: p.Description, Server: p.Server, Env: []MCPPresetEnv{}, Agents: append([]string{}, p.Agents...)}
+			for _, e := range p.Env {
+				preset.Env = append(preset.Env, MCPPresetEnv(e))
+			}
+			list = append(list, preset)
+		}
+		return list
+	case []commands.ProjectInfo:
+		list := []RegisteredProject{}
+		for _, p := range data {
+			list = append(list, RegisteredProject{Dir: p.Path, Name: p.Name, Agents: append([]string{}, p.Agents...), LastSync: p.LastSync, Missing: p.Missing})
+		}
+		return list
+	case []commands.SyncAllResult:
+		list := []SyncAllResult{}
+		for _, r := range data {
+			list = append(list, SyncAllResult{Dir: r.Path, Status: r.Status, Changed: r.Changed, Err: r.Err, Error: r.Error})
+		}
+		return list
+	case *commands.TemplateEnvironment:
+		return &Templates{Dir: data.ConfigDir}
+	}
+	return nil
+}
+
+func newInstalled(items commands.InstalledItems) Installed {
+	return Installed(items)
+}